@@ -1,11 +1,58 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
 	"github.com/cloudparallax/parallax/internal/config"
 	"github.com/cloudparallax/parallax/web/app"
 )
 
 func main() {
-	config.LoadEnvConfig()
-	app.LoadApp()
+	// parallax config print [flags] shows the effective configuration with secrets redacted
+	if len(os.Args) > 2 && os.Args[1] == "config" && os.Args[2] == "print" {
+		cfg, err := config.Load(os.Args[3:])
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		if printErr := cfg.Print(os.Stdout); printErr != nil {
+			fmt.Fprintln(os.Stderr, printErr)
+			os.Exit(1)
+		}
+		exitOnInvalid(err)
+		return
+	}
+
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	exitOnInvalid(err)
+	app.LoadApp(cfg)
+}
+
+// exitOnInvalid reports every configuration problem and exits
+func exitOnInvalid(err error) {
+	if err == nil {
+		return
+	}
+	for _, problem := range problems(err) {
+		fmt.Fprintf(os.Stderr, "invalid configuration: %v\n", problem)
+	}
+	os.Exit(1)
+}
+
+// problems flattens joined errors
+func problems(err error) []error {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+	var flat []error
+	for _, inner := range joined.Unwrap() {
+		flat = append(flat, problems(inner)...)
+	}
+	return flat
 }
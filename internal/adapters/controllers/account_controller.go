@@ -0,0 +1,136 @@
+package controllers
+
+import (
+	"github.com/cloudparallax/parallax/internal/adapters/http/dto"
+	"github.com/cloudparallax/parallax/internal/adapters/http/middleware"
+	"github.com/cloudparallax/parallax/internal/usecases"
+	"github.com/gofiber/fiber/v3"
+)
+
+// AccountController handles password reset and email verification HTTP requests
+type AccountController struct {
+	accountUseCase *usecases.AccountUseCase
+	middleware     *middleware.MiddlewareManager
+}
+
+// NewAccountController creates a new account controller
+func NewAccountController(accountUseCase *usecases.AccountUseCase, middleware *middleware.MiddlewareManager) *AccountController {
+	return &AccountController{
+		accountUseCase: accountUseCase,
+		middleware:     middleware,
+	}
+}
+
+// ForgotPassword emails a password reset link.
+// The response is the same whether or not the email belongs to an account.
+func (ac *AccountController) ForgotPassword(c fiber.Ctx) error {
+	var req dto.ForgotPasswordRequest
+	if err := c.Bind().JSON(&req); err != nil || req.Email == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid request body",
+			},
+		})
+	}
+
+	// The email is sent in the background and failures are only logged, so the answer never reveals the account
+	_ = ac.accountUseCase.RequestPasswordReset(c.RequestCtx(), req.Email)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "If an account exists for that email, a password reset link has been sent",
+	})
+}
+
+// ResetPassword sets a new password with a reset token and signs the user out everywhere
+func (ac *AccountController) ResetPassword(c fiber.Ctx) error {
+	var req dto.ResetPasswordRequest
+	if err := c.Bind().JSON(&req); err != nil || req.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid request body",
+			},
+		})
+	}
+
+	user, err := ac.accountUseCase.ResetPassword(c.RequestCtx(), req.Token, req.Password)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": err.Error(),
+			},
+		})
+	}
+
+	ac.middleware.RevokeUserSessions(c.RequestCtx(), user.ID.String())
+	ac.middleware.UnlockLogin(user.Email)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Password has been reset",
+	})
+}
+
+// VerifyEmail marks the email address as verified with a verification token
+func (ac *AccountController) VerifyEmail(c fiber.Ctx) error {
+	var req dto.VerifyEmailRequest
+	if err := c.Bind().JSON(&req); err != nil || req.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid request body",
+			},
+		})
+	}
+
+	if _, err := ac.accountUseCase.VerifyEmail(c.RequestCtx(), req.Token); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": err.Error(),
+			},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Email address verified",
+	})
+}
+
+// ResendVerification emails a new verification link to the signed-in user
+func (ac *AccountController) ResendVerification(c fiber.Ctx) error {
+	userID, ok := sessionUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusUnauthorized,
+				"message": "Authentication required",
+			},
+		})
+	}
+
+	if err := ac.accountUseCase.SendVerificationEmail(c.RequestCtx(), userID); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": err.Error(),
+			},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Verification email sent",
+	})
+}
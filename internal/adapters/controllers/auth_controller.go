@@ -0,0 +1,730 @@
+package controllers
+
+import (
+	"errors"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/cloudparallax/parallax/internal/adapters/http/dto"
+	"github.com/cloudparallax/parallax/internal/adapters/http/middleware"
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/cloudparallax/parallax/internal/usecases"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// AuthController handles authentication HTTP requests
+type AuthController struct {
+	authUseCase *usecases.AuthUseCase
+	middleware  *middleware.MiddlewareManager
+}
+
+// NewAuthController creates a new authentication controller
+func NewAuthController(authUseCase *usecases.AuthUseCase, middleware *middleware.MiddlewareManager) *AuthController {
+	return &AuthController{
+		authUseCase: authUseCase,
+		middleware:  middleware,
+	}
+}
+
+// Login verifies a password and starts a session.
+// Users who need a second factor get a session that stays pending until it is verified.
+func (ac *AuthController) Login(c fiber.Ctx) error {
+	var req dto.LoginRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid request body",
+			},
+		})
+	}
+
+	if wait, allowed := ac.middleware.CheckLogin(req.Username, c.IP()); !allowed {
+		return ac.tooManyAttempts(c, wait)
+	}
+
+	user, err := ac.authUseCase.Authenticate(c.RequestCtx(), req.Username, req.Password)
+	if err != nil {
+		ac.middleware.RecordLoginFailure(req.Username, c.IP())
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusUnauthorized,
+				"message": "Invalid credentials",
+			},
+		})
+	}
+
+	userData := ac.sessionData(user)
+	mfaRequired := ac.authUseCase.RequiresMFA(c.RequestCtx(), user)
+	if mfaRequired {
+		userData["mfa_pending"] = true
+	} else {
+		ac.middleware.RecordLoginSuccess(req.Username)
+	}
+
+	if err := ac.middleware.Login(c, user.ID.String(), userData); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusInternalServerError,
+				"message": "Failed to create session",
+			},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"user":                    userData,
+			"mfa_required":            mfaRequired,
+			"mfa_enrollment_required": mfaRequired && !user.TOTPEnabled,
+		},
+	})
+}
+
+// Logout logs out a user
+func (ac *AuthController) Logout(c fiber.Ctx) error {
+	if err := ac.middleware.Logout(c); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusInternalServerError,
+				"message": "Failed to logout",
+			},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Logged out successfully",
+	})
+}
+
+// IssueToken exchanges user credentials for a signed access token and a refresh token
+func (ac *AuthController) IssueToken(c fiber.Ctx) error {
+	var req dto.TokenRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid request body",
+			},
+		})
+	}
+
+	if wait, allowed := ac.middleware.CheckLogin(req.Username, c.IP()); !allowed {
+		return ac.tooManyAttempts(c, wait)
+	}
+
+	user, err := ac.authUseCase.Authenticate(c.RequestCtx(), req.Username, req.Password)
+	if err != nil {
+		ac.middleware.RecordLoginFailure(req.Username, c.IP())
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusUnauthorized,
+				"message": "Invalid credentials",
+			},
+		})
+	}
+
+	if ac.authUseCase.RequiresMFA(c.RequestCtx(), user) {
+		if !user.TOTPEnabled {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    fiber.StatusForbidden,
+					"message": "Two-factor enrollment required",
+				},
+			})
+		}
+
+		if err := ac.authUseCase.VerifyMFA(c.RequestCtx(), user.ID, req.MFACode); err != nil {
+			ac.middleware.RecordLoginFailure(req.Username, c.IP())
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    fiber.StatusUnauthorized,
+					"message": "Two-factor authentication required",
+				},
+			})
+		}
+	}
+
+	ac.middleware.RecordLoginSuccess(req.Username)
+
+	tokens, err := ac.middleware.IssueTokens(c.RequestCtx(), user.ID.String(), req.Scopes)
+	if errors.Is(err, middleware.ErrScopeNotAllowed) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": err.Error(),
+			},
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusInternalServerError,
+				"message": "Failed to issue tokens",
+			},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    tokens,
+	})
+}
+
+// RefreshToken rotates a refresh token and returns a new token pair
+func (ac *AuthController) RefreshToken(c fiber.Ctx) error {
+	var req dto.RefreshTokenRequest
+	if err := c.Bind().JSON(&req); err != nil || req.RefreshToken == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid request body",
+			},
+		})
+	}
+
+	tokens, err := ac.middleware.RefreshTokens(c.RequestCtx(), req.RefreshToken)
+	if err != nil && !errors.Is(err, middleware.ErrInvalidRefreshToken) && !errors.Is(err, middleware.ErrRefreshTokenReused) {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusServiceUnavailable,
+				"message": "Failed to refresh tokens",
+			},
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusUnauthorized,
+				"message": err.Error(),
+			},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    tokens,
+	})
+}
+
+// RevokeToken revokes a refresh token and every token rotated from it
+func (ac *AuthController) RevokeToken(c fiber.Ctx) error {
+	var req dto.RefreshTokenRequest
+	if err := c.Bind().JSON(&req); err != nil || req.RefreshToken == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid request body",
+			},
+		})
+	}
+
+	if err := ac.middleware.RevokeRefreshToken(c.RequestCtx(), req.RefreshToken); err != nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusServiceUnavailable,
+				"message": "Failed to revoke token",
+			},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Token revoked successfully",
+	})
+}
+
+// VerifyMFA completes a pending login with a TOTP or recovery code
+func (ac *AuthController) VerifyMFA(c fiber.Ctx) error {
+	userID, ok := sessionUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusUnauthorized,
+				"message": "Authentication required",
+			},
+		})
+	}
+
+	var req dto.MFACodeRequest
+	if err := c.Bind().JSON(&req); err != nil || req.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid request body",
+			},
+		})
+	}
+
+	// Second-factor guesses count towards the same lockout as password guesses
+	username := sessionUsername(c)
+	if wait, allowed := ac.middleware.CheckLogin(username, c.IP()); !allowed {
+		return ac.tooManyAttempts(c, wait)
+	}
+
+	if err := ac.authUseCase.VerifyMFA(c.RequestCtx(), userID, req.Code); err != nil {
+		ac.middleware.RecordLoginFailure(username, c.IP())
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusUnauthorized,
+				"message": err.Error(),
+			},
+		})
+	}
+	ac.middleware.RecordLoginSuccess(username)
+
+	if err := ac.middleware.CompleteMFA(c); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusInternalServerError,
+				"message": "Failed to update session",
+			},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Two-factor authentication verified",
+	})
+}
+
+// EnrollTOTP starts TOTP enrollment and returns the secret and provisioning URI
+func (ac *AuthController) EnrollTOTP(c fiber.Ctx) error {
+	userID, ok := sessionUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusUnauthorized,
+				"message": "Authentication required",
+			},
+		})
+	}
+
+	secret, uri, err := ac.authUseCase.BeginTOTPEnrollment(c.RequestCtx(), userID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": err.Error(),
+			},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": dto.TOTPEnrollmentResponse{
+			Secret:          secret,
+			ProvisioningURI: uri,
+		},
+	})
+}
+
+// ConfirmTOTP activates TOTP enrollment and returns one-time recovery codes.
+// Confirming also completes a login that was pending because of tenant policy.
+func (ac *AuthController) ConfirmTOTP(c fiber.Ctx) error {
+	userID, ok := sessionUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusUnauthorized,
+				"message": "Authentication required",
+			},
+		})
+	}
+
+	var req dto.MFACodeRequest
+	if err := c.Bind().JSON(&req); err != nil || req.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid request body",
+			},
+		})
+	}
+
+	codes, err := ac.authUseCase.ConfirmTOTPEnrollment(c.RequestCtx(), userID, req.Code)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": err.Error(),
+			},
+		})
+	}
+
+	if sessionMFAPending(c) {
+		if err := ac.middleware.CompleteMFA(c); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    fiber.StatusInternalServerError,
+					"message": "Failed to update session",
+				},
+			})
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": dto.RecoveryCodesResponse{
+			RecoveryCodes: codes,
+		},
+	})
+}
+
+// DisableTOTP removes the user's authenticator
+func (ac *AuthController) DisableTOTP(c fiber.Ctx) error {
+	userID, ok := sessionUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusUnauthorized,
+				"message": "Authentication required",
+			},
+		})
+	}
+
+	var req dto.MFACodeRequest
+	if err := c.Bind().JSON(&req); err != nil || req.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid request body",
+			},
+		})
+	}
+
+	// Wrong codes count towards the sign-in lockout, as in VerifyMFA
+	username := sessionUsername(c)
+	if wait, allowed := ac.middleware.CheckLogin(username, c.IP()); !allowed {
+		return ac.tooManyAttempts(c, wait)
+	}
+
+	if err := ac.authUseCase.DisableTOTP(c.RequestCtx(), userID, req.Code); err != nil {
+		if errors.Is(err, usecases.ErrInvalidMFACode) {
+			ac.middleware.RecordLoginFailure(username, c.IP())
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": err.Error(),
+			},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes
+func (ac *AuthController) RegenerateRecoveryCodes(c fiber.Ctx) error {
+	userID, ok := sessionUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusUnauthorized,
+				"message": "Authentication required",
+			},
+		})
+	}
+
+	var req dto.MFACodeRequest
+	if err := c.Bind().JSON(&req); err != nil || req.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid request body",
+			},
+		})
+	}
+
+	// Wrong codes count towards the sign-in lockout, as in VerifyMFA
+	username := sessionUsername(c)
+	if wait, allowed := ac.middleware.CheckLogin(username, c.IP()); !allowed {
+		return ac.tooManyAttempts(c, wait)
+	}
+
+	codes, err := ac.authUseCase.RegenerateRecoveryCodes(c.RequestCtx(), userID, req.Code)
+	if err != nil {
+		if errors.Is(err, usecases.ErrInvalidMFACode) {
+			ac.middleware.RecordLoginFailure(username, c.IP())
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": err.Error(),
+			},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": dto.RecoveryCodesResponse{
+			RecoveryCodes: codes,
+		},
+	})
+}
+
+// ChangePassword changes the signed-in user's password and signs out their other sessions
+func (ac *AuthController) ChangePassword(c fiber.Ctx) error {
+	userID, ok := sessionUserID(c)
+	if !ok {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusUnauthorized,
+				"message": "Authentication required",
+			},
+		})
+	}
+
+	var req dto.ChangePasswordRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid request body",
+			},
+		})
+	}
+
+	user, err := ac.authUseCase.ChangePassword(c.RequestCtx(), userID, req.CurrentPassword, req.NewPassword)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": err.Error(),
+			},
+		})
+	}
+
+	ac.middleware.RevokeUserSessions(c.RequestCtx(), user.ID.String())
+	if c.Locals("auth_method") == "session" {
+		if err := ac.middleware.Login(c, user.ID.String(), ac.sessionData(user)); err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    fiber.StatusInternalServerError,
+					"message": "Failed to create session",
+				},
+			})
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Password changed successfully",
+	})
+}
+
+// Impersonate starts a session as another user (admin only)
+func (ac *AuthController) Impersonate(c fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid user ID",
+			},
+		})
+	}
+
+	user, err := ac.authUseCase.GetUser(c.RequestCtx(), id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusNotFound,
+				"message": "User not found",
+			},
+		})
+	}
+
+	// Admins cannot borrow another admin's privileges or act as a disabled account
+	if user.Role == middleware.PlatformAdminRole || !user.IsActive {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusForbidden,
+				"message": "This user cannot be impersonated",
+			},
+		})
+	}
+
+	userData := ac.sessionData(user)
+	if err := ac.middleware.Impersonate(c, user.ID.String(), userData); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": err.Error(),
+			},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"user":          userData,
+			"impersonating": true,
+		},
+	})
+}
+
+// StopImpersonation ends an impersonation and returns the admin to their own session
+func (ac *AuthController) StopImpersonation(c fiber.Ctx) error {
+	adminID, err := ac.middleware.StopImpersonation(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": err.Error(),
+			},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"user_id":       adminID,
+			"impersonating": false,
+		},
+	})
+}
+
+// UnlockUser lifts a user's sign-in lockout (admin only)
+func (ac *AuthController) UnlockUser(c fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid user ID",
+			},
+		})
+	}
+
+	user, err := ac.authUseCase.GetUser(c.RequestCtx(), id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusNotFound,
+				"message": "User not found",
+			},
+		})
+	}
+
+	unlocked := ac.middleware.UnlockLogin(user.Email)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"user_id":  user.ID,
+			"unlocked": unlocked,
+		},
+	})
+}
+
+// tooManyAttempts rejects a throttled sign-in without revealing whether the account exists
+func (ac *AuthController) tooManyAttempts(c fiber.Ctx, wait time.Duration) error {
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"success": false,
+		"error": fiber.Map{
+			"code":    fiber.StatusTooManyRequests,
+			"message": "Too many failed sign-in attempts, try again later",
+		},
+	})
+}
+
+// sessionData builds the session data stored for a signed-in user
+func (ac *AuthController) sessionData(user *entities.User) map[string]interface{} {
+	return map[string]interface{}{
+		"username":  user.Email,
+		"email":     user.Email,
+		"role":      user.Role,
+		"tenant_id": tenantIDString(user),
+	}
+}
+
+// sessionUserID returns the ID of the user who owns the current session
+func sessionUserID(c fiber.Ctx) (uuid.UUID, bool) {
+	userID, ok := c.Locals("user_id").(string)
+	if !ok {
+		return uuid.Nil, false
+	}
+
+	id, err := uuid.Parse(userID)
+	return id, err == nil
+}
+
+// sessionUsername returns the username stored in the current session
+func sessionUsername(c fiber.Ctx) string {
+	session, ok := c.Locals("session").(*middleware.Session)
+	if !ok {
+		return ""
+	}
+
+	username, _ := session.Data["username"].(string)
+	return username
+}
+
+// sessionMFAPending reports whether the current session still awaits a second factor
+func sessionMFAPending(c fiber.Ctx) bool {
+	session, ok := c.Locals("session").(*middleware.Session)
+	if !ok {
+		return false
+	}
+
+	pending, _ := session.Data["mfa_pending"].(bool)
+	return pending
+}
+
+// tenantIDString returns the user's tenant ID, or an empty string for platform users
+func tenantIDString(user *entities.User) string {
+	if user.TenantID == uuid.Nil {
+		return ""
+	}
+	return user.TenantID.String()
+}
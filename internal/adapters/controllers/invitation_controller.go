@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/cloudparallax/parallax/internal/adapters/http/dto"
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/cloudparallax/parallax/internal/usecases"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// InvitationController handles tenant invitation HTTP requests
+type InvitationController struct {
+	invitationUseCase *usecases.InvitationUseCase
+}
+
+// NewInvitationController creates a new invitation controller
+func NewInvitationController(invitationUseCase *usecases.InvitationUseCase) *InvitationController {
+	return &InvitationController{
+		invitationUseCase: invitationUseCase,
+	}
+}
+
+// CreateInvitation invites someone to join a tenant
+func (ic *InvitationController) CreateInvitation(c fiber.Ctx) error {
+	tenantID, err := uuid.Parse(c.Params("tenantId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid tenant ID",
+			},
+		})
+	}
+
+	var req dto.CreateInvitationRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid request body",
+			},
+		})
+	}
+
+	inviterID, _ := sessionUserID(c)
+
+	invitation, err := ic.invitationUseCase.CreateInvitation(c.RequestCtx(), tenantID, inviterID, req.Email, req.Role, actorRole(c))
+	if err != nil {
+		status := fiber.StatusBadRequest
+		if errors.Is(err, usecases.ErrRoleNotAssignable) {
+			status = fiber.StatusForbidden
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    status,
+				"message": err.Error(),
+			},
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    ic.toInvitationResponse(invitation),
+	})
+}
+
+// GetInvitations retrieves a tenant's invitations with pagination
+func (ic *InvitationController) GetInvitations(c fiber.Ctx) error {
+	tenantID, err := uuid.Parse(c.Params("tenantId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid tenant ID",
+			},
+		})
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	offset, _ := strconv.Atoi(c.Query("offset", "0"))
+
+	if limit > 100 {
+		limit = 100
+	}
+
+	invitations, err := ic.invitationUseCase.ListInvitations(c.RequestCtx(), tenantID, limit, offset)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusInternalServerError,
+				"message": "Failed to retrieve invitations",
+			},
+		})
+	}
+
+	responses := make([]dto.InvitationResponse, 0, len(invitations))
+	for _, invitation := range invitations {
+		responses = append(responses, ic.toInvitationResponse(invitation))
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    responses,
+		"meta": fiber.Map{
+			"limit":  limit,
+			"offset": offset,
+			"count":  len(responses),
+		},
+	})
+}
+
+// RevokeInvitation revokes a pending invitation
+func (ic *InvitationController) RevokeInvitation(c fiber.Ctx) error {
+	tenantID, err := uuid.Parse(c.Params("tenantId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid tenant ID",
+			},
+		})
+	}
+
+	invitationID, err := uuid.Parse(c.Params("invitationId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid invitation ID",
+			},
+		})
+	}
+
+	invitation, err := ic.invitationUseCase.RevokeInvitation(c.RequestCtx(), tenantID, invitationID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": err.Error(),
+			},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    ic.toInvitationResponse(invitation),
+	})
+}
+
+// AcceptInvitation accepts an invitation, creating an account if the invited email has none
+func (ic *InvitationController) AcceptInvitation(c fiber.Ctx) error {
+	var req dto.AcceptInvitationRequest
+	if err := c.Bind().JSON(&req); err != nil || req.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid request body",
+			},
+		})
+	}
+
+	user, membership, err := ic.invitationUseCase.AcceptInvitation(c.RequestCtx(), req.Token, req.Password, req.FirstName, req.LastName)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": err.Error(),
+			},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": dto.MemberResponse{
+			UserID:      user.ID,
+			TenantID:    membership.TenantID,
+			Email:       user.Email,
+			FirstName:   user.FirstName,
+			LastName:    user.LastName,
+			Role:        membership.Role,
+			Permissions: entities.RolePermissions(membership.Role),
+			IsActive:    user.IsActive,
+			CreatedAt:   membership.CreatedAt,
+			UpdatedAt:   membership.UpdatedAt,
+		},
+	})
+}
+
+// toInvitationResponse converts entity to response DTO
+func (ic *InvitationController) toInvitationResponse(invitation *entities.Invitation) dto.InvitationResponse {
+	return dto.InvitationResponse{
+		ID:         invitation.ID,
+		TenantID:   invitation.TenantID,
+		Email:      invitation.Email,
+		Role:       invitation.Role,
+		Status:     invitation.Status(),
+		InvitedBy:  invitation.InvitedBy,
+		ExpiresAt:  invitation.ExpiresAt,
+		AcceptedAt: invitation.AcceptedAt,
+		RevokedAt:  invitation.RevokedAt,
+		CreatedAt:  invitation.CreatedAt,
+	}
+}
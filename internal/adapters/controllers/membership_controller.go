@@ -0,0 +1,220 @@
+package controllers
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/cloudparallax/parallax/internal/adapters/http/dto"
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/cloudparallax/parallax/internal/usecases"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// MembershipController handles tenant member HTTP requests
+type MembershipController struct {
+	membershipUseCase *usecases.MembershipUseCase
+}
+
+// NewMembershipController creates a new membership controller
+func NewMembershipController(membershipUseCase *usecases.MembershipUseCase) *MembershipController {
+	return &MembershipController{
+		membershipUseCase: membershipUseCase,
+	}
+}
+
+// GetMembers retrieves a tenant's members with pagination
+func (mc *MembershipController) GetMembers(c fiber.Ctx) error {
+	tenantID, err := uuid.Parse(c.Params("tenantId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid tenant ID",
+			},
+		})
+	}
+
+	limit, _ := strconv.Atoi(c.Query("limit", "10"))
+	offset, _ := strconv.Atoi(c.Query("offset", "0"))
+
+	if limit > 100 {
+		limit = 100
+	}
+
+	members, err := mc.membershipUseCase.ListMembers(c.RequestCtx(), tenantID, limit, offset)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusInternalServerError,
+				"message": "Failed to retrieve members",
+			},
+		})
+	}
+
+	responses := make([]dto.MemberResponse, 0, len(members))
+	for _, member := range members {
+		responses = append(responses, mc.toMemberResponse(member))
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    responses,
+		"meta": fiber.Map{
+			"limit":  limit,
+			"offset": offset,
+			"count":  len(responses),
+		},
+	})
+}
+
+// AddMember adds an existing user to a tenant
+func (mc *MembershipController) AddMember(c fiber.Ctx) error {
+	tenantID, err := uuid.Parse(c.Params("tenantId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid tenant ID",
+			},
+		})
+	}
+
+	var req dto.AddMemberRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid request body",
+			},
+		})
+	}
+
+	member, err := mc.membershipUseCase.AddMember(c.RequestCtx(), tenantID, req.Email, req.Role, actorRole(c))
+	if err != nil {
+		return mc.membershipError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data":    mc.toMemberResponse(member),
+	})
+}
+
+// UpdateMemberRole changes a member's role within a tenant
+func (mc *MembershipController) UpdateMemberRole(c fiber.Ctx) error {
+	tenantID, userID, ok := mc.memberParams(c)
+	if !ok {
+		return nil
+	}
+
+	var req dto.UpdateMemberRoleRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid request body",
+			},
+		})
+	}
+
+	member, err := mc.membershipUseCase.UpdateMemberRole(c.RequestCtx(), tenantID, userID, req.Role, actorRole(c))
+	if err != nil {
+		return mc.membershipError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    mc.toMemberResponse(member),
+	})
+}
+
+// RemoveMember removes a user from a tenant
+func (mc *MembershipController) RemoveMember(c fiber.Ctx) error {
+	tenantID, userID, ok := mc.memberParams(c)
+	if !ok {
+		return nil
+	}
+
+	err := mc.membershipUseCase.RemoveMember(c.RequestCtx(), tenantID, userID, actorRole(c))
+	if err != nil {
+		return mc.membershipError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Member removed successfully",
+	})
+}
+
+// memberParams parses the tenant and user IDs from the route, writing an error response if invalid
+func (mc *MembershipController) memberParams(c fiber.Ctx) (uuid.UUID, uuid.UUID, bool) {
+	tenantID, err := uuid.Parse(c.Params("tenantId"))
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid tenant ID",
+			},
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	userID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid user ID",
+			},
+		})
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	return tenantID, userID, true
+}
+
+// membershipError maps membership use case errors to responses
+func (mc *MembershipController) membershipError(c fiber.Ctx, err error) error {
+	status := fiber.StatusBadRequest
+	if errors.Is(err, usecases.ErrRoleNotAssignable) {
+		status = fiber.StatusForbidden
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"success": false,
+		"error": fiber.Map{
+			"code":    status,
+			"message": err.Error(),
+		},
+	})
+}
+
+// toMemberResponse converts a member to response DTO
+func (mc *MembershipController) toMemberResponse(member *usecases.Member) dto.MemberResponse {
+	return dto.MemberResponse{
+		UserID:      member.User.ID,
+		TenantID:    member.Membership.TenantID,
+		Email:       member.User.Email,
+		FirstName:   member.User.FirstName,
+		LastName:    member.User.LastName,
+		Role:        member.Membership.Role,
+		Permissions: entities.RolePermissions(member.Membership.Role),
+		IsActive:    member.User.IsActive,
+		CreatedAt:   member.Membership.CreatedAt,
+		UpdatedAt:   member.Membership.UpdatedAt,
+	}
+}
+
+// actorRole returns the tenant role resolved for the current user by the permission middleware
+func actorRole(c fiber.Ctx) string {
+	role, _ := c.Locals("tenant_role").(string)
+	return role
+}
@@ -0,0 +1,871 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/cloudparallax/parallax/internal/adapters/http/dto"
+	"github.com/cloudparallax/parallax/internal/adapters/http/middleware"
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/cloudparallax/parallax/internal/usecases"
+	"github.com/cloudparallax/parallax/pkg/scim"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// scimMaxResults caps how many resources a single SCIM query returns
+const scimMaxResults = 200
+
+// SCIMController handles SCIM 2.0 provisioning HTTP requests
+type SCIMController struct {
+	scimUseCase *usecases.SCIMUseCase
+	middleware  *middleware.MiddlewareManager
+}
+
+// NewSCIMController creates a new SCIM controller
+func NewSCIMController(scimUseCase *usecases.SCIMUseCase, middleware *middleware.MiddlewareManager) *SCIMController {
+	return &SCIMController{
+		scimUseCase: scimUseCase,
+		middleware:  middleware,
+	}
+}
+
+// Authenticate resolves the tenant from the SCIM bearer token
+func (sc *SCIMController) Authenticate(c fiber.Ctx) error {
+	header := c.Get(fiber.HeaderAuthorization)
+	if len(header) <= 7 || !strings.EqualFold(header[:7], "Bearer ") {
+		c.Set(fiber.HeaderWWWAuthenticate, "Bearer")
+		return sc.error(c, scim.NewError(fiber.StatusUnauthorized, "", "Bearer token required"))
+	}
+
+	tenantID, err := sc.scimUseCase.Authenticate(c.RequestCtx(), strings.TrimSpace(header[7:]))
+	if err != nil {
+		c.Set(fiber.HeaderWWWAuthenticate, "Bearer")
+		return sc.error(c, scim.NewError(fiber.StatusUnauthorized, "", "Invalid provisioning token"))
+	}
+
+	c.Locals("scim_tenant_id", tenantID)
+	return c.Next()
+}
+
+// GetServiceProviderConfig describes the SCIM features this server supports
+func (sc *SCIMController) GetServiceProviderConfig(c fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"schemas":        []string{scim.SchemaServiceProviderConfig},
+		"patch":          fiber.Map{"supported": true},
+		"bulk":           fiber.Map{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         fiber.Map{"supported": true, "maxResults": scimMaxResults},
+		"changePassword": fiber.Map{"supported": false},
+		"sort":           fiber.Map{"supported": false},
+		"etag":           fiber.Map{"supported": false},
+		"authenticationSchemes": []fiber.Map{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer token",
+			"description": "Tenant provisioning token",
+			"primary":     true,
+		}},
+	}, scim.ContentType)
+}
+
+// GetUsers lists the tenant's provisioned users, optionally filtered
+func (sc *SCIMController) GetUsers(c fiber.Ctx) error {
+	filter, err := sc.filter(c)
+	if err != nil {
+		return sc.error(c, scim.NewError(fiber.StatusBadRequest, scim.ErrorInvalidFilter, err.Error()))
+	}
+
+	users, err := sc.scimUseCase.ListUsers(c.RequestCtx(), sc.tenantID(c))
+	if err != nil {
+		return sc.error(c, scim.NewError(fiber.StatusInternalServerError, "", "Failed to list users"))
+	}
+
+	resources := make([]scim.User, 0, len(users))
+	for _, user := range users {
+		resource := sc.toSCIMUser(c, user)
+		if filter == nil || filter.Matches(userAttributes(resource)) {
+			resources = append(resources, resource)
+		}
+	}
+
+	startIndex, count := sc.pagination(c)
+	start, end := scim.Page(startIndex, count, len(resources))
+	return c.JSON(scim.NewListResponse(resources[start:end], len(resources), startIndex, end-start), scim.ContentType)
+}
+
+// GetUser retrieves a provisioned user
+func (sc *SCIMController) GetUser(c fiber.Ctx) error {
+	user, err := sc.user(c)
+	if err != nil {
+		return sc.useCaseError(c, err)
+	}
+
+	return c.JSON(sc.toSCIMUser(c, user), scim.ContentType)
+}
+
+// CreateUser provisions a user into the tenant
+func (sc *SCIMController) CreateUser(c fiber.Ctx) error {
+	var resource scim.User
+	if err := json.Unmarshal(c.Body(), &resource); err != nil {
+		return sc.error(c, scim.NewError(fiber.StatusBadRequest, scim.ErrorInvalidValue, "Invalid request body"))
+	}
+
+	user, err := sc.scimUseCase.CreateUser(c.RequestCtx(), sc.tenantID(c), userInput(resource))
+	if err != nil {
+		return sc.useCaseError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(sc.toSCIMUser(c, user), scim.ContentType)
+}
+
+// ReplaceUser replaces a provisioned user's attributes
+func (sc *SCIMController) ReplaceUser(c fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return sc.useCaseError(c, usecases.ErrSCIMNotFound)
+	}
+
+	var resource scim.User
+	if err := json.Unmarshal(c.Body(), &resource); err != nil {
+		return sc.error(c, scim.NewError(fiber.StatusBadRequest, scim.ErrorInvalidValue, "Invalid request body"))
+	}
+
+	return sc.saveUser(c, userID, resource)
+}
+
+// PatchUser applies add, remove and replace operations to a provisioned user
+func (sc *SCIMController) PatchUser(c fiber.Ctx) error {
+	user, err := sc.user(c)
+	if err != nil {
+		return sc.useCaseError(c, err)
+	}
+
+	var req scim.PatchRequest
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return sc.error(c, scim.NewError(fiber.StatusBadRequest, scim.ErrorInvalidValue, "Invalid request body"))
+	}
+
+	resource := sc.toSCIMUser(c, user)
+	for _, op := range req.Operations {
+		if err := patchUser(&resource, strings.ToLower(op.Op), op.Path, op.Value); err != nil {
+			return sc.useCaseError(c, err)
+		}
+	}
+
+	return sc.saveUser(c, user.ID, resource)
+}
+
+// DeleteUser deprovisions a user, deactivating the account and ending their sessions
+func (sc *SCIMController) DeleteUser(c fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return sc.useCaseError(c, usecases.ErrSCIMNotFound)
+	}
+
+	user, err := sc.scimUseCase.DeleteUser(c.RequestCtx(), sc.tenantID(c), userID)
+	if err != nil {
+		return sc.useCaseError(c, err)
+	}
+
+	sc.middleware.RevokeUserSessions(c.RequestCtx(), user.ID.String())
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GetGroups lists the tenant's directory groups, optionally filtered
+func (sc *SCIMController) GetGroups(c fiber.Ctx) error {
+	filter, err := sc.filter(c)
+	if err != nil {
+		return sc.error(c, scim.NewError(fiber.StatusBadRequest, scim.ErrorInvalidFilter, err.Error()))
+	}
+
+	groups, err := sc.scimUseCase.ListGroups(c.RequestCtx(), sc.tenantID(c))
+	if err != nil {
+		return sc.error(c, scim.NewError(fiber.StatusInternalServerError, "", "Failed to list groups"))
+	}
+
+	excludeMembers := strings.Contains(strings.ToLower(c.Query("excludedAttributes")), "members")
+
+	resources := make([]scim.Group, 0, len(groups))
+	for _, group := range groups {
+		resource := sc.toSCIMGroup(c, group)
+		if filter != nil && !filter.Matches(groupAttributes(resource)) {
+			continue
+		}
+		if excludeMembers {
+			resource.Members = nil
+		}
+		resources = append(resources, resource)
+	}
+
+	startIndex, count := sc.pagination(c)
+	start, end := scim.Page(startIndex, count, len(resources))
+	return c.JSON(scim.NewListResponse(resources[start:end], len(resources), startIndex, end-start), scim.ContentType)
+}
+
+// GetGroup retrieves a directory group
+func (sc *SCIMController) GetGroup(c fiber.Ctx) error {
+	group, err := sc.group(c)
+	if err != nil {
+		return sc.useCaseError(c, err)
+	}
+
+	return c.JSON(sc.toSCIMGroup(c, group), scim.ContentType)
+}
+
+// CreateGroup provisions a directory group
+func (sc *SCIMController) CreateGroup(c fiber.Ctx) error {
+	var resource scim.Group
+	if err := json.Unmarshal(c.Body(), &resource); err != nil {
+		return sc.error(c, scim.NewError(fiber.StatusBadRequest, scim.ErrorInvalidValue, "Invalid request body"))
+	}
+
+	input, err := groupInput(resource)
+	if err != nil {
+		return sc.useCaseError(c, err)
+	}
+
+	group, err := sc.scimUseCase.CreateGroup(c.RequestCtx(), sc.tenantID(c), input)
+	if err != nil {
+		return sc.useCaseError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(sc.toSCIMGroup(c, group), scim.ContentType)
+}
+
+// ReplaceGroup replaces a directory group's attributes and members
+func (sc *SCIMController) ReplaceGroup(c fiber.Ctx) error {
+	groupID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return sc.useCaseError(c, usecases.ErrSCIMNotFound)
+	}
+
+	var resource scim.Group
+	if err := json.Unmarshal(c.Body(), &resource); err != nil {
+		return sc.error(c, scim.NewError(fiber.StatusBadRequest, scim.ErrorInvalidValue, "Invalid request body"))
+	}
+
+	return sc.saveGroup(c, groupID, resource)
+}
+
+// PatchGroup applies add, remove and replace operations to a directory group
+func (sc *SCIMController) PatchGroup(c fiber.Ctx) error {
+	group, err := sc.group(c)
+	if err != nil {
+		return sc.useCaseError(c, err)
+	}
+
+	var req scim.PatchRequest
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return sc.error(c, scim.NewError(fiber.StatusBadRequest, scim.ErrorInvalidValue, "Invalid request body"))
+	}
+
+	resource := sc.toSCIMGroup(c, group)
+	for _, op := range req.Operations {
+		if err := patchGroup(&resource, strings.ToLower(op.Op), op.Path, op.Value); err != nil {
+			return sc.useCaseError(c, err)
+		}
+	}
+
+	return sc.saveGroup(c, group.ID, resource)
+}
+
+// DeleteGroup removes a directory group
+func (sc *SCIMController) DeleteGroup(c fiber.Ctx) error {
+	groupID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return sc.useCaseError(c, usecases.ErrSCIMNotFound)
+	}
+
+	if err := sc.scimUseCase.DeleteGroup(c.RequestCtx(), sc.tenantID(c), groupID); err != nil {
+		return sc.useCaseError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// GenerateToken creates or rotates a tenant's SCIM token; the token is only shown once
+func (sc *SCIMController) GenerateToken(c fiber.Ctx) error {
+	tenantID, err := uuid.Parse(c.Params("tenantId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid tenant ID",
+			},
+		})
+	}
+
+	token, config, err := sc.scimUseCase.GenerateToken(c.RequestCtx(), tenantID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": err.Error(),
+			},
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"data": dto.SCIMTokenResponse{
+			Token:  token,
+			Config: sc.toSCIMConfigResponse(c, config),
+		},
+	})
+}
+
+// GetSCIMConfig retrieves a tenant's SCIM configuration
+func (sc *SCIMController) GetSCIMConfig(c fiber.Ctx) error {
+	tenantID, err := uuid.Parse(c.Params("tenantId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid tenant ID",
+			},
+		})
+	}
+
+	config, err := sc.scimUseCase.GetConfig(c.RequestCtx(), tenantID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusNotFound,
+				"message": "SCIM configuration not found",
+			},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    sc.toSCIMConfigResponse(c, config),
+	})
+}
+
+// SetGroupRoles sets which tenant role each directory group grants
+func (sc *SCIMController) SetGroupRoles(c fiber.Ctx) error {
+	tenantID, err := uuid.Parse(c.Params("tenantId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid tenant ID",
+			},
+		})
+	}
+
+	var req dto.SetSCIMGroupRolesRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid request body",
+			},
+		})
+	}
+
+	config, err := sc.scimUseCase.SetGroupRoles(c.RequestCtx(), tenantID, req.GroupRoles, req.DefaultRole)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": err.Error(),
+			},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    sc.toSCIMConfigResponse(c, config),
+	})
+}
+
+// DeleteSCIMConfig disables provisioning for a tenant and revokes its token
+func (sc *SCIMController) DeleteSCIMConfig(c fiber.Ctx) error {
+	tenantID, err := uuid.Parse(c.Params("tenantId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid tenant ID",
+			},
+		})
+	}
+
+	if err := sc.scimUseCase.DeleteConfig(c.RequestCtx(), tenantID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusNotFound,
+				"message": "SCIM configuration not found",
+			},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "SCIM configuration deleted successfully",
+	})
+}
+
+// saveUser replaces a user from a SCIM resource and ends their sessions if they were deactivated
+func (sc *SCIMController) saveUser(c fiber.Ctx, userID uuid.UUID, resource scim.User) error {
+	user, err := sc.scimUseCase.ReplaceUser(c.RequestCtx(), sc.tenantID(c), userID, userInput(resource))
+	if err != nil {
+		return sc.useCaseError(c, err)
+	}
+
+	if !user.IsActive {
+		sc.middleware.RevokeUserSessions(c.RequestCtx(), user.ID.String())
+	}
+
+	return c.JSON(sc.toSCIMUser(c, user), scim.ContentType)
+}
+
+// saveGroup replaces a group from a SCIM resource
+func (sc *SCIMController) saveGroup(c fiber.Ctx, groupID uuid.UUID, resource scim.Group) error {
+	input, err := groupInput(resource)
+	if err != nil {
+		return sc.useCaseError(c, err)
+	}
+
+	group, err := sc.scimUseCase.ReplaceGroup(c.RequestCtx(), sc.tenantID(c), groupID, input)
+	if err != nil {
+		return sc.useCaseError(c, err)
+	}
+
+	return c.JSON(sc.toSCIMGroup(c, group), scim.ContentType)
+}
+
+// user loads the user in the route
+func (sc *SCIMController) user(c fiber.Ctx) (*entities.User, error) {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, usecases.ErrSCIMNotFound
+	}
+	return sc.scimUseCase.GetUser(c.RequestCtx(), sc.tenantID(c), userID)
+}
+
+// group loads the group in the route
+func (sc *SCIMController) group(c fiber.Ctx) (*entities.Group, error) {
+	groupID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return nil, usecases.ErrSCIMNotFound
+	}
+	return sc.scimUseCase.GetGroup(c.RequestCtx(), sc.tenantID(c), groupID)
+}
+
+// tenantID returns the tenant resolved by Authenticate
+func (sc *SCIMController) tenantID(c fiber.Ctx) uuid.UUID {
+	tenantID, _ := c.Locals("scim_tenant_id").(uuid.UUID)
+	return tenantID
+}
+
+// filter parses the filter query parameter, returning nil when there is none
+func (sc *SCIMController) filter(c fiber.Ctx) (scim.Filter, error) {
+	expression := c.Query("filter")
+	if expression == "" {
+		return nil, nil
+	}
+	return scim.ParseFilter(expression)
+}
+
+// pagination reads the startIndex and count query parameters
+func (sc *SCIMController) pagination(c fiber.Ctx) (int, int) {
+	startIndex, err := strconv.Atoi(c.Query("startIndex", "1"))
+	if err != nil || startIndex < 1 {
+		startIndex = 1
+	}
+
+	count, err := strconv.Atoi(c.Query("count", strconv.Itoa(scimMaxResults)))
+	if err != nil || count > scimMaxResults {
+		count = scimMaxResults
+	}
+
+	return startIndex, count
+}
+
+// error writes a SCIM error response
+func (sc *SCIMController) error(c fiber.Ctx, body scim.Error) error {
+	status, _ := strconv.Atoi(body.Status)
+	return c.Status(status).JSON(body, scim.ContentType)
+}
+
+// useCaseError maps use case and PATCH errors to SCIM error responses
+func (sc *SCIMController) useCaseError(c fiber.Ctx, err error) error {
+	var scimErr scim.Error
+	switch {
+	case errors.As(err, &scimErr):
+		return sc.error(c, scimErr)
+	case errors.Is(err, usecases.ErrSCIMNotFound):
+		return sc.error(c, scim.NewError(fiber.StatusNotFound, "", "Resource not found"))
+	case errors.Is(err, usecases.ErrSCIMConflict):
+		return sc.error(c, scim.NewError(fiber.StatusConflict, scim.ErrorUniqueness, "Resource already exists"))
+	default:
+		return sc.error(c, scim.NewError(fiber.StatusBadRequest, scim.ErrorInvalidValue, err.Error()))
+	}
+}
+
+// toSCIMUser converts a user to a SCIM resource
+func (sc *SCIMController) toSCIMUser(c fiber.Ctx, user *entities.User) scim.User {
+	active := user.IsActive
+	resource := scim.User{
+		Schemas:     []string{scim.SchemaUser},
+		ID:          user.ID.String(),
+		ExternalID:  user.DirectoryID,
+		UserName:    user.Email,
+		Name:        &scim.Name{GivenName: user.FirstName, FamilyName: user.LastName},
+		DisplayName: strings.TrimSpace(user.FirstName + " " + user.LastName),
+		Emails:      []scim.Email{{Value: user.Email, Type: "work", Primary: true}},
+		Active:      &active,
+		Meta: &scim.Meta{
+			ResourceType: "User",
+			Created:      user.CreatedAt,
+			LastModified: user.UpdatedAt,
+			Location:     c.BaseURL() + "/scim/v2/Users/" + user.ID.String(),
+		},
+	}
+
+	groups, _ := sc.scimUseCase.GetUserGroups(c.RequestCtx(), user.TenantID, user.ID)
+	for _, group := range groups {
+		resource.Groups = append(resource.Groups, scim.Reference{
+			Value:   group.ID.String(),
+			Display: group.DisplayName,
+			Ref:     c.BaseURL() + "/scim/v2/Groups/" + group.ID.String(),
+		})
+	}
+
+	return resource
+}
+
+// toSCIMGroup converts a group to a SCIM resource
+func (sc *SCIMController) toSCIMGroup(c fiber.Ctx, group *entities.Group) scim.Group {
+	resource := scim.Group{
+		Schemas:     []string{scim.SchemaGroup},
+		ID:          group.ID.String(),
+		ExternalID:  group.ExternalID,
+		DisplayName: group.DisplayName,
+		Members:     make([]scim.Reference, 0, len(group.MemberIDs)),
+		Meta: &scim.Meta{
+			ResourceType: "Group",
+			Created:      group.CreatedAt,
+			LastModified: group.UpdatedAt,
+			Location:     c.BaseURL() + "/scim/v2/Groups/" + group.ID.String(),
+		},
+	}
+
+	for _, id := range group.MemberIDs {
+		resource.Members = append(resource.Members, scim.Reference{
+			Value: id.String(),
+			Ref:   c.BaseURL() + "/scim/v2/Users/" + id.String(),
+		})
+	}
+
+	return resource
+}
+
+// toSCIMConfigResponse converts entity to response DTO
+func (sc *SCIMController) toSCIMConfigResponse(c fiber.Ctx, config *entities.SCIMConfig) dto.SCIMConfigResponse {
+	return dto.SCIMConfigResponse{
+		TenantID:    config.TenantID,
+		BaseURL:     c.BaseURL() + "/scim/v2",
+		GroupRoles:  config.GroupRoles,
+		DefaultRole: config.DefaultRole,
+		LastUsedAt:  config.LastUsedAt,
+		CreatedAt:   config.CreatedAt,
+		UpdatedAt:   config.UpdatedAt,
+	}
+}
+
+// userInput extracts the provisioned attributes from a SCIM user
+func userInput(resource scim.User) usecases.SCIMUserInput {
+	input := usecases.SCIMUserInput{
+		UserName:    resource.UserName,
+		DirectoryID: resource.ExternalID,
+		Active:      resource.IsActive(),
+	}
+	if resource.Name != nil {
+		input.GivenName = resource.Name.GivenName
+		input.FamilyName = resource.Name.FamilyName
+	}
+	return input
+}
+
+// groupInput extracts the provisioned attributes from a SCIM group
+func groupInput(resource scim.Group) (usecases.SCIMGroupInput, error) {
+	input := usecases.SCIMGroupInput{
+		DisplayName: resource.DisplayName,
+		ExternalID:  resource.ExternalID,
+		MemberIDs:   make([]uuid.UUID, 0, len(resource.Members)),
+	}
+	for _, member := range resource.Members {
+		id, err := uuid.Parse(member.Value)
+		if err != nil {
+			return input, scim.NewError(fiber.StatusBadRequest, scim.ErrorInvalidValue, "Unknown group member: "+member.Value)
+		}
+		input.MemberIDs = append(input.MemberIDs, id)
+	}
+	return input, nil
+}
+
+// userAttributes lists a SCIM user's filterable attributes
+func userAttributes(resource scim.User) scim.Attributes {
+	attributes := scim.Attributes{}
+	attributes.Add("id", resource.ID)
+	attributes.Add("externalId", resource.ExternalID)
+	attributes.Add("userName", resource.UserName)
+	attributes.Add("displayName", resource.DisplayName)
+	attributes.Add("active", strconv.FormatBool(resource.IsActive()))
+	if resource.Name != nil {
+		attributes.Add("name.givenName", resource.Name.GivenName)
+		attributes.Add("name.familyName", resource.Name.FamilyName)
+	}
+	for _, email := range resource.Emails {
+		attributes.Add("emails", email.Value)
+		attributes.Add("emails.value", email.Value)
+	}
+	for _, group := range resource.Groups {
+		attributes.Add("groups", group.Value)
+		attributes.Add("groups.value", group.Value)
+		attributes.Add("groups.display", group.Display)
+	}
+	return attributes
+}
+
+// groupAttributes lists a SCIM group's filterable attributes
+func groupAttributes(resource scim.Group) scim.Attributes {
+	attributes := scim.Attributes{}
+	attributes.Add("id", resource.ID)
+	attributes.Add("externalId", resource.ExternalID)
+	attributes.Add("displayName", resource.DisplayName)
+	for _, member := range resource.Members {
+		attributes.Add("members", member.Value)
+		attributes.Add("members.value", member.Value)
+	}
+	return attributes
+}
+
+// patchUser applies one PATCH operation to a SCIM user
+func patchUser(resource *scim.User, op, path string, value json.RawMessage) error {
+	if op != "add" && op != "replace" && op != "remove" {
+		return scim.NewError(fiber.StatusBadRequest, scim.ErrorInvalidValue, "Unsupported operation: "+op)
+	}
+
+	// Without a path the value is an object of attributes to set
+	if path == "" {
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(value, &values); err != nil {
+			return scim.NewError(fiber.StatusBadRequest, scim.ErrorInvalidValue, "Operation value must be an object when no path is given")
+		}
+		for attribute, attributeValue := range values {
+			if err := patchUser(resource, op, attribute, attributeValue); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	parsed, err := scim.ParsePath(path)
+	if err != nil {
+		return scim.NewError(fiber.StatusBadRequest, scim.ErrorInvalidPath, err.Error())
+	}
+
+	invalid := scim.NewError(fiber.StatusBadRequest, scim.ErrorInvalidValue, "Invalid value for "+path)
+
+	switch parsed.Attribute {
+	case "active":
+		if op == "remove" {
+			return scim.NewError(fiber.StatusBadRequest, scim.ErrorInvalidValue, "active cannot be removed")
+		}
+		active, err := scim.ParseBool(value)
+		if err != nil {
+			return invalid
+		}
+		resource.Active = &active
+	case "username":
+		if op == "remove" {
+			return scim.NewError(fiber.StatusBadRequest, scim.ErrorInvalidValue, "userName cannot be removed")
+		}
+		userName, err := scim.ParseString(value)
+		if err != nil {
+			return invalid
+		}
+		resource.UserName = userName
+	case "externalid":
+		externalID := ""
+		if op != "remove" {
+			if externalID, err = scim.ParseString(value); err != nil {
+				return invalid
+			}
+		}
+		resource.ExternalID = externalID
+	case "displayname":
+		// Display names are derived from the user's name
+	case "name":
+		if resource.Name == nil {
+			resource.Name = &scim.Name{}
+		}
+		if parsed.SubAttribute == "" {
+			name := scim.Name{}
+			if op != "remove" {
+				if err := json.Unmarshal(value, &name); err != nil {
+					return invalid
+				}
+			}
+			resource.Name = &name
+			return nil
+		}
+		text := ""
+		if op != "remove" {
+			if text, err = scim.ParseString(value); err != nil {
+				return invalid
+			}
+		}
+		switch parsed.SubAttribute {
+		case "givenname":
+			resource.Name.GivenName = text
+		case "familyname":
+			resource.Name.FamilyName = text
+		case "formatted":
+			resource.Name.Formatted = text
+		default:
+			return scim.NewError(fiber.StatusBadRequest, scim.ErrorInvalidPath, "Unsupported attribute: "+path)
+		}
+	case "emails":
+		if op == "remove" {
+			return scim.NewError(fiber.StatusBadRequest, scim.ErrorInvalidValue, "emails cannot be removed")
+		}
+		// Only a single address is kept; filtered paths such as emails[type eq "work"].value set it directly
+		if parsed.Filter != nil || parsed.SubAttribute != "" {
+			email, err := scim.ParseString(value)
+			if err != nil {
+				return invalid
+			}
+			resource.Emails = []scim.Email{{Value: email, Primary: true}}
+			return nil
+		}
+		var emails []scim.Email
+		if err := json.Unmarshal(value, &emails); err != nil {
+			return invalid
+		}
+		resource.Emails = emails
+		resource.UserName = resource.PrimaryEmail()
+	default:
+		// Extension attributes we don't store are ignored rather than rejected
+		if strings.Contains(path, ":") {
+			return nil
+		}
+		return scim.NewError(fiber.StatusBadRequest, scim.ErrorInvalidPath, "Unsupported attribute: "+path)
+	}
+
+	return nil
+}
+
+// patchGroup applies one PATCH operation to a SCIM group
+func patchGroup(resource *scim.Group, op, path string, value json.RawMessage) error {
+	if op != "add" && op != "replace" && op != "remove" {
+		return scim.NewError(fiber.StatusBadRequest, scim.ErrorInvalidValue, "Unsupported operation: "+op)
+	}
+
+	// Without a path the value is an object of attributes to set
+	if path == "" {
+		var values map[string]json.RawMessage
+		if err := json.Unmarshal(value, &values); err != nil {
+			return scim.NewError(fiber.StatusBadRequest, scim.ErrorInvalidValue, "Operation value must be an object when no path is given")
+		}
+		for attribute, attributeValue := range values {
+			if err := patchGroup(resource, op, attribute, attributeValue); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	parsed, err := scim.ParsePath(path)
+	if err != nil {
+		return scim.NewError(fiber.StatusBadRequest, scim.ErrorInvalidPath, err.Error())
+	}
+
+	invalid := scim.NewError(fiber.StatusBadRequest, scim.ErrorInvalidValue, "Invalid value for "+path)
+
+	switch parsed.Attribute {
+	case "displayname":
+		if op == "remove" {
+			return scim.NewError(fiber.StatusBadRequest, scim.ErrorInvalidValue, "displayName cannot be removed")
+		}
+		displayName, err := scim.ParseString(value)
+		if err != nil {
+			return invalid
+		}
+		resource.DisplayName = displayName
+	case "externalid":
+		externalID := ""
+		if op != "remove" {
+			if externalID, err = scim.ParseString(value); err != nil {
+				return invalid
+			}
+		}
+		resource.ExternalID = externalID
+	case "members":
+		var members []scim.Reference
+		if len(value) > 0 && string(value) != "null" {
+			if err := json.Unmarshal(value, &members); err != nil {
+				return invalid
+			}
+		}
+
+		switch {
+		case op == "replace":
+			resource.Members = members
+		case op == "add":
+			resource.Members = append(resource.Members, members...)
+		case parsed.Filter != nil:
+			// remove members[value eq "..."]
+			resource.Members = removeMembers(resource.Members, func(member scim.Reference) bool {
+				attributes := scim.Attributes{}
+				attributes.Add("value", member.Value)
+				attributes.Add("display", member.Display)
+				return parsed.Filter.Matches(attributes)
+			})
+		case len(members) > 0:
+			// remove with the members to drop listed in the value
+			drop := make(map[string]bool, len(members))
+			for _, member := range members {
+				drop[strings.ToLower(member.Value)] = true
+			}
+			resource.Members = removeMembers(resource.Members, func(member scim.Reference) bool {
+				return drop[strings.ToLower(member.Value)]
+			})
+		default:
+			resource.Members = nil
+		}
+	default:
+		if strings.Contains(path, ":") {
+			return nil
+		}
+		return scim.NewError(fiber.StatusBadRequest, scim.ErrorInvalidPath, "Unsupported attribute: "+path)
+	}
+
+	return nil
+}
+
+// removeMembers returns the members that don't match
+func removeMembers(members []scim.Reference, match func(scim.Reference) bool) []scim.Reference {
+	kept := make([]scim.Reference, 0, len(members))
+	for _, member := range members {
+		if !match(member) {
+			kept = append(kept, member)
+		}
+	}
+	return kept
+}
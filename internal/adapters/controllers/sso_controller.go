@@ -0,0 +1,363 @@
+package controllers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/cloudparallax/parallax/internal/adapters/http/dto"
+	"github.com/cloudparallax/parallax/internal/adapters/sso"
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/cloudparallax/parallax/internal/usecases"
+	"github.com/cloudparallax/parallax/pkg/logging"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+var logger = logging.Logger("controllers")
+
+// oidcStateCookie binds a pending single sign-on login to the browser that started it
+const oidcStateCookie = "oidc_state"
+
+// SessionStarter starts an authenticated session for a user
+type SessionStarter interface {
+	Login(c fiber.Ctx, userID string, userData map[string]interface{}) error
+}
+
+// SSOController handles single sign-on HTTP requests
+type SSOController struct {
+	ssoUseCase *usecases.SSOUseCase
+	oidcClient *sso.OIDCClient
+	sessions   SessionStarter
+}
+
+// NewSSOController creates a new single sign-on controller
+func NewSSOController(ssoUseCase *usecases.SSOUseCase, oidcClient *sso.OIDCClient, sessions SessionStarter) *SSOController {
+	return &SSOController{
+		ssoUseCase: ssoUseCase,
+		oidcClient: oidcClient,
+		sessions:   sessions,
+	}
+}
+
+// StartLogin redirects the user to their tenant's identity provider
+func (sc *SSOController) StartLogin(c fiber.Ctx) error {
+	tenantID, err := uuid.Parse(c.Params("tenantId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid tenant ID",
+			},
+		})
+	}
+
+	config, err := sc.ssoUseCase.GetLoginConfig(c.RequestCtx(), tenantID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusNotFound,
+				"message": err.Error(),
+			},
+		})
+	}
+
+	returnTo := c.Query("return_to")
+	if !strings.HasPrefix(returnTo, "/") || strings.HasPrefix(returnTo, "//") {
+		returnTo = ""
+	}
+
+	redirectURL := c.BaseURL() + strings.TrimSuffix(c.Path(), "/login") + "/callback"
+	authURL, state, err := sc.oidcClient.AuthCodeURL(c.RequestCtx(), config, redirectURL, returnTo)
+	if err != nil {
+		logger.WarnContext(c.RequestCtx(), "OIDC discovery failed", "tenant_id", tenantID, "error", err)
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadGateway,
+				"message": "Identity provider is unavailable",
+			},
+		})
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Value:    state,
+		Path:     strings.TrimSuffix(c.Path(), "/login"),
+		Expires:  time.Now().Add(10 * time.Minute),
+		HTTPOnly: true,
+		Secure:   c.Protocol() == "https",
+		SameSite: "Lax",
+	})
+
+	return c.Redirect().Status(fiber.StatusFound).To(authURL)
+}
+
+// Callback completes a single sign-on login and starts a session
+func (sc *SSOController) Callback(c fiber.Ctx) error {
+	tenantID, err := uuid.Parse(c.Params("tenantId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid tenant ID",
+			},
+		})
+	}
+
+	if idpError := c.Query("error"); idpError != "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusUnauthorized,
+				"message": "Identity provider returned an error: " + idpError,
+			},
+		})
+	}
+
+	state := c.Query("state")
+	code := c.Query("code")
+	if state == "" || code == "" || c.Cookies(oidcStateCookie) != state {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid login state",
+			},
+		})
+	}
+
+	config, err := sc.ssoUseCase.GetLoginConfig(c.RequestCtx(), tenantID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusNotFound,
+				"message": err.Error(),
+			},
+		})
+	}
+
+	identity, returnTo, err := sc.oidcClient.Exchange(c.RequestCtx(), config, c.BaseURL()+c.Path(), state, code)
+	if err != nil {
+		logger.WarnContext(c.RequestCtx(), "OIDC login failed", "tenant_id", tenantID, "error", err)
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusUnauthorized,
+				"message": "Single sign-on failed",
+			},
+		})
+	}
+
+	user, err := sc.ssoUseCase.ProvisionOIDCUser(c.RequestCtx(), config, identity)
+	if err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusForbidden,
+				"message": err.Error(),
+			},
+		})
+	}
+
+	userData := map[string]interface{}{
+		"username":      user.Email,
+		"email":         user.Email,
+		"role":          user.Role,
+		"tenant_id":     user.TenantID.String(),
+		"auth_provider": entities.AuthProviderOIDC,
+	}
+
+	if err := sc.sessions.Login(c, user.ID.String(), userData); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusInternalServerError,
+				"message": "Failed to create session",
+			},
+		})
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Value:    "",
+		Path:     strings.TrimSuffix(c.Path(), "/callback"),
+		Expires:  time.Now().Add(-time.Hour),
+		HTTPOnly: true,
+		Secure:   c.Protocol() == "https",
+		SameSite: "Lax",
+	})
+
+	if returnTo != "" {
+		return c.Redirect().Status(fiber.StatusFound).To(returnTo)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"user": userData,
+		},
+	})
+}
+
+// GetOIDCConfig retrieves a tenant's OIDC configuration
+func (sc *SSOController) GetOIDCConfig(c fiber.Ctx) error {
+	tenantID, err := uuid.Parse(c.Params("tenantId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid tenant ID",
+			},
+		})
+	}
+
+	config, err := sc.ssoUseCase.GetOIDCConfig(c.RequestCtx(), tenantID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusNotFound,
+				"message": "OIDC configuration not found",
+			},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    sc.toOIDCConfigResponse(config),
+	})
+}
+
+// ConfigureOIDC creates or updates a tenant's OIDC configuration
+func (sc *SSOController) ConfigureOIDC(c fiber.Ctx) error {
+	tenantID, err := uuid.Parse(c.Params("tenantId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid tenant ID",
+			},
+		})
+	}
+
+	var req dto.ConfigureOIDCRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid request body",
+			},
+		})
+	}
+
+	config, err := sc.ssoUseCase.ConfigureOIDC(c.RequestCtx(), tenantID, req.Issuer, req.ClientID, req.ClientSecret, req.RedirectURL, req.Scopes, req.AllowedDomains, req.RoleClaim, req.RoleMapping, req.DefaultRole)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": err.Error(),
+			},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    sc.toOIDCConfigResponse(config),
+	})
+}
+
+// EnableOIDC enables single sign-on for a tenant
+func (sc *SSOController) EnableOIDC(c fiber.Ctx) error {
+	return sc.setOIDCEnabled(c, true)
+}
+
+// DisableOIDC disables single sign-on for a tenant
+func (sc *SSOController) DisableOIDC(c fiber.Ctx) error {
+	return sc.setOIDCEnabled(c, false)
+}
+
+// setOIDCEnabled toggles single sign-on for a tenant
+func (sc *SSOController) setOIDCEnabled(c fiber.Ctx, enabled bool) error {
+	tenantID, err := uuid.Parse(c.Params("tenantId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid tenant ID",
+			},
+		})
+	}
+
+	config, err := sc.ssoUseCase.SetOIDCEnabled(c.RequestCtx(), tenantID, enabled)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusNotFound,
+				"message": "OIDC configuration not found",
+			},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    sc.toOIDCConfigResponse(config),
+	})
+}
+
+// DeleteOIDCConfig removes a tenant's OIDC configuration
+func (sc *SSOController) DeleteOIDCConfig(c fiber.Ctx) error {
+	tenantID, err := uuid.Parse(c.Params("tenantId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid tenant ID",
+			},
+		})
+	}
+
+	err = sc.ssoUseCase.DeleteOIDCConfig(c.RequestCtx(), tenantID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusNotFound,
+				"message": "OIDC configuration not found",
+			},
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "OIDC configuration deleted successfully",
+	})
+}
+
+// toOIDCConfigResponse converts entity to response DTO
+func (sc *SSOController) toOIDCConfigResponse(config *entities.OIDCConfig) dto.OIDCConfigResponse {
+	return dto.OIDCConfigResponse{
+		TenantID:       config.TenantID,
+		Issuer:         config.Issuer,
+		ClientID:       config.ClientID,
+		RedirectURL:    config.RedirectURL,
+		Scopes:         config.Scopes,
+		AllowedDomains: config.AllowedDomains,
+		RoleClaim:      config.RoleClaim,
+		RoleMapping:    config.RoleMapping,
+		DefaultRole:    config.DefaultRole,
+		IsEnabled:      config.IsEnabled,
+		CreatedAt:      config.CreatedAt,
+		UpdatedAt:      config.UpdatedAt,
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"strconv"
 
 	"github.com/cloudparallax/parallax/internal/adapters/http/dto"
+	"github.com/cloudparallax/parallax/internal/adapters/http/middleware"
 	"github.com/cloudparallax/parallax/internal/domain/entities"
 	"github.com/cloudparallax/parallax/internal/usecases"
 	"github.com/gofiber/fiber/v3"
@@ -12,13 +13,15 @@ import (
 
 // TenantController handles tenant HTTP requests
 type TenantController struct {
-	tenantUseCase *usecases.TenantUseCase
+	tenantUseCase     *usecases.TenantUseCase
+	membershipUseCase *usecases.MembershipUseCase
 }
 
 // NewTenantController creates a new tenant controller
-func NewTenantController(tenantUseCase *usecases.TenantUseCase) *TenantController {
+func NewTenantController(tenantUseCase *usecases.TenantUseCase, membershipUseCase *usecases.MembershipUseCase) *TenantController {
 	return &TenantController{
-		tenantUseCase: tenantUseCase,
+		tenantUseCase:     tenantUseCase,
+		membershipUseCase: membershipUseCase,
 	}
 }
 
@@ -35,7 +38,34 @@ func (tc *TenantController) CreateTenant(c fiber.Ctx) error {
 		})
 	}
 
-	tenant, err := tc.tenantUseCase.CreateTenant(c.RequestCtx(), req.Name, req.Domain, req.Plan, req.MaxUsers, req.MaxLocations)
+	// Only platform admins choose the plan and limits, which gate rate limits, seats and SSO
+	if !middleware.IsPlatformAdmin(c) {
+		req.Plan, req.MaxUsers, req.MaxLocations = "", 0, 0
+	}
+	if req.Plan == "" {
+		req.Plan = entities.DefaultTenantPlan
+	}
+	if req.MaxUsers == 0 {
+		req.MaxUsers = entities.DefaultTenantMaxUsers
+	}
+	if req.MaxLocations == 0 {
+		req.MaxLocations = entities.DefaultTenantMaxLocations
+	}
+
+	// The user who creates a tenant becomes its owner
+	userID, _ := c.Locals("user_id").(string)
+	ownerID, err := uuid.Parse(userID)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusUnauthorized,
+				"message": "Authentication required",
+			},
+		})
+	}
+
+	tenant, err := tc.membershipUseCase.CreateTenant(c.RequestCtx(), ownerID, req.Name, req.Domain, req.Plan, req.MaxUsers, req.MaxLocations)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
@@ -146,7 +176,50 @@ func (tc *TenantController) UpdateTenant(c fiber.Ctx) error {
 		})
 	}
 
-	tenant, err := tc.tenantUseCase.UpdateTenant(c.RequestCtx(), id, req.Name, req.Plan, req.MaxUsers, req.MaxLocations)
+	tenant, err := tc.tenantUseCase.UpdateTenant(c.RequestCtx(), id, req.Name)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": err.Error(),
+			},
+		})
+	}
+
+	response := tc.toTenantResponse(tenant)
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    response,
+	})
+}
+
+// SetTenantPlan changes a tenant's plan and limits
+func (tc *TenantController) SetTenantPlan(c fiber.Ctx) error {
+	idParam := c.Params("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid tenant ID",
+			},
+		})
+	}
+
+	var req dto.SetTenantPlanRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid request body",
+			},
+		})
+	}
+
+	tenant, err := tc.tenantUseCase.SetTenantPlan(c.RequestCtx(), id, req.Plan, req.MaxUsers, req.MaxLocations)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
@@ -228,6 +301,92 @@ func (tc *TenantController) DeactivateTenant(c fiber.Ctx) error {
 	})
 }
 
+// SetMFAPolicy sets whether a tenant requires two-factor authentication
+func (tc *TenantController) SetMFAPolicy(c fiber.Ctx) error {
+	idParam := c.Params("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid tenant ID",
+			},
+		})
+	}
+
+	var req dto.SetMFAPolicyRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid request body",
+			},
+		})
+	}
+
+	tenant, err := tc.tenantUseCase.SetMFAPolicy(c.RequestCtx(), id, req.Required)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": err.Error(),
+			},
+		})
+	}
+
+	response := tc.toTenantResponse(tenant)
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    response,
+	})
+}
+
+// SetAllowedOrigins sets the browser origins allowed to call the API for a tenant
+func (tc *TenantController) SetAllowedOrigins(c fiber.Ctx) error {
+	idParam := c.Params("id")
+	id, err := uuid.Parse(idParam)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid tenant ID",
+			},
+		})
+	}
+
+	var req dto.SetAllowedOriginsRequest
+	if err := c.Bind().JSON(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": "Invalid request body",
+			},
+		})
+	}
+
+	tenant, err := tc.tenantUseCase.SetAllowedOrigins(c.RequestCtx(), id, req.AllowedOrigins)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusBadRequest,
+				"message": err.Error(),
+			},
+		})
+	}
+
+	response := tc.toTenantResponse(tenant)
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    response,
+	})
+}
+
 // DeleteTenant deletes a tenant
 func (tc *TenantController) DeleteTenant(c fiber.Ctx) error {
 	idParam := c.Params("id")
@@ -262,14 +421,16 @@ func (tc *TenantController) DeleteTenant(c fiber.Ctx) error {
 // toTenantResponse converts entity to response DTO
 func (tc *TenantController) toTenantResponse(tenant *entities.Tenant) dto.TenantResponse {
 	return dto.TenantResponse{
-		ID:           tenant.ID,
-		Name:         tenant.Name,
-		Domain:       tenant.Domain,
-		IsActive:     tenant.IsActive,
-		Plan:         tenant.Plan,
-		MaxUsers:     tenant.MaxUsers,
-		MaxLocations: tenant.MaxLocations,
-		CreatedAt:    tenant.CreatedAt,
-		UpdatedAt:    tenant.UpdatedAt,
+		ID:             tenant.ID,
+		Name:           tenant.Name,
+		Domain:         tenant.Domain,
+		IsActive:       tenant.IsActive,
+		Plan:           tenant.Plan,
+		MaxUsers:       tenant.MaxUsers,
+		MaxLocations:   tenant.MaxLocations,
+		RequireMFA:     tenant.RequireMFA,
+		AllowedOrigins: tenant.AllowedOrigins,
+		CreatedAt:      tenant.CreatedAt,
+		UpdatedAt:      tenant.UpdatedAt,
 	}
 }
@@ -0,0 +1,58 @@
+package dto
+
+// LoginRequest represents a password login request
+type LoginRequest struct {
+	Username string `json:"username" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// TokenRequest represents a request to exchange credentials for tokens
+type TokenRequest struct {
+	Username string   `json:"username" validate:"required"`
+	Password string   `json:"password" validate:"required"`
+	MFACode  string   `json:"mfa_code"`
+	Scopes   []string `json:"scopes"`
+}
+
+// RefreshTokenRequest represents a request that carries a refresh token
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+// MFACodeRequest represents a request that carries a TOTP or recovery code
+type MFACodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// TOTPEnrollmentResponse represents a started TOTP enrollment
+type TOTPEnrollmentResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// RecoveryCodesResponse represents newly issued one-time recovery codes
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// ForgotPasswordRequest represents a request for a password reset email
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+// ResetPasswordRequest represents a request to set a new password with a reset token
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=8"`
+}
+
+// VerifyEmailRequest represents a request to verify an email address with a token
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ChangePasswordRequest represents a request to change the signed-in user's password
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8"`
+}
@@ -0,0 +1,36 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InvitationResponse represents a tenant invitation in API responses
+type InvitationResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	TenantID   uuid.UUID  `json:"tenant_id"`
+	Email      string     `json:"email"`
+	Role       string     `json:"role"`
+	Status     string     `json:"status"`
+	InvitedBy  uuid.UUID  `json:"invited_by"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// CreateInvitationRequest represents a request to invite someone to a tenant
+type CreateInvitationRequest struct {
+	Email string `json:"email" validate:"required,email"`
+	Role  string `json:"role" validate:"required,oneof=owner admin manager member viewer"`
+}
+
+// AcceptInvitationRequest represents a request to accept an invitation.
+// Password and name are only needed when the invited email has no account yet.
+type AcceptInvitationRequest struct {
+	Token     string `json:"token" validate:"required"`
+	Password  string `json:"password" validate:"omitempty,min=8"`
+	FirstName string `json:"first_name" validate:"omitempty,max=50"`
+	LastName  string `json:"last_name" validate:"omitempty,max=50"`
+}
@@ -0,0 +1,30 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SCIMConfigResponse represents a tenant's SCIM provisioning configuration in API responses
+type SCIMConfigResponse struct {
+	TenantID    uuid.UUID         `json:"tenant_id"`
+	BaseURL     string            `json:"base_url"`
+	GroupRoles  map[string]string `json:"group_roles"`
+	DefaultRole string            `json:"default_role"`
+	LastUsedAt  *time.Time        `json:"last_used_at,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// SCIMTokenResponse returns a newly generated SCIM token, which is not shown again
+type SCIMTokenResponse struct {
+	Token  string             `json:"token"`
+	Config SCIMConfigResponse `json:"config"`
+}
+
+// SetSCIMGroupRolesRequest represents a request to map directory groups to tenant roles
+type SetSCIMGroupRolesRequest struct {
+	GroupRoles  map[string]string `json:"group_roles"`
+	DefaultRole string            `json:"default_role"`
+}
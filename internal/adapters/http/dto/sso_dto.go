@@ -0,0 +1,36 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OIDCConfigResponse represents a tenant's OIDC configuration in API responses
+type OIDCConfigResponse struct {
+	TenantID       uuid.UUID         `json:"tenant_id"`
+	Issuer         string            `json:"issuer"`
+	ClientID       string            `json:"client_id"`
+	RedirectURL    string            `json:"redirect_url"`
+	Scopes         []string          `json:"scopes"`
+	AllowedDomains []string          `json:"allowed_domains"`
+	RoleClaim      string            `json:"role_claim"`
+	RoleMapping    map[string]string `json:"role_mapping"`
+	DefaultRole    string            `json:"default_role"`
+	IsEnabled      bool              `json:"is_enabled"`
+	CreatedAt      time.Time         `json:"created_at"`
+	UpdatedAt      time.Time         `json:"updated_at"`
+}
+
+// ConfigureOIDCRequest represents a request to create or update a tenant's OIDC configuration
+type ConfigureOIDCRequest struct {
+	Issuer         string            `json:"issuer" validate:"required,url"`
+	ClientID       string            `json:"client_id" validate:"required"`
+	ClientSecret   string            `json:"client_secret"`
+	RedirectURL    string            `json:"redirect_url" validate:"omitempty,url"`
+	Scopes         []string          `json:"scopes"`
+	AllowedDomains []string          `json:"allowed_domains"`
+	RoleClaim      string            `json:"role_claim"`
+	RoleMapping    map[string]string `json:"role_mapping"`
+	DefaultRole    string            `json:"default_role"`
+}
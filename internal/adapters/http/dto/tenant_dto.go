@@ -8,30 +8,47 @@ import (
 
 // TenantResponse represents a tenant in API responses
 type TenantResponse struct {
-	ID           uuid.UUID `json:"id"`
-	Name         string    `json:"name"`
-	Domain       string    `json:"domain"`
-	IsActive     bool      `json:"is_active"`
-	Plan         string    `json:"plan"`
-	MaxUsers     int       `json:"max_users"`
-	MaxLocations int       `json:"max_locations"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID             uuid.UUID `json:"id"`
+	Name           string    `json:"name"`
+	Domain         string    `json:"domain"`
+	IsActive       bool      `json:"is_active"`
+	Plan           string    `json:"plan"`
+	MaxUsers       int       `json:"max_users"`
+	MaxLocations   int       `json:"max_locations"`
+	RequireMFA     bool      `json:"require_mfa"`
+	AllowedOrigins []string  `json:"allowed_origins"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
 
 // CreateTenantRequest represents a request to create a tenant
 type CreateTenantRequest struct {
-	Name         string `json:"name" validate:"required,min=1,max=100"`
-	Domain       string `json:"domain" validate:"required,min=1,max=50"`
-	Plan         string `json:"plan" validate:"required,oneof=basic premium enterprise"`
-	MaxUsers     int    `json:"max_users" validate:"required,min=1"`
-	MaxLocations int    `json:"max_locations" validate:"required,min=1"`
+	Name   string `json:"name" validate:"required,min=1,max=100"`
+	Domain string `json:"domain" validate:"required,min=1,max=50"`
+	// Plan and limits are only taken from platform admins; other users get the defaults
+	Plan         string `json:"plan" validate:"omitempty,oneof=basic premium enterprise"`
+	MaxUsers     int    `json:"max_users" validate:"omitempty,min=1"`
+	MaxLocations int    `json:"max_locations" validate:"omitempty,min=1"`
 }
 
 // UpdateTenantRequest represents a request to update a tenant
 type UpdateTenantRequest struct {
-	Name         string `json:"name" validate:"required,min=1,max=100"`
+	Name string `json:"name" validate:"required,min=1,max=100"`
+}
+
+// SetTenantPlanRequest represents a request to change a tenant's plan and limits
+type SetTenantPlanRequest struct {
 	Plan         string `json:"plan" validate:"required,oneof=basic premium enterprise"`
 	MaxUsers     int    `json:"max_users" validate:"required,min=1"`
 	MaxLocations int    `json:"max_locations" validate:"required,min=1"`
 }
+
+// SetAllowedOriginsRequest represents a request to set the browser origins allowed for a tenant
+type SetAllowedOriginsRequest struct {
+	AllowedOrigins []string `json:"allowed_origins"`
+}
+
+// SetMFAPolicyRequest represents a request to change a tenant's two-factor authentication policy
+type SetMFAPolicyRequest struct {
+	Required bool `json:"required"`
+}
@@ -1,8 +1,11 @@
 package middleware
 
 import (
+	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
+	"strings"
 	"sync"
 	"time"
 
@@ -26,9 +29,11 @@ type SessionStore struct {
 
 // AuthMiddleware provides session-based authentication functionality
 type AuthMiddleware struct {
-	store      *SessionStore
-	cookieName string
-	maxAge     time.Duration
+	store       *SessionStore
+	tokens      *TokenManager
+	permissions PermissionResolver
+	cookieName  string
+	maxAge      time.Duration
 }
 
 // NewSessionStore creates a new session store
@@ -80,15 +85,61 @@ func (a *AuthMiddleware) GetSession(sessionID string) (*Session, bool) {
 		return nil, false
 	}
 
-	// Check if session is expired
+	// Check if session is expired; CleanupExpiredSessions removes it, since this only holds the read lock
 	if time.Now().After(session.ExpiresAt) {
-		delete(a.store.sessions, sessionID)
 		return nil, false
 	}
 
 	return session, true
 }
 
+// sessionValue returns a string stored in a session's data, creating and storing it when absent
+func (a *AuthMiddleware) sessionValue(sessionID, key string, create func() (string, error)) (string, error) {
+	a.store.mutex.Lock()
+	defer a.store.mutex.Unlock()
+
+	session, exists := a.store.sessions[sessionID]
+	if !exists || time.Now().After(session.ExpiresAt) {
+		return "", errors.New("session not found")
+	}
+
+	if value, ok := session.Data[key].(string); ok && value != "" {
+		return value, nil
+	}
+
+	value, err := create()
+	if err != nil {
+		return "", err
+	}
+
+	// Requests read a session's data without the lock, so store a changed copy of the session
+	// rather than writing to a map that may be being read
+	data := make(map[string]interface{}, len(session.Data)+1)
+	for k, v := range session.Data {
+		data[k] = v
+	}
+	data[key] = value
+
+	updated := *session
+	updated.Data = data
+	a.store.sessions[sessionID] = &updated
+
+	return value, nil
+}
+
+// currentSessionID returns the ID of the session set on this response, or else the request's session cookie
+func (a *AuthMiddleware) currentSessionID(c fiber.Ctx) string {
+	if sessionID, ok := c.Locals("issued_session_id").(string); ok {
+		return sessionID
+	}
+	return c.Cookies(a.cookieName)
+}
+
+// UseTokenManager enables bearer access tokens as an alternative to session cookies
+func (a *AuthMiddleware) UseTokenManager(tokens *TokenManager) {
+	a.tokens = tokens
+}
+
 // DeleteSession removes a session
 func (a *AuthMiddleware) DeleteSession(sessionID string) {
 	a.store.mutex.Lock()
@@ -96,11 +147,24 @@ func (a *AuthMiddleware) DeleteSession(sessionID string) {
 	delete(a.store.sessions, sessionID)
 }
 
-// RequireAuth validates session cookies for protected routes
+// DeleteUserSessions removes every session belonging to a user
+func (a *AuthMiddleware) DeleteUserSessions(userID string) {
+	a.store.mutex.Lock()
+	defer a.store.mutex.Unlock()
+
+	for id, session := range a.store.sessions {
+		if session.UserID == userID {
+			delete(a.store.sessions, id)
+		}
+	}
+}
+
+// RequireAuth validates session cookies or bearer tokens for protected routes
 func (a *AuthMiddleware) RequireAuth() fiber.Handler {
 	return func(c fiber.Ctx) error {
 		sessionID := c.Cookies(a.cookieName)
-		if sessionID == "" {
+		bearer := a.bearerToken(c)
+		if sessionID == "" && bearer == "" {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"success": false,
 				"error": fiber.Map{
@@ -110,7 +174,7 @@ func (a *AuthMiddleware) RequireAuth() fiber.Handler {
 			})
 		}
 
-		session, exists := a.GetSession(sessionID)
+		session, method, exists := a.authenticate(c)
 		if !exists {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"success": false,
@@ -121,10 +185,40 @@ func (a *AuthMiddleware) RequireAuth() fiber.Handler {
 			})
 		}
 
+		// Sessions waiting for a second factor are not authenticated yet
+		if isMFAPending(session) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    fiber.StatusUnauthorized,
+					"message": "Two-factor authentication required",
+				},
+			})
+		}
+
 		// Store session and user info in context
-		c.Locals("session", session)
-		c.Locals("user_id", session.UserID)
-		c.Locals("authenticated", true)
+		a.setLocals(c, session, method)
+
+		return c.Next()
+	}
+}
+
+// RequireSession validates the session cookie without requiring completed
+// two-factor authentication; it guards the endpoints that complete it
+func (a *AuthMiddleware) RequireSession() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		session, exists := a.GetSession(c.Cookies(a.cookieName))
+		if !exists {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    fiber.StatusUnauthorized,
+					"message": "Authentication required",
+				},
+			})
+		}
+
+		a.setLocals(c, session, "session")
 
 		return c.Next()
 	}
@@ -133,19 +227,86 @@ func (a *AuthMiddleware) RequireAuth() fiber.Handler {
 // OptionalAuth validates session but doesn't require it
 func (a *AuthMiddleware) OptionalAuth() fiber.Handler {
 	return func(c fiber.Ctx) error {
-		sessionID := c.Cookies(a.cookieName)
-		if sessionID != "" {
-			if session, exists := a.GetSession(sessionID); exists {
-				c.Locals("session", session)
-				c.Locals("user_id", session.UserID)
-				c.Locals("authenticated", true)
-			}
+		if session, method, exists := a.authenticate(c); exists && !isMFAPending(session) {
+			a.setLocals(c, session, method)
 		}
 
 		return c.Next()
 	}
 }
 
+// isMFAPending reports whether a session still needs a second factor
+func isMFAPending(session *Session) bool {
+	pending, _ := session.Data["mfa_pending"].(bool)
+	return pending
+}
+
+// authenticate resolves the request's session from a bearer token or session cookie
+func (a *AuthMiddleware) authenticate(c fiber.Ctx) (*Session, string, bool) {
+	if bearer := a.bearerToken(c); bearer != "" {
+		claims, err := a.tokens.ParseAccessToken(bearer)
+		if err != nil {
+			return nil, "", false
+		}
+		return sessionFromClaims(claims), "token", true
+	}
+
+	sessionID := c.Cookies(a.cookieName)
+	if sessionID == "" {
+		return nil, "", false
+	}
+
+	session, exists := a.GetSession(sessionID)
+	return session, "session", exists
+}
+
+// bearerToken extracts the bearer token from the Authorization header when tokens are enabled
+func (a *AuthMiddleware) bearerToken(c fiber.Ctx) string {
+	if a.tokens == nil {
+		return ""
+	}
+
+	header := c.Get(fiber.HeaderAuthorization)
+	if len(header) > 7 && strings.EqualFold(header[:7], "Bearer ") {
+		return strings.TrimSpace(header[7:])
+	}
+	return ""
+}
+
+// setLocals stores the session and user info in the request context
+func (a *AuthMiddleware) setLocals(c fiber.Ctx, session *Session, method string) {
+	c.Locals("session", session)
+	c.Locals("user_id", session.UserID)
+	c.Locals("authenticated", true)
+	c.Locals("auth_method", method)
+
+	if isImpersonating(session) {
+		impersonatorID, _ := session.Data[impersonatorIDKey].(string)
+		c.Locals("impersonator_id", impersonatorID)
+		c.Set(ImpersonatingHeader, impersonatorID)
+	}
+}
+
+// sessionFromClaims builds a request-scoped session from access token claims
+func sessionFromClaims(claims *TokenClaims) *Session {
+	session := &Session{
+		ID:     claims.ID,
+		UserID: claims.Subject,
+		Data: map[string]interface{}{
+			"role":      claims.Role,
+			"tenant_id": claims.TenantID,
+			"scopes":    claims.Scopes,
+		},
+	}
+	if claims.IssuedAt != nil {
+		session.CreatedAt = claims.IssuedAt.Time
+	}
+	if claims.ExpiresAt != nil {
+		session.ExpiresAt = claims.ExpiresAt.Time
+	}
+	return session
+}
+
 // RequireRole checks if user has required role
 func (a *AuthMiddleware) RequireRole(role string) fiber.Handler {
 	return func(c fiber.Ctx) error {
@@ -184,6 +345,15 @@ func (a *AuthMiddleware) Login(c fiber.Ctx, userID string, userData map[string]i
 	}
 
 	// Set session cookie
+	a.setSessionCookie(c, session)
+
+	return nil
+}
+
+// setSessionCookie points the session cookie at a session
+func (a *AuthMiddleware) setSessionCookie(c fiber.Ctx, session *Session) {
+	c.Locals("issued_session_id", session.ID)
+
 	c.Cookie(&fiber.Cookie{
 		Name:     a.cookieName,
 		Value:    session.ID,
@@ -192,8 +362,27 @@ func (a *AuthMiddleware) Login(c fiber.Ctx, userID string, userData map[string]i
 		Secure:   c.Protocol() == "https",
 		SameSite: "Lax",
 	})
+}
 
-	return nil
+// CompleteMFA marks the current session's second factor as verified.
+// The session is reissued under a new ID so a pre-verification ID cannot be reused.
+func (a *AuthMiddleware) CompleteMFA(c fiber.Ctx) error {
+	sessionID := c.Cookies(a.cookieName)
+	session, exists := a.GetSession(sessionID)
+	if !exists {
+		return errors.New("session not found")
+	}
+
+	data := make(map[string]interface{}, len(session.Data))
+	for key, value := range session.Data {
+		if key != "mfa_pending" && key != csrfSecretKey {
+			data[key] = value
+		}
+	}
+	data["mfa_verified"] = true
+
+	a.DeleteSession(sessionID)
+	return a.Login(c, session.UserID, data)
 }
 
 // Logout removes session and clears cookie
@@ -202,6 +391,7 @@ func (a *AuthMiddleware) Logout(c fiber.Ctx) error {
 	if sessionID != "" {
 		a.DeleteSession(sessionID)
 	}
+	c.Locals("issued_session_id", "")
 
 	// Clear session cookie
 	c.Cookie(&fiber.Cookie{
@@ -216,6 +406,28 @@ func (a *AuthMiddleware) Logout(c fiber.Ctx) error {
 	return nil
 }
 
+// HealthCheck reports whether the session store can be read
+func (a *AuthMiddleware) HealthCheck(ctx context.Context) error {
+	a.store.mutex.RLock()
+	defer a.store.mutex.RUnlock()
+	return nil
+}
+
+// ActiveSessions returns the number of sessions that haven't expired
+func (a *AuthMiddleware) ActiveSessions() int {
+	a.store.mutex.RLock()
+	defer a.store.mutex.RUnlock()
+
+	now := time.Now()
+	active := 0
+	for _, session := range a.store.sessions {
+		if now.Before(session.ExpiresAt) {
+			active++
+		}
+	}
+	return active
+}
+
 // CleanupExpiredSessions removes expired sessions (should be called periodically)
 func (a *AuthMiddleware) CleanupExpiredSessions() {
 	a.store.mutex.Lock()
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSessionValueWhileSessionIsRead(t *testing.T) {
+	auth := NewAuthMiddleware("session_id", time.Hour)
+	session, err := auth.CreateSession("user-1", map[string]interface{}{"role": "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	var started, wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		started.Add(1)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			started.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				current, ok := auth.GetSession(session.ID)
+				if !ok {
+					t.Error("session not found")
+					return
+				}
+				if role, _ := current.Data["role"].(string); role != "user" {
+					t.Errorf("role = %q", role)
+					return
+				}
+			}
+		}()
+	}
+
+	started.Wait()
+	for i := 0; i < 200; i++ {
+		key := "key-" + strconv.Itoa(i)
+		if _, err := auth.sessionValue(session.ID, key, func() (string, error) { return key, nil }); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(done)
+	wg.Wait()
+
+	current, _ := auth.GetSession(session.ID)
+	if len(current.Data) != 201 {
+		t.Fatalf("session has %d values, want 201", len(current.Data))
+	}
+	value, err := auth.sessionValue(session.ID, "key-7", func() (string, error) { return "new", nil })
+	if err != nil || value != "key-7" {
+		t.Fatalf("stored value = %q, %v; want key-7", value, err)
+	}
+}
@@ -1,54 +1,255 @@
 package middleware
 
 import (
-	"os"
+	"context"
+	"fmt"
+	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 
+	"github.com/cloudparallax/parallax/internal/config"
 	"github.com/gofiber/fiber/v3"
 )
 
-// CORSMiddleware provides CORS functionality
+// Named CORS policies
+const (
+	// CORSPolicyDefault applies to routes without a more specific policy
+	CORSPolicyDefault = "default"
+	// CORSPolicyPublic allows read-only access from any origin without credentials
+	CORSPolicyPublic = "public"
+	// CORSPolicyAuth applies to sign-in and account endpoints
+	CORSPolicyAuth = "auth"
+	// CORSPolicyAdmin applies to platform administration endpoints
+	CORSPolicyAdmin = "admin"
+	// CORSPolicyNone allows no browser origins, for server-to-server APIs
+	CORSPolicyNone = "none"
+)
+
+// CORSPolicy holds the cross-origin rules for a group of routes. AllowOrigins entries are
+// "*", exact origins such as https://app.example.com:8443, subdomain wildcards such as
+// https://*.example.com (which do not match the domain itself), or "regex:" patterns matched
+// against the whole origin. Entries without a scheme only match https.
+type CORSPolicy struct {
+	AllowOrigins       []string
+	AllowMethods       []string
+	AllowHeaders       []string
+	ExposeHeaders      []string
+	AllowCredentials   bool
+	MaxAge             int
+	AllowTenantOrigins bool // Also allow the origins configured on the request's tenant, without credentials
+}
+
+// CORSConfig holds CORS middleware configuration
+type CORSConfig struct {
+	Policies map[string]CORSPolicy
+}
+
+// Validate checks that every policy's origins parse and that no policy combines "*" with credentials
+func (c CORSConfig) Validate() error {
+	for name, policy := range c.Policies {
+		if _, err := compileCORSPolicy(name, policy); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TenantOriginResolver returns the browser origins a tenant allows
+type TenantOriginResolver func(ctx context.Context, tenantID string) []string
+
+// corsRoute attaches a policy to a path prefix
+type corsRoute struct {
+	prefix string
+	policy string
+}
+
+// corsPolicy is a policy with its allowed origins parsed
+type corsPolicy struct {
+	CORSPolicy
+	origins   []originPattern
+	anyOrigin bool
+}
+
+// compileCORSPolicy parses a policy's allowed origins
+func compileCORSPolicy(name string, policy CORSPolicy) (*corsPolicy, error) {
+	compiled := &corsPolicy{CORSPolicy: policy}
+	for _, allowed := range policy.AllowOrigins {
+		pattern, err := parseOriginPattern(allowed)
+		if err != nil {
+			return nil, fmt.Errorf("CORS policy %q: %w", name, err)
+		}
+		compiled.origins = append(compiled.origins, pattern)
+		compiled.anyOrigin = compiled.anyOrigin || pattern.any
+	}
+
+	// Browsers reject "*" on credentialed requests, and echoing any origin instead would let every site read responses
+	if compiled.anyOrigin && policy.AllowCredentials {
+		return nil, fmt.Errorf("CORS policy %q: allowing credentials from any origin (*) is not permitted; list the allowed origins", name)
+	}
+
+	return compiled, nil
+}
+
+// CORSMiddleware provides CORS functionality with named policies per route group
 type CORSMiddleware struct {
-	allowOrigins     []string
-	allowMethods     []string
-	allowHeaders     []string
-	exposeHeaders    []string
-	allowCredentials bool
-	maxAge           int
+	policies      atomic.Pointer[map[string]*corsPolicy] // Swapped as a whole on reload
+	routes        []corsRoute
+	tenantOrigins TenantOriginResolver
+}
+
+// DefaultCORSConfig returns the default CORS policies
+func DefaultCORSConfig() CORSConfig {
+	return corsConfig(config.Default().CORS)
 }
 
-// NewCORSMiddleware creates a new CORS middleware with environment configuration
-func NewCORSMiddleware() *CORSMiddleware {
-	return &CORSMiddleware{
-		allowOrigins:     parseEnvArray("CORS_ALLOW_ORIGINS", []string{"*"}),
-		allowMethods:     parseEnvArray("CORS_ALLOW_METHODS", []string{"GET", "POST", "HEAD", "PUT", "DELETE", "PATCH", "OPTIONS"}),
-		allowHeaders:     parseEnvArray("CORS_ALLOW_HEADERS", []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With"}),
-		exposeHeaders:    parseEnvArray("CORS_EXPOSE_HEADERS", []string{"Content-Length"}),
-		allowCredentials: parseEnvBool("CORS_ALLOW_CREDENTIALS", false),
-		maxAge:           parseEnvInt("CORS_MAX_AGE", 86400),
+// corsConfig maps the CORS settings to the named policies; the auth and admin policies allow
+// the default policy's origins unless they have their own
+func corsConfig(cfg config.CORS) CORSConfig {
+	defaultPolicy := CORSPolicy{
+		AllowOrigins:       cfg.AllowOrigins,
+		AllowMethods:       cfg.AllowMethods,
+		AllowHeaders:       cfg.AllowHeaders,
+		ExposeHeaders:      cfg.ExposeHeaders,
+		AllowCredentials:   cfg.AllowCredentials,
+		MaxAge:             cfg.MaxAge,
+		AllowTenantOrigins: true,
+	}
+
+	authPolicy := defaultPolicy
+	if len(cfg.AuthAllowOrigins) > 0 {
+		authPolicy.AllowOrigins = cfg.AuthAllowOrigins
+	}
+	authPolicy.AllowTenantOrigins = false
+
+	adminPolicy := defaultPolicy
+	if len(cfg.AdminAllowOrigins) > 0 {
+		adminPolicy.AllowOrigins = cfg.AdminAllowOrigins
+	}
+	adminPolicy.AllowTenantOrigins = false
+
+	return CORSConfig{
+		Policies: map[string]CORSPolicy{
+			CORSPolicyDefault: defaultPolicy,
+			CORSPolicyPublic: {
+				AllowOrigins:  cfg.PublicAllowOrigins,
+				AllowMethods:  []string{"GET", "HEAD", "OPTIONS"},
+				AllowHeaders:  defaultPolicy.AllowHeaders,
+				ExposeHeaders: defaultPolicy.ExposeHeaders,
+				MaxAge:        defaultPolicy.MaxAge,
+			},
+			CORSPolicyAuth:  authPolicy,
+			CORSPolicyAdmin: adminPolicy,
+			CORSPolicyNone:  {},
+		},
 	}
 }
 
+// NewCORSMiddleware creates a new CORS middleware; it panics if the configuration is invalid
+func NewCORSMiddleware(config ...CORSConfig) *CORSMiddleware {
+	cfg := DefaultCORSConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	policies, err := compileCORSConfig(cfg)
+	if err != nil {
+		panic("invalid CORS configuration: " + err.Error())
+	}
+
+	c := &CORSMiddleware{}
+	c.policies.Store(&policies)
+	return c
+}
+
+// compileCORSConfig compiles every policy; the default policy is added when missing
+func compileCORSConfig(cfg CORSConfig) (map[string]*corsPolicy, error) {
+	policies := make(map[string]*corsPolicy, len(cfg.Policies)+1)
+	for name, policy := range cfg.Policies {
+		compiled, err := compileCORSPolicy(name, policy)
+		if err != nil {
+			return nil, err
+		}
+		policies[name] = compiled
+	}
+	if _, exists := policies[CORSPolicyDefault]; !exists {
+		compiled, err := compileCORSPolicy(CORSPolicyDefault, DefaultCORSConfig().Policies[CORSPolicyDefault])
+		if err != nil {
+			return nil, err
+		}
+		policies[CORSPolicyDefault] = compiled
+	}
+	return policies, nil
+}
+
+// Reload replaces the policies while requests are being served. The new configuration must be
+// valid and keep every policy that routes use; otherwise the current policies stay in effect.
+func (c *CORSMiddleware) Reload(cfg CORSConfig) error {
+	policies, err := compileCORSConfig(cfg)
+	if err != nil {
+		return err
+	}
+	for _, route := range c.routes {
+		if _, exists := policies[route.policy]; !exists {
+			return fmt.Errorf("CORS policy %q is used by %s and cannot be removed", route.policy, route.prefix)
+		}
+	}
+
+	c.policies.Store(&policies)
+	return nil
+}
+
+// UsePolicy applies a named policy to every route under a path prefix; the longest matching prefix wins
+func (c *CORSMiddleware) UsePolicy(prefix, name string) {
+	if _, exists := (*c.policies.Load())[name]; !exists {
+		panic("unknown CORS policy: " + name)
+	}
+
+	c.routes = append(c.routes, corsRoute{prefix: strings.TrimSuffix(prefix, "/"), policy: name})
+	sort.SliceStable(c.routes, func(i, j int) bool {
+		return len(c.routes[i].prefix) > len(c.routes[j].prefix)
+	})
+}
+
+// UseTenantOrigins sets how per-tenant allowed origins are looked up
+func (c *CORSMiddleware) UseTenantOrigins(resolver TenantOriginResolver) {
+	c.tenantOrigins = resolver
+}
+
 // Handler returns the CORS middleware handler
 func (c *CORSMiddleware) Handler() fiber.Handler {
 	return func(ctx fiber.Ctx) error {
 		origin := ctx.Get("Origin")
-		
+		policy := c.policyFor(ctx.Path())
+
+		// Responses differ by origin, so caches must key on it
+		ctx.Vary("Origin")
+
 		// Handle preflight requests
 		if ctx.Method() == fiber.MethodOptions {
-			return c.handlePreflight(ctx, origin)
+			return c.handlePreflight(ctx, policy, origin)
 		}
 
 		// Handle actual requests
-		return c.handleActualRequest(ctx, origin)
+		return c.handleActualRequest(ctx, policy, origin)
+	}
+}
+
+// policyFor returns the policy attached to the longest prefix of a path
+func (c *CORSMiddleware) policyFor(path string) *corsPolicy {
+	policies := *c.policies.Load()
+	for _, route := range c.routes {
+		if path == route.prefix || strings.HasPrefix(path, route.prefix+"/") {
+			return policies[route.policy]
+		}
 	}
+	return policies[CORSPolicyDefault]
 }
 
 // handlePreflight handles CORS preflight requests
-func (c *CORSMiddleware) handlePreflight(ctx fiber.Ctx, origin string) error {
+func (c *CORSMiddleware) handlePreflight(ctx fiber.Ctx, policy *corsPolicy, origin string) error {
 	// Check if origin is allowed
-	if !c.isOriginAllowed(origin) {
+	if !c.isOriginAllowed(ctx, policy, origin) {
 		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"success": false,
 			"error": fiber.Map{
@@ -58,17 +259,42 @@ func (c *CORSMiddleware) handlePreflight(ctx fiber.Ctx, origin string) error {
 		})
 	}
 
+	// Check the method and headers the browser wants to send
+	if method := ctx.Get("Access-Control-Request-Method"); method != "" && !containsFold(policy.AllowMethods, method) {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusForbidden,
+				"message": "Method not allowed by CORS policy",
+			},
+		})
+	}
+
+	if !containsFold(policy.AllowHeaders, "*") {
+		for _, header := range strings.Split(ctx.Get("Access-Control-Request-Headers"), ",") {
+			if header = strings.TrimSpace(header); header != "" && !containsFold(policy.AllowHeaders, header) {
+				return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"success": false,
+					"error": fiber.Map{
+						"code":    fiber.StatusForbidden,
+						"message": "Header not allowed by CORS policy: " + header,
+					},
+				})
+			}
+		}
+	}
+
 	// Set CORS headers for preflight
-	c.setAllowOriginHeader(ctx, origin)
-	ctx.Set("Access-Control-Allow-Methods", strings.Join(c.allowMethods, ", "))
-	ctx.Set("Access-Control-Allow-Headers", strings.Join(c.allowHeaders, ", "))
+	c.setAllowOriginHeader(ctx, policy, origin)
+	ctx.Set("Access-Control-Allow-Methods", strings.Join(policy.AllowMethods, ", "))
+	ctx.Set("Access-Control-Allow-Headers", strings.Join(policy.AllowHeaders, ", "))
 	
-	if c.allowCredentials {
+	if c.allowsCredentials(policy, origin) {
 		ctx.Set("Access-Control-Allow-Credentials", "true")
 	}
 	
-	if c.maxAge > 0 {
-		ctx.Set("Access-Control-Max-Age", strconv.Itoa(c.maxAge))
+	if policy.MaxAge > 0 {
+		ctx.Set("Access-Control-Max-Age", strconv.Itoa(policy.MaxAge))
 	}
 
 	// Respond to preflight with 204 No Content
@@ -76,9 +302,9 @@ func (c *CORSMiddleware) handlePreflight(ctx fiber.Ctx, origin string) error {
 }
 
 // handleActualRequest handles actual CORS requests
-func (c *CORSMiddleware) handleActualRequest(ctx fiber.Ctx, origin string) error {
+func (c *CORSMiddleware) handleActualRequest(ctx fiber.Ctx, policy *corsPolicy, origin string) error {
 	// Check if origin is allowed
-	if origin != "" && !c.isOriginAllowed(origin) {
+	if origin != "" && !c.isOriginAllowed(ctx, policy, origin) {
 		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
 			"success": false,
 			"error": fiber.Map{
@@ -90,109 +316,127 @@ func (c *CORSMiddleware) handleActualRequest(ctx fiber.Ctx, origin string) error
 
 	// Set CORS headers for actual request
 	if origin != "" {
-		c.setAllowOriginHeader(ctx, origin)
+		c.setAllowOriginHeader(ctx, policy, origin)
 	}
 	
-	if len(c.exposeHeaders) > 0 {
-		ctx.Set("Access-Control-Expose-Headers", strings.Join(c.exposeHeaders, ", "))
+	if len(policy.ExposeHeaders) > 0 {
+		ctx.Set("Access-Control-Expose-Headers", strings.Join(policy.ExposeHeaders, ", "))
 	}
 	
-	if c.allowCredentials {
+	if c.allowsCredentials(policy, origin) {
 		ctx.Set("Access-Control-Allow-Credentials", "true")
 	}
 
 	return ctx.Next()
 }
 
-// isOriginAllowed checks if the origin is in the allowed list
-func (c *CORSMiddleware) isOriginAllowed(origin string) bool {
+// isOriginAllowed checks if the origin is allowed by the policy
+func (c *CORSMiddleware) isOriginAllowed(ctx fiber.Ctx, policy *corsPolicy, origin string) bool {
 	if origin == "" {
 		return true // Allow requests without Origin header
 	}
 
-	for _, allowed := range c.allowOrigins {
-		if allowed == "*" {
+	if policy.anyOrigin {
+		return true
+	}
+
+	parsed, ok := parseOrigin(origin)
+	if !ok {
+		return false // "null" and malformed origins only match "*"
+	}
+
+	return isListedOrigin(policy, parsed) || c.isTenantOrigin(ctx, policy, parsed)
+}
+
+// IsOriginTrusted checks if the origin is explicitly listed for the request's route; the "*"
+// wildcard trusts no one, and neither do tenant origins, since the tenant is chosen by the client
+func (c *CORSMiddleware) IsOriginTrusted(ctx fiber.Ctx, origin string) bool {
+	parsed, ok := parseOrigin(origin)
+	if !ok {
+		return false
+	}
+
+	return isListedOrigin(c.policyFor(ctx.Path()), parsed)
+}
+
+// allowsCredentials checks if a response to the origin may be read with credentials. Tenant
+// origins never are: any tenant admin can add one and name their tenant in X-Tenant-ID, so
+// with credentials their page could read other users' responses.
+func (c *CORSMiddleware) allowsCredentials(policy *corsPolicy, origin string) bool {
+	if !policy.AllowCredentials {
+		return false
+	}
+	parsed, ok := parseOrigin(origin)
+	return ok && isListedOrigin(policy, parsed)
+}
+
+// isListedOrigin checks the origin against the policy's own allowed origins, other than "*"
+func isListedOrigin(policy *corsPolicy, requested origin) bool {
+	for _, pattern := range policy.origins {
+		if !pattern.any && pattern.matches(requested) {
 			return true
 		}
-		if allowed == origin {
+	}
+	return false
+}
+
+// isTenantOrigin checks the origin against the allowed origins of the request's tenant
+func (c *CORSMiddleware) isTenantOrigin(ctx fiber.Ctx, policy *corsPolicy, requested origin) bool {
+	if !policy.AllowTenantOrigins || c.tenantOrigins == nil {
+		return false
+	}
+
+	tenantID := requestTenantID(ctx)
+	if tenantID == "" {
+		return false
+	}
+
+	for _, allowed := range c.tenantOrigins(ctx.RequestCtx(), tenantID) {
+		if pattern, err := parseOriginPattern(allowed); err == nil && !pattern.any && pattern.matches(requested) {
 			return true
 		}
-		// Support wildcard subdomains like *.example.com
-		if strings.HasPrefix(allowed, "*.") {
-			domain := strings.TrimPrefix(allowed, "*.")
-			if strings.HasSuffix(origin, domain) {
-				return true
-			}
+	}
+	return false
+}
+
+// requestTenantID finds the tenant a request is for before routing, from a /tenants/{id}
+// path segment or the X-Tenant-ID header
+func requestTenantID(ctx fiber.Ctx) string {
+	segments := strings.Split(ctx.Path(), "/")
+	for i, segment := range segments {
+		if segment == "tenants" && i+1 < len(segments) && segments[i+1] != "" {
+			return segments[i+1]
 		}
 	}
+	return ctx.Get("X-Tenant-ID")
+}
 
+// containsFold checks if a list contains a value, ignoring case
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
 	return false
 }
 
 // setAllowOriginHeader sets the appropriate Access-Control-Allow-Origin header
-func (c *CORSMiddleware) setAllowOriginHeader(ctx fiber.Ctx, origin string) {
-	if c.allowCredentials {
+func (c *CORSMiddleware) setAllowOriginHeader(ctx fiber.Ctx, policy *corsPolicy, origin string) {
+	if policy.AllowCredentials {
 		// When credentials are allowed, we can't use "*"
 		// We must echo back the specific origin
-		if origin != "" && c.isOriginAllowed(origin) {
+		if origin != "" && c.isOriginAllowed(ctx, policy, origin) {
 			ctx.Set("Access-Control-Allow-Origin", origin)
 		}
 	} else {
 		// When credentials are not allowed, we can use "*" or specific origin
-		for _, allowed := range c.allowOrigins {
-			if allowed == "*" {
-				ctx.Set("Access-Control-Allow-Origin", "*")
-				return
-			}
+		if policy.anyOrigin {
+			ctx.Set("Access-Control-Allow-Origin", "*")
+			return
 		}
-		if origin != "" && c.isOriginAllowed(origin) {
+		if origin != "" && c.isOriginAllowed(ctx, policy, origin) {
 			ctx.Set("Access-Control-Allow-Origin", origin)
 		}
 	}
 }
-
-// parseEnvArray parses comma-separated environment variable into string slice
-func parseEnvArray(key string, defaultValue []string) []string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
-	}
-	
-	parts := strings.Split(value, ",")
-	result := make([]string, len(parts))
-	for i, part := range parts {
-		result[i] = strings.TrimSpace(part)
-	}
-	
-	return result
-}
-
-// parseEnvBool parses environment variable as boolean
-func parseEnvBool(key string, defaultValue bool) bool {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
-	}
-	
-	parsed, err := strconv.ParseBool(value)
-	if err != nil {
-		return defaultValue
-	}
-	
-	return parsed
-}
-
-// parseEnvInt parses environment variable as integer
-func parseEnvInt(key string, defaultValue int) int {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
-	}
-	
-	parsed, err := strconv.Atoi(value)
-	if err != nil {
-		return defaultValue
-	}
-	
-	return parsed
-}
\ No newline at end of file
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cloudparallax/parallax/internal/config"
+	"github.com/gofiber/fiber/v3"
+)
+
+func TestCORSTenantOriginsWithoutCredentials(t *testing.T) {
+	cfg := config.Default().CORS
+	cfg.AllowOrigins = []string{"https://app.example.com"}
+	cfg.AllowCredentials = true
+
+	cors := NewCORSMiddleware(corsConfig(cfg))
+	cors.UseTenantOrigins(func(ctx context.Context, tenantID string) []string {
+		if tenantID == "tenant-1" {
+			return []string{"https://tenant.example.net"}
+		}
+		return nil
+	})
+
+	app := fiber.New()
+	app.Use(cors.Handler())
+	app.Get("/api/v1/items", func(c fiber.Ctx) error {
+		if cors.IsOriginTrusted(c, c.Get("Origin")) {
+			return c.SendString("trusted")
+		}
+		return c.SendString("untrusted")
+	})
+
+	request := func(origin string) (*http.Response, string) {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/items", nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("X-Tenant-ID", "tenant-1")
+		response, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body := make([]byte, 16)
+		n, _ := response.Body.Read(body)
+		return response, string(body[:n])
+	}
+
+	response, body := request("https://app.example.com")
+	if response.Header.Get("Access-Control-Allow-Credentials") != "true" || body != "trusted" {
+		t.Fatalf("listed origin: credentials = %q, body = %q", response.Header.Get("Access-Control-Allow-Credentials"), body)
+	}
+
+	// The tenant is named by the client, so its origins may read responses but not with the
+	// user's cookies, and are not trusted for CSRF
+	response, body = request("https://tenant.example.net")
+	if response.Header.Get("Access-Control-Allow-Origin") != "https://tenant.example.net" {
+		t.Fatalf("tenant origin was not allowed: %q", response.Header.Get("Access-Control-Allow-Origin"))
+	}
+	if response.Header.Get("Access-Control-Allow-Credentials") != "" || body != "untrusted" {
+		t.Fatalf("tenant origin: credentials = %q, body = %q", response.Header.Get("Access-Control-Allow-Credentials"), body)
+	}
+
+	if response, _ := request("https://evil.example"); response.StatusCode != fiber.StatusForbidden {
+		t.Fatalf("unknown origin: status = %d, want 403", response.StatusCode)
+	}
+}
+
+func TestOriginPatternWithoutSchemeMatchesOnlyHTTPS(t *testing.T) {
+	pattern, err := parseOriginPattern("app.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for raw, want := range map[string]bool{
+		"https://app.example.com":     true,
+		"http://app.example.com":      false,
+		"https://app.example.com:443": true,
+		"http://app.example.com:443":  false,
+	} {
+		o, ok := parseOrigin(raw)
+		if !ok {
+			t.Fatalf("parseOrigin(%q) failed", raw)
+		}
+		if got := pattern.matches(o); got != want {
+			t.Errorf("matches(%q) = %v, want %v", raw, got, want)
+		}
+	}
+}
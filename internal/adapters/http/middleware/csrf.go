@@ -1,17 +1,57 @@
 package middleware
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
 	"time"
 
+	"github.com/cloudparallax/parallax/internal/config"
 	"github.com/gofiber/fiber/v3"
 )
 
-// CSRFMiddleware provides CSRF protection using double submit cookie pattern
+// CSRF protection modes
+const (
+	// CSRFModeDoubleSubmit compares a token sent with the request against the CSRF cookie
+	CSRFModeDoubleSubmit = "double-submit"
+	// CSRFModeSession keeps the CSRF secret in the session and signs tokens with the session ID
+	CSRFModeSession = "session"
+)
+
+// Enforcement levels for the Origin/Referer and Fetch Metadata checks
+const (
+	// CSRFCheckOff skips the check
+	CSRFCheckOff = "off"
+	// CSRFCheckReport logs failures without rejecting the request
+	CSRFCheckReport = "report"
+	// CSRFCheckEnforce rejects requests that fail the check
+	CSRFCheckEnforce = "enforce"
+)
+
+// csrfSecretKey is the session data key holding the session's CSRF secret
+const csrfSecretKey = "csrf_secret"
+
+// csrfExtractor reads a submitted CSRF token from one part of the request
+type csrfExtractor func(ctx fiber.Ctx) string
+
+// CSRFMiddleware provides CSRF protection using the double submit cookie pattern,
+// or synchronizer tokens bound to the session
 type CSRFMiddleware struct {
-	tokenLookup    string
+	mode           string
+	sessions       *AuthMiddleware
+	extractors     []csrfExtractor
+	exemptPaths    []string
+	trustedOrigins []string
+	originTrusted  func(ctx fiber.Ctx, origin string) bool
+	metrics        *MetricsMiddleware
+	originCheck    string
+	fetchSiteCheck string
 	cookieName     string
 	headerName     string
 	cookieSecure   bool
@@ -23,7 +63,12 @@ type CSRFMiddleware struct {
 
 // CSRFConfig holds CSRF middleware configuration
 type CSRFConfig struct {
-	TokenLookup    string              // "header:X-CSRF-Token" or "form:_token"
+	Mode           string              // CSRFModeDoubleSubmit or CSRFModeSession
+	TokenLookup    string              // Comma-separated sources such as "header:X-CSRF-Token,form:_token"; query: and param: are also supported
+	ExemptPaths    []string            // Paths that skip CSRF validation; a trailing * matches any suffix
+	TrustedOrigins []string            // Origins other than the API's own and the CORS origins that may send unsafe requests
+	OriginCheck    string              // Origin/Referer verification: CSRFCheckOff, CSRFCheckReport or CSRFCheckEnforce
+	FetchSiteCheck string              // Sec-Fetch-Site verification: CSRFCheckOff, CSRFCheckReport or CSRFCheckEnforce
 	CookieName     string              // Name of the CSRF cookie
 	HeaderName     string              // Name of the CSRF header
 	CookieSecure   bool                // Set cookie secure flag
@@ -35,14 +80,30 @@ type CSRFConfig struct {
 
 // DefaultCSRFConfig returns default CSRF configuration
 func DefaultCSRFConfig() CSRFConfig {
+	return csrfConfig(config.Default().CSRF)
+}
+
+// csrfConfig maps the CSRF settings to the middleware configuration; tokens are looked up in
+// the header, then the _token form field, unless a lookup is configured
+func csrfConfig(cfg config.CSRF) CSRFConfig {
+	tokenLookup := cfg.TokenLookup
+	if tokenLookup == "" {
+		tokenLookup = "header:" + cfg.HeaderName + ",form:_token"
+	}
+
 	return CSRFConfig{
-		TokenLookup:    "header:X-CSRF-Token",
-		CookieName:     "csrf_token",
-		HeaderName:     "X-CSRF-Token",
-		CookieSecure:   false, // Should be true in production with HTTPS
-		CookieHTTPOnly: true,
-		CookieSameSite: "Lax",
-		Expiration:     24 * time.Hour,
+		Mode:           cfg.Mode,
+		TokenLookup:    tokenLookup,
+		ExemptPaths:    cfg.ExemptPaths,
+		TrustedOrigins: cfg.TrustedOrigins,
+		OriginCheck:    cfg.OriginCheck,
+		FetchSiteCheck: cfg.FetchSiteCheck,
+		CookieName:     cfg.CookieName,
+		HeaderName:     cfg.HeaderName,
+		CookieSecure:   cfg.CookieSecure, // Should be true in production with HTTPS
+		CookieHTTPOnly: cfg.CookieHTTPOnly,
+		CookieSameSite: cfg.CookieSameSite,
+		Expiration:     cfg.Expiration,
 		KeyGenerator:   generateRandomBytes,
 	}
 }
@@ -54,8 +115,27 @@ func NewCSRFMiddleware(config ...CSRFConfig) *CSRFMiddleware {
 		cfg = config[0]
 	}
 
+	extractors, err := parseTokenLookup(cfg.TokenLookup)
+	if err != nil {
+		// Fall back to the header, which cross-site forms cannot set
+		logger.Warn("Invalid CSRF token lookup, using the header", "lookup", cfg.TokenLookup, "header", cfg.HeaderName, "error", err)
+		extractors = []csrfExtractor{headerExtractor(cfg.HeaderName)}
+	}
+
+	trustedOrigins := make([]string, 0, len(cfg.TrustedOrigins))
+	for _, origin := range cfg.TrustedOrigins {
+		if origin = normalizeOrigin(origin); origin != "" {
+			trustedOrigins = append(trustedOrigins, origin)
+		}
+	}
+
 	return &CSRFMiddleware{
-		tokenLookup:    cfg.TokenLookup,
+		mode:           cfg.Mode,
+		extractors:     extractors,
+		exemptPaths:    cfg.ExemptPaths,
+		trustedOrigins: trustedOrigins,
+		originCheck:    cfg.OriginCheck,
+		fetchSiteCheck: cfg.FetchSiteCheck,
 		cookieName:     cfg.CookieName,
 		headerName:     cfg.HeaderName,
 		cookieSecure:   cfg.CookieSecure,
@@ -66,6 +146,21 @@ func NewCSRFMiddleware(config ...CSRFConfig) *CSRFMiddleware {
 	}
 }
 
+// UseSessions gives the middleware access to sessions, which session mode stores its secrets in
+func (c *CSRFMiddleware) UseSessions(sessions *AuthMiddleware) {
+	c.sessions = sessions
+}
+
+// TrustOrigins adds a check for further trusted origins, such as the origins CORS allows for the route
+func (c *CSRFMiddleware) TrustOrigins(trusted func(ctx fiber.Ctx, origin string) bool) {
+	c.originTrusted = trusted
+}
+
+// UseMetrics counts failed checks in the given metrics
+func (c *CSRFMiddleware) UseMetrics(metrics *MetricsMiddleware) {
+	c.metrics = metrics
+}
+
 // Handler returns the CSRF middleware handler
 func (c *CSRFMiddleware) Handler() fiber.Handler {
 	return func(ctx fiber.Ctx) error {
@@ -74,8 +169,36 @@ func (c *CSRFMiddleware) Handler() fiber.Handler {
 			return c.setCSRFToken(ctx)
 		}
 
+		// Bearer tokens are not sent automatically by browsers, so they cannot be forged cross-site
+		if ctx.Locals("auth_method") == "token" || c.isExempt(ctx.Path()) {
+			return ctx.Next()
+		}
+
+		// Reject unsafe requests from origins that are not trusted, even with a valid token
+		if !c.passes(ctx, "origin", c.originCheck, c.verifyOrigin) {
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    fiber.StatusForbidden,
+					"message": "Origin not allowed",
+				},
+			})
+		}
+
+		// Reject unsafe requests the browser reports as cross-site
+		if !c.passes(ctx, "fetch metadata", c.fetchSiteCheck, c.verifyFetchSite) {
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    fiber.StatusForbidden,
+					"message": "Cross-site request not allowed",
+				},
+			})
+		}
+
 		// Validate CSRF token for unsafe methods
 		if !c.validateCSRFToken(ctx) {
+			c.metrics.csrfFailed("token", false)
 			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
 				"success": false,
 				"error": fiber.Map{
@@ -98,6 +221,14 @@ func (c *CSRFMiddleware) isSafeMethod(method string) bool {
 
 // setCSRFToken generates and sets a new CSRF token
 func (c *CSRFMiddleware) setCSRFToken(ctx fiber.Ctx) error {
+	// Session tokens are derived from the session, so there is no cookie to set
+	if c.mode == CSRFModeSession {
+		if token, err := c.sessionToken(ctx, ""); err == nil {
+			ctx.Set(c.headerName, token)
+		}
+		return ctx.Next()
+	}
+
 	// Check if token already exists and is valid
 	existingToken := ctx.Cookies(c.cookieName)
 	if existingToken != "" && c.isValidToken(existingToken) {
@@ -106,9 +237,7 @@ func (c *CSRFMiddleware) setCSRFToken(ctx fiber.Ctx) error {
 		return ctx.Next()
 	}
 
-	// Generate new token
-	token, err := c.generateToken()
-	if err != nil {
+	if _, err := c.issueCookieToken(ctx); err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"success": false,
 			"error": fiber.Map{
@@ -118,6 +247,17 @@ func (c *CSRFMiddleware) setCSRFToken(ctx fiber.Ctx) error {
 		})
 	}
 
+	return ctx.Next()
+}
+
+// issueCookieToken generates a new double-submit token and sets it as the CSRF cookie
+func (c *CSRFMiddleware) issueCookieToken(ctx fiber.Ctx) (string, error) {
+	// Generate new token
+	token, err := c.generateToken()
+	if err != nil {
+		return "", err
+	}
+
 	// Set CSRF cookie
 	ctx.Cookie(&fiber.Cookie{
 		Name:     c.cookieName,
@@ -131,30 +271,39 @@ func (c *CSRFMiddleware) setCSRFToken(ctx fiber.Ctx) error {
 	// Add token to response headers for client access
 	ctx.Set(c.headerName, token)
 
-	return ctx.Next()
+	return token, nil
 }
 
-// validateCSRFToken validates the CSRF token using double submit cookie pattern
+// validateCSRFToken validates the CSRF token against the session or the CSRF cookie.
+// Per-form tokens are only accepted for the method and path they were issued for.
 func (c *CSRFMiddleware) validateCSRFToken(ctx fiber.Ctx) bool {
+	action := formAction(ctx.Method(), ctx.Path())
+
+	if c.mode == CSRFModeSession {
+		secret, sessionID, ok := c.sessionSecret(ctx)
+		if !ok {
+			return false
+		}
+		token := c.submittedToken(ctx)
+		return verifySignedToken(secret, sessionID, token, "") || verifySignedToken(secret, sessionID, token, action)
+	}
+
 	// Get token from cookie
 	cookieToken := ctx.Cookies(c.cookieName)
 	if cookieToken == "" {
 		return false
 	}
 
-	// Get token from header or form
-	var headerToken string
-	if c.tokenLookup == "header:"+c.headerName {
-		headerToken = ctx.Get(c.headerName)
-	} else {
-		// Parse form token if needed
-		headerToken = ctx.FormValue("_token")
-	}
-
+	headerToken := c.submittedToken(ctx)
 	if headerToken == "" {
 		return false
 	}
 
+	// Per-form tokens are signed with the cookie token
+	if verifySignedToken([]byte(cookieToken), "", headerToken, action) {
+		return c.isValidToken(cookieToken)
+	}
+
 	// Validate both tokens exist and match
 	if !c.isValidToken(cookieToken) || !c.isValidToken(headerToken) {
 		return false
@@ -164,6 +313,131 @@ func (c *CSRFMiddleware) validateCSRFToken(ctx fiber.Ctx) bool {
 	return subtle.ConstantTimeCompare([]byte(cookieToken), []byte(headerToken)) == 1
 }
 
+// submittedToken extracts the token sent with the request from the first lookup source that has one
+func (c *CSRFMiddleware) submittedToken(ctx fiber.Ctx) string {
+	for _, extract := range c.extractors {
+		if token := extract(ctx); token != "" {
+			return token
+		}
+	}
+	return ""
+}
+
+// isExempt reports whether a path skips CSRF validation
+func (c *CSRFMiddleware) isExempt(path string) bool {
+	for _, exempt := range c.exemptPaths {
+		if prefix, ok := strings.CutSuffix(exempt, "*"); ok {
+			if strings.HasPrefix(path, prefix) {
+				return true
+			}
+		} else if path == exempt {
+			return true
+		}
+	}
+	return false
+}
+
+// passes runs a check at its enforcement level; failures are logged, and only rejected when enforced
+func (c *CSRFMiddleware) passes(ctx fiber.Ctx, name, level string, check func(fiber.Ctx) bool) bool {
+	if level == CSRFCheckOff || check(ctx) {
+		return true
+	}
+
+	logger.WarnContext(ctx.RequestCtx(), "CSRF check failed", "check", name, "method", ctx.Method(), "path", ctx.Path(),
+		"origin", ctx.Get(fiber.HeaderOrigin), "referer", ctx.Get(fiber.HeaderReferer), "sec_fetch_site", ctx.Get("Sec-Fetch-Site"),
+		"ip", ctx.IP(), "report_only", level == CSRFCheckReport)
+	c.metrics.csrfFailed(name, level == CSRFCheckReport)
+
+	return level == CSRFCheckReport
+}
+
+// verifyOrigin checks where an unsafe request came from using the Origin header, or the Referer when
+// there is no Origin. Requests carrying neither, such as from non-browser clients, pass.
+func (c *CSRFMiddleware) verifyOrigin(ctx fiber.Ctx) bool {
+	origin := requestOrigin(ctx)
+	return origin == "" || c.isTrustedOrigin(ctx, origin)
+}
+
+// verifyFetchSite rejects requests the browser marks as cross-site unless they come from a trusted origin.
+// Browsers that don't send Sec-Fetch-Site pass.
+func (c *CSRFMiddleware) verifyFetchSite(ctx fiber.Ctx) bool {
+	if !strings.EqualFold(ctx.Get("Sec-Fetch-Site"), "cross-site") {
+		return true
+	}
+
+	origin := requestOrigin(ctx)
+	return origin != "" && c.isTrustedOrigin(ctx, origin)
+}
+
+// isTrustedOrigin reports whether an origin is the API's own, a configured trusted origin, or allowed by CORS
+func (c *CSRFMiddleware) isTrustedOrigin(ctx fiber.Ctx, origin string) bool {
+	if origin == normalizeOrigin(ctx.BaseURL()) {
+		return true
+	}
+
+	for _, trusted := range c.trustedOrigins {
+		if origin == trusted {
+			return true
+		}
+	}
+
+	return c.originTrusted != nil && c.originTrusted(ctx, origin)
+}
+
+// requestOrigin returns the request's origin from the Origin header or else the Referer.
+// The opaque origin "null" is returned as is, so it never matches a trusted origin.
+func requestOrigin(ctx fiber.Ctx) string {
+	if origin := ctx.Get(fiber.HeaderOrigin); origin != "" {
+		return normalizeOrigin(origin)
+	}
+
+	referer, err := url.Parse(ctx.Get(fiber.HeaderReferer))
+	if err != nil || referer.Scheme == "" || referer.Host == "" {
+		return ""
+	}
+	return normalizeOrigin(referer.Scheme + "://" + referer.Host)
+}
+
+// normalizeOrigin returns an origin in canonical form, so https://a.io:443 equals https://a.io;
+// values that are not origins, such as "null", are only lowercased
+func normalizeOrigin(origin string) string {
+	if parsed, ok := parseOrigin(origin); ok {
+		return parsed.String()
+	}
+	return strings.TrimSuffix(strings.ToLower(strings.TrimSpace(origin)), "/")
+}
+
+// parseTokenLookup parses a comma-separated list of token sources such as "header:X-CSRF-Token,form:_token"
+func parseTokenLookup(lookup string) ([]csrfExtractor, error) {
+	var extractors []csrfExtractor
+	for _, source := range strings.Split(lookup, ",") {
+		kind, name, found := strings.Cut(strings.TrimSpace(source), ":")
+		name = strings.TrimSpace(name)
+		if !found || name == "" {
+			return nil, fmt.Errorf("token source %q must look like kind:name", source)
+		}
+
+		switch strings.ToLower(kind) {
+		case "header":
+			extractors = append(extractors, headerExtractor(name))
+		case "form":
+			extractors = append(extractors, func(ctx fiber.Ctx) string { return ctx.FormValue(name) })
+		case "query":
+			extractors = append(extractors, func(ctx fiber.Ctx) string { return ctx.Query(name) })
+		case "param":
+			extractors = append(extractors, func(ctx fiber.Ctx) string { return ctx.Params(name) })
+		default:
+			return nil, fmt.Errorf("unknown token source %q", kind)
+		}
+	}
+	return extractors, nil
+}
+
+// headerExtractor reads the token from a request header
+func headerExtractor(name string) csrfExtractor {
+	return func(ctx fiber.Ctx) string { return ctx.Get(name) }
+}
+
 // generateToken generates a new CSRF token
 func (c *CSRFMiddleware) generateToken() (string, error) {
 	bytes, err := c.keyGenerator()
@@ -196,11 +470,125 @@ func generateRandomBytes() ([]byte, error) {
 	return bytes, err
 }
 
-// GetToken returns the current CSRF token for the request
+// GetToken returns the current CSRF token for the request,
+// including a token that was issued while handling this request
 func (c *CSRFMiddleware) GetToken(ctx fiber.Ctx) string {
+	if token := string(ctx.Response().Header.Peek(c.headerName)); token != "" {
+		return token
+	}
+	if c.mode == CSRFModeSession {
+		token, _ := c.sessionToken(ctx, "")
+		return token
+	}
 	return ctx.Cookies(c.cookieName)
 }
 
+// FormToken returns a token that is only valid for submitting the given method and path,
+// for embedding in server-rendered forms
+func (c *CSRFMiddleware) FormToken(ctx fiber.Ctx, method, path string) (string, error) {
+	action := formAction(method, path)
+
+	if c.mode == CSRFModeSession {
+		return c.sessionToken(ctx, action)
+	}
+
+	cookieToken := c.GetToken(ctx)
+	if !c.isValidToken(cookieToken) {
+		var err error
+		if cookieToken, err = c.issueCookieToken(ctx); err != nil {
+			return "", err
+		}
+	}
+	return signToken([]byte(cookieToken), "", action)
+}
+
+// RotateToken replaces the request's CSRF token, for use whenever the session changes.
+// In session mode the new token is derived from the session set on this response, if any.
+func (c *CSRFMiddleware) RotateToken(ctx fiber.Ctx) error {
+	if c.mode == CSRFModeSession {
+		if token, err := c.sessionToken(ctx, ""); err == nil {
+			ctx.Set(c.headerName, token)
+		}
+		return nil
+	}
+
+	_, err := c.issueCookieToken(ctx)
+	return err
+}
+
+// sessionToken signs a token for the request's session, creating the session's secret on first use
+func (c *CSRFMiddleware) sessionToken(ctx fiber.Ctx, action string) (string, error) {
+	secret, sessionID, ok := c.sessionSecret(ctx)
+	if !ok {
+		return "", errors.New("no session to bind the CSRF token to")
+	}
+	return signToken(secret, sessionID, action)
+}
+
+// sessionSecret returns the CSRF secret of the request's session along with the session ID
+func (c *CSRFMiddleware) sessionSecret(ctx fiber.Ctx) ([]byte, string, bool) {
+	if c.sessions == nil {
+		return nil, "", false
+	}
+
+	sessionID := c.sessions.currentSessionID(ctx)
+	if sessionID == "" {
+		return nil, "", false
+	}
+
+	secret, err := c.sessions.sessionValue(sessionID, csrfSecretKey, func() (string, error) {
+		bytes, err := c.keyGenerator()
+		if err != nil {
+			return "", err
+		}
+		return base64.RawURLEncoding.EncodeToString(bytes), nil
+	})
+	if err != nil {
+		return nil, "", false
+	}
+
+	return []byte(secret), sessionID, true
+}
+
+// formAction identifies the request a per-form token is valid for
+func formAction(method, path string) string {
+	return strings.ToUpper(method) + " " + path
+}
+
+// signToken creates a token of a random nonce and an HMAC over the nonce, the session ID and the action.
+// The nonce makes every issued token different.
+func signToken(key []byte, sessionID, action string) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	encodedNonce := base64.RawURLEncoding.EncodeToString(nonce)
+	return encodedNonce + "." + base64.RawURLEncoding.EncodeToString(tokenMAC(key, sessionID, encodedNonce, action)), nil
+}
+
+// verifySignedToken checks a token created by signToken
+func verifySignedToken(key []byte, sessionID, token, action string) bool {
+	nonce, signature, found := strings.Cut(token, ".")
+	if !found || nonce == "" {
+		return false
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal(mac, tokenMAC(key, sessionID, nonce, action))
+}
+
+// tokenMAC computes the signature of a signed token
+func tokenMAC(key []byte, sessionID, nonce, action string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(sessionID + "\x00" + nonce + "\x00" + action))
+	return mac.Sum(nil)
+}
+
 // InvalidateToken removes the CSRF token cookie
 func (c *CSRFMiddleware) InvalidateToken(ctx fiber.Ctx) {
 	ctx.Cookie(&fiber.Cookie{
@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// impersonationMaxAge caps how long an impersonation session lasts
+const impersonationMaxAge = time.Hour
+
+// ImpersonatingHeader is the response header stamped on every request made while impersonating.
+// Its value is the ID of the admin doing the impersonation.
+const ImpersonatingHeader = "X-Impersonating"
+
+// Session data keys recording who is really behind an impersonation session
+const (
+	impersonatorIDKey      = "impersonator_id"
+	impersonatorEmailKey   = "impersonator_email"
+	impersonatorSessionKey = "impersonator_session"
+)
+
+var (
+	// ErrAlreadyImpersonating is returned when starting an impersonation from an impersonation session
+	ErrAlreadyImpersonating = errors.New("already impersonating a user")
+	// ErrNotImpersonating is returned when ending an impersonation from a regular session
+	ErrNotImpersonating = errors.New("not impersonating a user")
+)
+
+// Impersonate starts a session as another user on behalf of the signed-in admin.
+// The admin's own session is kept so StopImpersonation can return to it.
+func (a *AuthMiddleware) Impersonate(c fiber.Ctx, userID string, userData map[string]interface{}) error {
+	adminSessionID := c.Cookies(a.cookieName)
+	admin, exists := a.GetSession(adminSessionID)
+	if !exists {
+		return errors.New("impersonation requires a session")
+	}
+
+	if isImpersonating(admin) {
+		return ErrAlreadyImpersonating
+	}
+
+	data := make(map[string]interface{}, len(userData)+3)
+	for key, value := range userData {
+		data[key] = value
+	}
+	data[impersonatorIDKey] = admin.UserID
+	data[impersonatorEmailKey] = admin.Data["email"]
+	data[impersonatorSessionKey] = admin.ID
+
+	session, err := a.CreateSession(userID, data)
+	if err != nil {
+		return err
+	}
+
+	// An impersonation never outlives the admin's own session
+	expiresAt := time.Now().Add(impersonationMaxAge)
+	if admin.ExpiresAt.Before(expiresAt) {
+		expiresAt = admin.ExpiresAt
+	}
+	a.store.mutex.Lock()
+	session.ExpiresAt = expiresAt
+	a.store.mutex.Unlock()
+
+	a.setSessionCookie(c, session)
+	logger.InfoContext(c.RequestCtx(), "Impersonation started", "admin_id", admin.UserID, "impersonated_user_id", userID, "ip", c.IP())
+
+	return nil
+}
+
+// StopImpersonation ends the current impersonation and restores the admin's own session.
+// It returns the admin's user ID.
+func (a *AuthMiddleware) StopImpersonation(c fiber.Ctx) (string, error) {
+	sessionID := c.Cookies(a.cookieName)
+	session, exists := a.GetSession(sessionID)
+	if !exists || !isImpersonating(session) {
+		return "", ErrNotImpersonating
+	}
+
+	a.DeleteSession(sessionID)
+
+	adminID, _ := session.Data[impersonatorIDKey].(string)
+	logger.InfoContext(c.RequestCtx(), "Impersonation ended", "admin_id", adminID, "impersonated_user_id", session.UserID, "ip", c.IP())
+
+	adminSessionID, _ := session.Data[impersonatorSessionKey].(string)
+	admin, exists := a.GetSession(adminSessionID)
+	if !exists {
+		// The admin's session expired meanwhile, so sign out completely
+		return adminID, a.Logout(c)
+	}
+
+	a.setSessionCookie(c, admin)
+	return adminID, nil
+}
+
+// BlockImpersonation rejects requests made while impersonating, for actions only the real user may take
+func (a *AuthMiddleware) BlockImpersonation() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if session, ok := c.Locals("session").(*Session); ok && isImpersonating(session) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    fiber.StatusForbidden,
+					"message": "Not allowed while impersonating a user",
+				},
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// isImpersonating reports whether a session belongs to an admin acting as another user
+func isImpersonating(session *Session) bool {
+	_, exists := session.Data[impersonatorIDKey]
+	return exists
+}
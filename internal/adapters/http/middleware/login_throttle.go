@@ -0,0 +1,203 @@
+package middleware
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudparallax/parallax/internal/config"
+)
+
+// LoginThrottleConfig holds brute-force protection configuration for sign-in endpoints
+type LoginThrottleConfig struct {
+	MaxFailures     int           // Failures per username before the account is locked
+	MaxIPFailures   int           // Failures per IP address before the address is locked
+	LockoutDuration time.Duration // How long a locked username or IP address stays locked
+	BackoffBase     time.Duration // Delay enforced after the first failure, doubled for each further failure
+	BackoffMax      time.Duration // Upper bound for the enforced delay
+	FailureWindow   time.Duration // Failures older than this are forgotten
+}
+
+// DefaultLoginThrottleConfig returns default brute-force protection configuration
+func DefaultLoginThrottleConfig() LoginThrottleConfig {
+	return loginThrottleConfig(config.Default().Login)
+}
+
+// loginThrottleConfig maps the sign-in protection settings to the throttle configuration
+func loginThrottleConfig(cfg config.Login) LoginThrottleConfig {
+	return LoginThrottleConfig{
+		MaxFailures:     cfg.MaxFailures,
+		MaxIPFailures:   cfg.MaxIPFailures,
+		LockoutDuration: cfg.LockoutDuration,
+		BackoffBase:     cfg.BackoffBase,
+		BackoffMax:      cfg.BackoffMax,
+		FailureWindow:   cfg.FailureWindow,
+	}
+}
+
+// loginAttempts tracks recent failed sign-ins for a username or IP address
+type loginAttempts struct {
+	failures     int
+	lastFailure  time.Time
+	blockedUntil time.Time
+	lockedUntil  time.Time
+}
+
+// LoginThrottler tracks failed sign-ins per username and per IP address and
+// enforces exponential backoff and temporary lockouts. Usernames are tracked
+// whether or not an account exists, so responses do not reveal which do.
+type LoginThrottler struct {
+	config LoginThrottleConfig
+	users  map[string]*loginAttempts
+	ips    map[string]*loginAttempts
+	mutex  sync.Mutex
+}
+
+// NewLoginThrottler creates a new login throttler
+func NewLoginThrottler(config ...LoginThrottleConfig) *LoginThrottler {
+	cfg := DefaultLoginThrottleConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	return &LoginThrottler{
+		config: cfg,
+		users:  make(map[string]*loginAttempts),
+		ips:    make(map[string]*loginAttempts),
+	}
+}
+
+// Check reports whether a sign-in for the username from the IP address may be attempted now,
+// and if not, how long the client has to wait
+func (t *LoginThrottler) Check(username, ip string) (time.Duration, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := time.Now()
+	wait := t.waitFor(t.users[normalizeUsername(username)], now)
+	if ipWait := t.waitFor(t.ips[ip], now); ipWait > wait {
+		wait = ipWait
+	}
+
+	return wait, wait == 0
+}
+
+// RecordFailure records a failed sign-in, locking the username or IP address once they reach their limit
+func (t *LoginThrottler) RecordFailure(username, ip string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := time.Now()
+	username = normalizeUsername(username)
+
+	user := t.attempts(t.users, username, now)
+	user.failures++
+	user.lastFailure = now
+	user.blockedUntil = now.Add(t.backoff(user.failures))
+	if user.failures >= t.config.MaxFailures && now.After(user.lockedUntil) {
+		user.lockedUntil = now.Add(t.config.LockoutDuration)
+		logger.Warn("Login lockout", "username", username, "ip", ip, "failures", user.failures, "until", user.lockedUntil)
+	}
+
+	address := t.attempts(t.ips, ip, now)
+	address.failures++
+	address.lastFailure = now
+	// An IP address is only slowed down once it fails more often than a single user may,
+	// so a few typos behind a shared NAT do not affect everyone else
+	if excess := address.failures - t.config.MaxFailures; excess > 0 {
+		address.blockedUntil = now.Add(t.backoff(excess))
+	}
+	if address.failures >= t.config.MaxIPFailures && now.After(address.lockedUntil) {
+		address.lockedUntil = now.Add(t.config.LockoutDuration)
+		logger.Warn("Login lockout", "ip", ip, "username", username, "failures", address.failures, "until", address.lockedUntil)
+	}
+}
+
+// RecordSuccess clears the failure history of a username after a successful sign-in.
+// The IP address history is kept so one valid account cannot reset an attacker's counter.
+func (t *LoginThrottler) RecordSuccess(username string) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	delete(t.users, normalizeUsername(username))
+}
+
+// Unlock clears a username's failures and lockout, returning whether it was being tracked
+func (t *LoginThrottler) Unlock(username string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	username = normalizeUsername(username)
+	_, exists := t.users[username]
+	delete(t.users, username)
+	if exists {
+		logger.Info("Login lockout cleared", "username", username)
+	}
+
+	return exists
+}
+
+// CleanupExpired removes failure histories that are outside the window and no longer locked
+func (t *LoginThrottler) CleanupExpired() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := time.Now()
+	for _, entries := range []map[string]*loginAttempts{t.users, t.ips} {
+		for key, entry := range entries {
+			if t.expired(entry, now) {
+				delete(entries, key)
+			}
+		}
+	}
+}
+
+// attempts returns the failure history for a key, starting over if the previous one expired
+func (t *LoginThrottler) attempts(entries map[string]*loginAttempts, key string, now time.Time) *loginAttempts {
+	entry, exists := entries[key]
+	if !exists || t.expired(entry, now) {
+		entry = &loginAttempts{}
+		entries[key] = entry
+	}
+	return entry
+}
+
+// expired reports whether a failure history can be forgotten
+func (t *LoginThrottler) expired(entry *loginAttempts, now time.Time) bool {
+	return now.Sub(entry.lastFailure) > t.config.FailureWindow && now.After(entry.lockedUntil)
+}
+
+// waitFor returns how long a client must wait before its next attempt
+func (t *LoginThrottler) waitFor(entry *loginAttempts, now time.Time) time.Duration {
+	if entry == nil {
+		return 0
+	}
+
+	until := entry.blockedUntil
+	if entry.lockedUntil.After(until) {
+		until = entry.lockedUntil
+	}
+
+	if now.After(until) {
+		return 0
+	}
+	return until.Sub(now)
+}
+
+// backoff returns the delay enforced after the given number of consecutive failures
+func (t *LoginThrottler) backoff(failures int) time.Duration {
+	delay := t.config.BackoffBase
+	for i := 1; i < failures && delay < t.config.BackoffMax; i++ {
+		delay *= 2
+	}
+
+	if delay > t.config.BackoffMax {
+		delay = t.config.BackoffMax
+	}
+	return delay
+}
+
+// normalizeUsername makes username tracking case-insensitive
+func normalizeUsername(username string) string {
+	return strings.ToLower(strings.TrimSpace(username))
+}
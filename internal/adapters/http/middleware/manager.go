@@ -1,13 +1,15 @@
 package middleware
 
 import (
-	"os"
-	"strconv"
+	"context"
 	"time"
 
+	"github.com/cloudparallax/parallax/internal/config"
+	"github.com/cloudparallax/parallax/pkg/health"
+	"github.com/cloudparallax/parallax/pkg/lifecycle"
+	"github.com/cloudparallax/parallax/pkg/metrics"
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/compress"
-	"github.com/gofiber/fiber/v3/middleware/logger"
 	"github.com/gofiber/fiber/v3/middleware/recover"
 )
 
@@ -16,7 +18,11 @@ type MiddlewareManager struct {
 	cors      *CORSMiddleware
 	csrf      *CSRFMiddleware
 	auth      *AuthMiddleware
+	tokens    *TokenManager
+	logins    *LoginThrottler
 	rateLimit *RateLimitMiddleware
+	requests  *RequestLogMiddleware
+	metrics   *MetricsMiddleware
 }
 
 // MiddlewareConfig holds configuration for all middlewares
@@ -25,24 +31,54 @@ type MiddlewareConfig struct {
 	SessionCookieName string
 	SessionMaxAge     time.Duration
 
+	// Token configuration
+	TokenConfig TokenConfig
+
+	// Sign-in brute-force protection configuration
+	LoginThrottleConfig LoginThrottleConfig
+
+	// CORS configuration
+	CORSConfig CORSConfig
+
 	// CSRF configuration
 	CSRFConfig CSRFConfig
 
 	// Rate limit configuration
 	RateLimitConfig RateLimitConfig
 
+	// Request ID and request log configuration
+	RequestLogConfig RequestLogConfig
+
+	// Metrics configuration
+	MetricsConfig MetricsConfig
+
 	// Environment
 	Environment string
 }
 
 // DefaultMiddlewareConfig returns default middleware configuration
 func DefaultMiddlewareConfig() MiddlewareConfig {
+	return NewMiddlewareConfig(config.Default())
+}
+
+// NewMiddlewareConfig maps the application configuration to the middleware configuration
+func NewMiddlewareConfig(cfg *config.Config) MiddlewareConfig {
+	rateLimit := rateLimitConfig(cfg.RateLimit)
+	rateLimit.Store = rateLimitStore(cfg.RateLimit)
+	tokens := tokenConfig(cfg.JWT)
+	tokens.Store = refreshTokenStore(cfg.JWT, cfg.RateLimit)
+
 	return MiddlewareConfig{
-		SessionCookieName: getEnv("SESSION_COOKIE_NAME", "session_id"),
-		SessionMaxAge:     parseDuration("SESSION_MAX_AGE", 24*time.Hour),
-		CSRFConfig:        DefaultCSRFConfig(),
-		RateLimitConfig:   DefaultRateLimitConfig(),
-		Environment:       getEnv("APP_ENV", "development"),
+		SessionCookieName:   cfg.Session.CookieName,
+		SessionMaxAge:       cfg.Session.MaxAge,
+		TokenConfig:         tokens,
+		LoginThrottleConfig: loginThrottleConfig(cfg.Login),
+		CORSConfig:          corsConfig(cfg.CORS),
+		CSRFConfig:          csrfConfig(cfg.CSRF),
+		RateLimitConfig:     rateLimit,
+		RequestLogConfig:    requestLogConfig(cfg.Logging),
+		MetricsConfig:       metricsConfig(cfg.Metrics),
+		Environment:         cfg.Server.Environment,
 	}
 }
 
@@ -58,22 +94,60 @@ func NewMiddlewareManager(config ...MiddlewareConfig) *MiddlewareManager {
 		cfg.CSRFConfig.CookieSecure = true
 	}
 
+	tokens := NewTokenManager(cfg.TokenConfig)
+	auth := NewAuthMiddleware(cfg.SessionCookieName, cfg.SessionMaxAge)
+	auth.UseTokenManager(tokens)
+	cors := NewCORSMiddleware(cfg.CORSConfig)
+	csrf := NewCSRFMiddleware(cfg.CSRFConfig)
+	csrf.UseSessions(auth)
+	csrf.TrustOrigins(cors.IsOriginTrusted)
+	rateLimit := NewRateLimitMiddleware(cfg.RateLimitConfig)
+
+	metricsMiddleware := NewMetricsMiddleware(cfg.MetricsConfig)
+	csrf.UseMetrics(metricsMiddleware)
+	rateLimit.UseMetrics(metricsMiddleware)
+	metricsMiddleware.Registry().NewGaugeFunc("parallax_active_sessions", "Signed-in sessions that haven't expired.", nil,
+		func() []metrics.Sample { return []metrics.Sample{{Value: float64(auth.ActiveSessions())}} })
+
 	return &MiddlewareManager{
-		cors:      NewCORSMiddleware(),
-		csrf:      NewCSRFMiddleware(cfg.CSRFConfig),
-		auth:      NewAuthMiddleware(cfg.SessionCookieName, cfg.SessionMaxAge),
-		rateLimit: NewRateLimitMiddleware(cfg.RateLimitConfig),
+		cors:      cors,
+		csrf:      csrf,
+		auth:      auth,
+		tokens:    tokens,
+		logins:    NewLoginThrottler(cfg.LoginThrottleConfig),
+		rateLimit: rateLimit,
+		requests:  NewRequestLogMiddleware(cfg.RequestLogConfig),
+		metrics:   metricsMiddleware,
 	}
 }
 
+// Reload applies the reloadable settings of a configuration to the running middlewares: the
+// token signing keys, the CORS policies and the rate limit rules. Each is checked before it
+// is applied, and the CORS policies before anything is.
+func (m *MiddlewareManager) Reload(cfg *config.Config) error {
+	cors := corsConfig(cfg.CORS)
+	if err := cors.Validate(); err != nil {
+		return err
+	}
+	if err := m.tokens.Reload(tokenConfig(cfg.JWT)); err != nil {
+		return err
+	}
+	if err := m.rateLimit.Reload(rateLimitConfig(cfg.RateLimit)); err != nil {
+		return err
+	}
+	return m.cors.Reload(cors)
+}
+
 // SetupGlobalMiddleware configures global middlewares that apply to all routes
 func (m *MiddlewareManager) SetupGlobalMiddleware(app *fiber.App) {
-	// Logger middleware
-	app.Use(logger.New(logger.Config{
-		Format:     "[${time}] ${status} - ${method} ${path} - ${latency} - ${ip}\n",
-		TimeFormat: "15:04:05",
-		Stream:     os.Stdout,
-	}))
+	// Tracing middleware, first so every other middleware runs within the request span
+	app.Use(TracingHandler())
+
+	// Request metrics middleware
+	app.Use(m.metrics.Handler())
+
+	// Request ID and request log middleware
+	app.Use(m.requests.Handler())
 
 	// Recover middleware
 	app.Use(recover.New(recover.Config{
@@ -101,6 +175,52 @@ func (m *MiddlewareManager) SetupGlobalMiddleware(app *fiber.App) {
 	})
 }
 
+// UseCORSPolicy applies a named CORS policy to a route group and everything below it
+func (m *MiddlewareManager) UseCORSPolicy(group fiber.Router, name string) {
+	prefix := "/"
+	if g, ok := group.(*fiber.Group); ok {
+		prefix = g.Prefix
+	}
+	m.cors.UsePolicy(prefix, name)
+}
+
+// SetTenantOriginResolver sets how per-tenant allowed origins are looked up
+func (m *MiddlewareManager) SetTenantOriginResolver(resolver TenantOriginResolver) {
+	m.cors.UseTenantOrigins(resolver)
+}
+
+// UseRateLimitRule applies a named rate limit rule to a route group and everything below it,
+// in place of the default rule. Call it before adding the group's routes.
+func (m *MiddlewareManager) UseRateLimitRule(group fiber.Router, name string) {
+	prefix := "/"
+	if g, ok := group.(*fiber.Group); ok {
+		prefix = g.Prefix
+	}
+	group.Use(m.rateLimit.UseRule(prefix, name))
+}
+
+// SetTenantPlanResolver sets how tenant plans are looked up for rate limits that scale with the plan
+func (m *MiddlewareManager) SetTenantPlanResolver(resolver TenantPlanResolver) {
+	m.rateLimit.UseTenantPlans(resolver)
+}
+
+// MetricsEndpoint returns the path and handler of the metrics endpoint, if metrics are enabled
+func (m *MiddlewareManager) MetricsEndpoint() (string, fiber.Handler, bool) {
+	return m.metrics.path, m.metrics.Endpoint(), m.metrics.enabled
+}
+
+// Metrics returns the registry that further metrics can be added to
+func (m *MiddlewareManager) Metrics() *metrics.Registry {
+	return m.metrics.Registry()
+}
+
+// RegisterHealthChecks registers the session, rate limit and refresh token store checks
+func (m *MiddlewareManager) RegisterHealthChecks(registry *health.Registry) {
+	registry.Register("sessions", m.auth.HealthCheck)
+	registry.Register("rate_limit_store", m.rateLimit.HealthCheck)
+	registry.Register("refresh_token_store", m.tokens.HealthCheck)
+}
+
 // SetupCSRF sets up CSRF protection for specific routes
 func (m *MiddlewareManager) SetupCSRF() fiber.Handler {
 	return m.csrf.Handler()
@@ -116,6 +236,11 @@ func (m *MiddlewareManager) RequireAuth() fiber.Handler {
 	return m.auth.RequireAuth()
 }
 
+// RequireSession returns a handler that accepts sessions still awaiting two-factor authentication
+func (m *MiddlewareManager) RequireSession() fiber.Handler {
+	return m.auth.RequireSession()
+}
+
 // OptionalAuth returns optional authentication middleware handler
 func (m *MiddlewareManager) OptionalAuth() fiber.Handler {
 	return m.auth.OptionalAuth()
@@ -126,19 +251,131 @@ func (m *MiddlewareManager) RequireRole(role string) fiber.Handler {
 	return m.auth.RequireRole(role)
 }
 
+// RequirePermission returns tenant permission authorization middleware handler
+func (m *MiddlewareManager) RequirePermission(permission string, resolver ...TenantResolver) fiber.Handler {
+	return m.auth.RequirePermission(permission, resolver...)
+}
+
+// SetPermissionResolver sets how tenant permissions are looked up
+func (m *MiddlewareManager) SetPermissionResolver(resolver PermissionResolver) {
+	m.auth.UsePermissionResolver(resolver)
+}
+
 // GetCSRFToken returns the CSRF token for the current request
 func (m *MiddlewareManager) GetCSRFToken(c fiber.Ctx) string {
 	return m.csrf.GetToken(c)
 }
 
-// Login authenticates a user and creates a session
+// GetCSRFFormToken returns a CSRF token that is only valid for submitting the given method and path
+func (m *MiddlewareManager) GetCSRFFormToken(c fiber.Ctx, method, path string) (string, error) {
+	return m.csrf.FormToken(c, method, path)
+}
+
+// Login authenticates a user, creates a session and rotates the CSRF token
 func (m *MiddlewareManager) Login(c fiber.Ctx, userID string, userData map[string]interface{}) error {
-	return m.auth.Login(c, userID, userData)
+	if err := m.auth.Login(c, userID, userData); err != nil {
+		return err
+	}
+	return m.csrf.RotateToken(c)
 }
 
-// Logout logs out a user and destroys the session
+// CompleteMFA marks the current session as having passed two-factor authentication
+func (m *MiddlewareManager) CompleteMFA(c fiber.Ctx) error {
+	if err := m.auth.CompleteMFA(c); err != nil {
+		return err
+	}
+	return m.csrf.RotateToken(c)
+}
+
+// Impersonate starts a session as another user on behalf of the signed-in admin
+func (m *MiddlewareManager) Impersonate(c fiber.Ctx, userID string, userData map[string]interface{}) error {
+	if err := m.auth.Impersonate(c, userID, userData); err != nil {
+		return err
+	}
+	return m.csrf.RotateToken(c)
+}
+
+// StopImpersonation ends an impersonation and returns the admin to their own session
+func (m *MiddlewareManager) StopImpersonation(c fiber.Ctx) (string, error) {
+	adminID, err := m.auth.StopImpersonation(c)
+	if err != nil {
+		return "", err
+	}
+	return adminID, m.csrf.RotateToken(c)
+}
+
+// BlockImpersonation returns a handler that rejects requests made while impersonating
+func (m *MiddlewareManager) BlockImpersonation() fiber.Handler {
+	return m.auth.BlockImpersonation()
+}
+
+// Logout logs out a user, destroys the session and clears the CSRF token
 func (m *MiddlewareManager) Logout(c fiber.Ctx) error {
-	return m.auth.Logout(c)
+	if err := m.auth.Logout(c); err != nil {
+		return err
+	}
+	m.csrf.InvalidateToken(c)
+	return nil
+}
+
+// CheckLogin reports whether a sign-in may be attempted now, and if not, how long to wait
+func (m *MiddlewareManager) CheckLogin(username, ip string) (time.Duration, bool) {
+	wait, allowed := m.logins.Check(username, ip)
+	if !allowed {
+		m.metrics.login(loginThrottled)
+	}
+	return wait, allowed
+}
+
+// RecordLoginFailure records a failed sign-in for brute-force protection
+func (m *MiddlewareManager) RecordLoginFailure(username, ip string) {
+	m.logins.RecordFailure(username, ip)
+	m.metrics.login(loginFailure)
+}
+
+// RecordLoginSuccess clears a username's failed sign-ins
+func (m *MiddlewareManager) RecordLoginSuccess(username string) {
+	m.logins.RecordSuccess(username)
+	m.metrics.login(loginSuccess)
+}
+
+// UnlockLogin lifts a username's sign-in lockout
+func (m *MiddlewareManager) UnlockLogin(username string) bool {
+	return m.logins.Unlock(username)
+}
+
+// RevokeUserSessions signs a user out everywhere by deleting their sessions and refresh tokens.
+// Refresh tokens the store fails to revoke are still refused once the user is deactivated.
+func (m *MiddlewareManager) RevokeUserSessions(ctx context.Context, userID string) {
+	m.auth.DeleteUserSessions(userID)
+	if err := m.tokens.RevokeSubject(ctx, userID); err != nil {
+		logger.Error("Failed to revoke refresh tokens", "user_id", userID, "error", err)
+	}
+}
+
+// IssueTokens issues a signed access token and a refresh token for a user
+func (m *MiddlewareManager) IssueTokens(ctx context.Context, userID string, scopes []string) (*TokenPair, error) {
+	return m.tokens.IssueTokens(ctx, userID, scopes)
+}
+
+// RefreshTokens rotates a refresh token and issues a new token pair
+func (m *MiddlewareManager) RefreshTokens(ctx context.Context, refreshToken string) (*TokenPair, error) {
+	return m.tokens.Refresh(ctx, refreshToken)
+}
+
+// RevokeRefreshToken revokes a refresh token and every token rotated from it
+func (m *MiddlewareManager) RevokeRefreshToken(ctx context.Context, refreshToken string) error {
+	return m.tokens.RevokeRefreshToken(ctx, refreshToken)
+}
+
+// SetTokenSubjectResolver sets how the tenant, role and grantable scopes of token subjects are looked up
+func (m *MiddlewareManager) SetTokenSubjectResolver(resolver TokenSubjectResolver) {
+	m.tokens.UseSubjects(resolver)
+}
+
+// JWKS returns the public token verification keys
+func (m *MiddlewareManager) JWKS() JSONWebKeySet {
+	return m.tokens.JWKS()
 }
 
 // securityHeaders adds security headers to responses
@@ -168,46 +405,19 @@ func (m *MiddlewareManager) CleanupMiddlewares() {
 	// Cleanup expired sessions
 	m.auth.CleanupExpiredSessions()
 
-	// Cleanup expired rate limiters
-	m.rateLimit.CleanupExpiredLimiters()
-}
-
-// StartCleanupRoutine starts a goroutine to periodically cleanup middlewares
-func (m *MiddlewareManager) StartCleanupRoutine() {
-	go func() {
-		ticker := time.NewTicker(30 * time.Minute)
-		defer ticker.Stop()
+	// Cleanup expired refresh tokens and retired signing keys
+	m.tokens.CleanupExpiredTokens()
 
-		for range ticker.C {
-			m.CleanupMiddlewares()
-		}
-	}()
-}
+	// Cleanup expired sign-in failure histories
+	m.logins.CleanupExpired()
 
-// getEnv gets environment variable with fallback
-func getEnv(key, fallback string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return fallback
+	// Cleanup expired rate limiters
+	m.rateLimit.CleanupExpiredLimiters()
 }
 
-// parseDuration parses duration from environment variable with fallback
-func parseDuration(key string, fallback time.Duration) time.Duration {
-	value := os.Getenv(key)
-	if value == "" {
-		return fallback
-	}
-
-	// Try parsing as duration string (e.g., "24h", "30m")
-	if duration, err := time.ParseDuration(value); err == nil {
-		return duration
-	}
-
-	// Try parsing as hours
-	if hours, err := strconv.Atoi(value); err == nil {
-		return time.Duration(hours) * time.Hour
-	}
-
-	return fallback
+// StartCleanupRoutine periodically cleans up middlewares as a background task, stopped at shutdown
+func (m *MiddlewareManager) StartCleanupRoutine(background *lifecycle.Manager) error {
+	return background.Every("middleware_cleanup", 30*time.Minute, func(ctx context.Context) {
+		m.CleanupMiddlewares()
+	})
 }
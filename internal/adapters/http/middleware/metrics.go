@@ -0,0 +1,163 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudparallax/parallax/internal/config"
+	"github.com/cloudparallax/parallax/pkg/metrics"
+	"github.com/gofiber/fiber/v3"
+)
+
+// Sign-in results counted by the logins metric
+const (
+	loginSuccess   = "success"
+	loginFailure   = "failure"
+	loginThrottled = "throttled"
+)
+
+// MetricsConfig holds configuration for metrics and the metrics endpoint
+type MetricsConfig struct {
+	Enabled bool   // Record metrics and serve the endpoint
+	Path    string // Path of the metrics endpoint
+	Token   string // Bearer token required to read metrics; the endpoint is open when empty
+}
+
+// DefaultMetricsConfig returns default metrics configuration
+func DefaultMetricsConfig() MetricsConfig {
+	return metricsConfig(config.Default().Metrics)
+}
+
+// metricsConfig maps the metrics settings to the middleware configuration
+func metricsConfig(cfg config.Metrics) MetricsConfig {
+	return MetricsConfig{
+		Enabled: cfg.Enabled,
+		Path:    cfg.Path,
+		Token:   cfg.Token,
+	}
+}
+
+// MetricsMiddleware records HTTP, sign-in, CSRF and rate limit metrics and serves them in the
+// Prometheus text format
+type MetricsMiddleware struct {
+	enabled             bool
+	path                string
+	token               string
+	registry            *metrics.Registry
+	requestDuration     *metrics.Histogram
+	requestsInFlight    *metrics.Gauge
+	rateLimitRejections *metrics.Counter
+	csrfFailures        *metrics.Counter
+	logins              *metrics.Counter
+}
+
+// NewMetricsMiddleware creates a new metrics middleware
+func NewMetricsMiddleware(config ...MetricsConfig) *MetricsMiddleware {
+	cfg := DefaultMetricsConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Path == "" {
+		cfg.Path = "/metrics"
+	}
+
+	registry := metrics.NewRegistry()
+	return &MetricsMiddleware{
+		enabled:  cfg.Enabled,
+		path:     cfg.Path,
+		token:    cfg.Token,
+		registry: registry,
+		requestDuration: registry.NewHistogram("parallax_http_request_duration_seconds",
+			"HTTP request latency by route template and status.", metrics.DefaultBuckets, "method", "route", "status"),
+		requestsInFlight: registry.NewGauge("parallax_http_requests_in_flight",
+			"HTTP requests being served."),
+		rateLimitRejections: registry.NewCounter("parallax_rate_limit_rejections_total",
+			"Requests rejected by rate limits, by rule and the kind of key limited.", "rule", "key_type"),
+		csrfFailures: registry.NewCounter("parallax_csrf_failures_total",
+			"Failed CSRF checks, by check and whether the check is enforced or only reported.", "check", "mode"),
+		logins: registry.NewCounter("parallax_logins_total",
+			"Sign-in attempts by result.", "result"),
+	}
+}
+
+// Registry returns the registry that further metrics can be added to
+func (m *MetricsMiddleware) Registry() *metrics.Registry {
+	return m.registry
+}
+
+// Handler returns the middleware handler that records request metrics
+func (m *MetricsMiddleware) Handler() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if !m.enabled {
+			return c.Next()
+		}
+
+		start := time.Now()
+		m.requestsInFlight.Inc()
+		defer m.requestsInFlight.Dec()
+
+		err := c.Next()
+
+		// Route templates such as /api/v1/tenants/:id keep the number of series bounded
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = fiber.StatusInternalServerError
+			if e, ok := err.(*fiber.Error); ok {
+				status = e.Code
+			}
+		}
+		m.requestDuration.Observe(time.Since(start).Seconds(), c.Method(), c.Route().Path, strconv.Itoa(status))
+		return err
+	}
+}
+
+// Endpoint returns the handler that serves metrics, behind the bearer token when one is configured
+func (m *MetricsMiddleware) Endpoint() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		if m.token != "" {
+			token, found := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+			if !found || subtle.ConstantTimeCompare([]byte(token), []byte(m.token)) != 1 {
+				c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="metrics"`)
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"success": false,
+					"error": fiber.Map{
+						"code":    fiber.StatusUnauthorized,
+						"message": "Invalid metrics token",
+					},
+				})
+			}
+		}
+
+		c.Set(fiber.HeaderContentType, metrics.ContentType)
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		_, err := m.registry.WriteTo(c.Response().BodyWriter())
+		return err
+	}
+}
+
+// rateLimitRejected counts a request rejected by a rate limit rule
+func (m *MetricsMiddleware) rateLimitRejected(rule, keyType string) {
+	if m != nil && m.enabled {
+		m.rateLimitRejections.Inc(rule, keyType)
+	}
+}
+
+// csrfFailed counts a failed CSRF check
+func (m *MetricsMiddleware) csrfFailed(check string, reportOnly bool) {
+	if m != nil && m.enabled {
+		mode := "enforce"
+		if reportOnly {
+			mode = "report"
+		}
+		m.csrfFailures.Inc(check, mode)
+	}
+}
+
+// login counts a sign-in attempt
+func (m *MetricsMiddleware) login(result string) {
+	if m != nil && m.enabled {
+		m.logins.Inc(result)
+	}
+}
@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// origin is a parsed web origin: scheme, host and port
+type origin struct {
+	scheme string
+	host   string
+	port   string
+}
+
+// parseOrigin parses an Origin header value; the port defaults to the scheme's default port
+func parseOrigin(raw string) (origin, bool) {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || u.Scheme == "" || u.Host == "" || u.User != nil ||
+		(u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return origin{}, false
+	}
+
+	o := origin{
+		scheme: strings.ToLower(u.Scheme),
+		host:   strings.ToLower(u.Hostname()),
+		port:   u.Port(),
+	}
+	if o.port == "" {
+		o.port = defaultPort(o.scheme)
+	}
+	return o, o.host != ""
+}
+
+// String returns the origin in canonical form, omitting the scheme's default port
+func (o origin) String() string {
+	if o.port == defaultPort(o.scheme) {
+		if strings.Contains(o.host, ":") {
+			return o.scheme + "://[" + o.host + "]"
+		}
+		return o.scheme + "://" + o.host
+	}
+	return o.scheme + "://" + net.JoinHostPort(o.host, o.port)
+}
+
+// defaultPort returns the default port of a scheme
+func defaultPort(scheme string) string {
+	switch scheme {
+	case "http", "ws":
+		return "80"
+	case "https", "wss":
+		return "443"
+	}
+	return ""
+}
+
+// originPattern is an allowed origin: "*", an exact origin such as https://app.example.com:8443,
+// a subdomain wildcard such as https://*.example.com, or a regular expression prefixed with "regex:"
+type originPattern struct {
+	any        bool
+	regex      *regexp.Regexp
+	scheme     string // https when the pattern has no scheme
+	host       string
+	subdomains bool   // host is a parent domain, and only its subdomains match
+	port       string // empty is the scheme's default port, "*" is any port
+}
+
+// parseOriginPattern parses an allowed origin
+func parseOriginPattern(raw string) (originPattern, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "*" {
+		return originPattern{any: true}, nil
+	}
+
+	if expression, ok := strings.CutPrefix(raw, "regex:"); ok {
+		// Patterns must match the whole origin
+		regex, err := regexp.Compile("^(?:" + expression + ")$")
+		if err != nil {
+			return originPattern{}, fmt.Errorf("origin pattern %q: %w", raw, err)
+		}
+		return originPattern{regex: regex}, nil
+	}
+
+	// Patterns without a scheme only match https, so that they never trust plain-text origins
+	pattern := originPattern{scheme: "https"}
+	rest := raw
+	if scheme, remainder, found := strings.Cut(raw, "://"); found {
+		pattern.scheme = strings.ToLower(scheme)
+		rest = remainder
+	}
+
+	rest = strings.TrimSuffix(rest, "/")
+	if rest == "" || strings.ContainsAny(rest, "/?#@") {
+		return originPattern{}, fmt.Errorf("origin %q must be a scheme, host and optional port", raw)
+	}
+
+	host := rest
+	if i := strings.LastIndex(rest, ":"); i >= 0 && !strings.HasSuffix(rest, "]") {
+		host, pattern.port = rest[:i], rest[i+1:]
+		if _, err := strconv.Atoi(pattern.port); err != nil && pattern.port != "*" {
+			return originPattern{}, fmt.Errorf("origin %q has an invalid port", raw)
+		}
+	}
+
+	if parent, ok := strings.CutPrefix(host, "*."); ok {
+		pattern.subdomains = true
+		host = parent
+	}
+	host = strings.ToLower(strings.Trim(host, "[]"))
+	if host == "" || strings.Contains(host, "*") {
+		return originPattern{}, fmt.Errorf("origin %q: wildcards are only supported as the leftmost label, as in *.example.com", raw)
+	}
+	pattern.host = host
+
+	return pattern, nil
+}
+
+// matches checks an origin against the pattern
+func (p originPattern) matches(o origin) bool {
+	if p.any {
+		return true
+	}
+	if p.regex != nil {
+		return p.regex.MatchString(o.String())
+	}
+
+	if o.scheme != p.scheme {
+		return false
+	}
+
+	port := p.port
+	if port == "" {
+		port = defaultPort(o.scheme)
+	}
+	if port != "*" && port != o.port {
+		return false
+	}
+
+	if p.subdomains {
+		return strings.HasSuffix(o.host, "."+p.host)
+	}
+	return o.host == p.host
+}
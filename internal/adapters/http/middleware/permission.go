@@ -0,0 +1,129 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gofiber/fiber/v3"
+)
+
+// PlatformAdminRole is the user role that bypasses tenant permission checks
+const PlatformAdminRole = "admin"
+
+// PermissionResolver returns the tenant role and permissions a user holds in a tenant
+type PermissionResolver func(ctx context.Context, userID, tenantID string) (string, []string, error)
+
+// TenantResolver determines which tenant a request acts on
+type TenantResolver func(c fiber.Ctx) (string, error)
+
+// TenantFromParam resolves the tenant from a route parameter
+func TenantFromParam(name string) TenantResolver {
+	return func(c fiber.Ctx) (string, error) {
+		if tenantID := c.Params(name); tenantID != "" {
+			return tenantID, nil
+		}
+		return "", errors.New("tenant not specified")
+	}
+}
+
+// defaultTenantResolver resolves the tenant from the tenantId route parameter,
+// then the X-Tenant-ID header, then the tenant stored in the session
+func defaultTenantResolver(c fiber.Ctx) (string, error) {
+	if tenantID := c.Params("tenantId"); tenantID != "" {
+		return tenantID, nil
+	}
+
+	if tenantID := c.Get("X-Tenant-ID"); tenantID != "" {
+		return tenantID, nil
+	}
+
+	if session, ok := c.Locals("session").(*Session); ok {
+		if tenantID, ok := session.Data["tenant_id"].(string); ok && tenantID != "" {
+			return tenantID, nil
+		}
+	}
+
+	return "", errors.New("tenant not specified")
+}
+
+// UsePermissionResolver sets how tenant permissions are looked up for RequirePermission
+func (a *AuthMiddleware) UsePermissionResolver(resolver PermissionResolver) {
+	a.permissions = resolver
+}
+
+// RequirePermission checks that the user holds a permission in the tenant the request acts on.
+// The tenant is found with the given resolver, or from the route, X-Tenant-ID header or session.
+// Platform admins are allowed through. The resolved tenant and role are stored in the
+// request context as tenant_id and tenant_role.
+func (a *AuthMiddleware) RequirePermission(permission string, resolver ...TenantResolver) fiber.Handler {
+	resolveTenant := TenantResolver(defaultTenantResolver)
+	if len(resolver) > 0 && resolver[0] != nil {
+		resolveTenant = resolver[0]
+	}
+
+	return func(c fiber.Ctx) error {
+		session, ok := c.Locals("session").(*Session)
+		if !ok {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    fiber.StatusUnauthorized,
+					"message": "Authentication required",
+				},
+			})
+		}
+
+		tenantID, err := resolveTenant(c)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    fiber.StatusNotFound,
+					"message": "Tenant not found",
+				},
+			})
+		}
+		c.Locals("tenant_id", tenantID)
+
+		if role, _ := session.Data["role"].(string); role == PlatformAdminRole {
+			c.Locals("tenant_role", "owner")
+			return c.Next()
+		}
+
+		if a.permissions != nil {
+			role, permissions, err := a.permissions(c.RequestCtx(), session.UserID, tenantID)
+			if err == nil && hasPermission(permissions, permission) {
+				c.Locals("tenant_role", role)
+				return c.Next()
+			}
+		}
+
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusForbidden,
+				"message": "Insufficient permissions",
+			},
+		})
+	}
+}
+
+// IsPlatformAdmin reports whether the request is authenticated as a platform admin
+func IsPlatformAdmin(c fiber.Ctx) bool {
+	session, ok := c.Locals("session").(*Session)
+	if !ok {
+		return false
+	}
+	role, _ := session.Data["role"].(string)
+	return role == PlatformAdminRole
+}
+
+// hasPermission checks whether a permission is in a granted set
+func hasPermission(granted []string, permission string) bool {
+	for _, p := range granted {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
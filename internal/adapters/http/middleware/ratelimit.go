@@ -1,47 +1,73 @@
 package middleware
 
 import (
+	"context"
 	"fmt"
-	"sync"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/gofiber/fiber/v3"
 )
 
-// RateLimiter represents a rate limiter for a specific client
-type RateLimiter struct {
-	tokens     int
-	maxTokens  int
-	refillRate int
-	lastRefill time.Time
-	mutex      sync.Mutex
+// rateLimitAppliedKey marks a request that a rate limit rule has already been applied to
+const rateLimitAppliedKey = "rate_limit_rule"
+
+// storeErrorLogInterval limits how often an unreachable store is logged
+const storeErrorLogInterval = 10 * time.Second
+
+// TenantPlanResolver returns the plan of a tenant the user belongs to, or an empty string
+// if the tenant doesn't exist or the user isn't a member
+type TenantPlanResolver func(ctx context.Context, userID, tenantID string) string
+
+// rateLimitRoute attaches a rule to a route prefix, which may contain :param segments
+type rateLimitRoute struct {
+	segments []string
+	rule     string
 }
 
-// RateLimitMiddleware provides rate limiting functionality
+// RateLimitMiddleware provides rate limiting functionality with named rules per route group
 type RateLimitMiddleware struct {
-	limiters    map[string]*RateLimiter
-	maxRequests int
-	window      time.Duration
-	keyFunc     func(fiber.Ctx) string
-	mutex       sync.RWMutex
+	store          RateLimitStore
+	settings       atomic.Pointer[rateLimitSettings] // Swapped as a whole on reload
+	routes         []rateLimitRoute
+	tenantPlans    TenantPlanResolver
+	metrics        *MetricsMiddleware
+	lastStoreError atomic.Int64
 }
 
-// RateLimitConfig holds rate limit configuration
+// rateLimitSettings are the rules and policies that can be reloaded while requests are served
+type rateLimitSettings struct {
+	failOpen        bool
+	message         string
+	bypass          rateLimitBypass
+	rules           map[string]RateLimitRule
+	planMultipliers map[string]float64
+}
+
+// RateLimitConfig holds rate limit configuration. The top-level limit is the default rule,
+// which applies to routes without a more specific rule.
 type RateLimitConfig struct {
-	MaxRequests int                    // Maximum requests allowed
-	Window      time.Duration          // Time window for rate limiting
-	KeyFunc     func(fiber.Ctx) string // Function to extract client identifier
-	Message     string                 // Custom message for rate limit exceeded
+	Algorithm       string                   // One of the RateLimit* algorithms; defaults to the sliding window counter
+	MaxRequests     int                      // Maximum requests allowed
+	Window          time.Duration            // Time window for rate limiting
+	KeyFunc         func(fiber.Ctx) string   // Function to extract client identifier
+	Message         string                   // Custom message for rate limit exceeded
+	Rules           map[string]RateLimitRule // Named rules that route groups can use
+	PlanMultipliers map[string]float64       // Limit multipliers per tenant plan, for rules that scale with the plan
+	Store           RateLimitStore           // Where limits are tracked; in process memory when nil
+	FailOpen        bool                     // Allow requests when the store is unreachable, instead of rejecting them
+	Bypass          []string                 // Clients never limited: IP addresses, CIDR ranges, user:<id>, or keys from a rule's KeyFunc
 }
 
-// DefaultRateLimitConfig returns default rate limit configuration
-func DefaultRateLimitConfig() RateLimitConfig {
-	return RateLimitConfig{
-		MaxRequests: 100,
-		Window:      time.Minute,
-		KeyFunc:     defaultKeyFunc,
-		Message:     "Rate limit exceeded",
-	}
+// rateLimitBypass holds the clients that are never rate limited, such as health checkers and service accounts
+type rateLimitBypass struct {
+	networks []*net.IPNet
+	users    map[string]bool
+	keys     map[string]bool
 }
 
 // NewRateLimitMiddleware creates a new rate limiting middleware
@@ -51,90 +77,322 @@ func NewRateLimitMiddleware(config ...RateLimitConfig) *RateLimitMiddleware {
 		cfg = config[0]
 	}
 
-	return &RateLimitMiddleware{
-		limiters:    make(map[string]*RateLimiter),
-		maxRequests: cfg.MaxRequests,
-		window:      cfg.Window,
-		keyFunc:     cfg.KeyFunc,
-		mutex:       sync.RWMutex{},
+	store := cfg.Store
+	if store == nil {
+		store = NewMemoryRateLimitStore()
+	}
+
+	r := &RateLimitMiddleware{store: store}
+	r.settings.Store(newRateLimitSettings(cfg))
+	return r
+}
+
+// newRateLimitSettings builds the rules and policies of a configuration
+func newRateLimitSettings(cfg RateLimitConfig) *rateLimitSettings {
+	defaultRule := validRateLimitRule(RateLimitRuleDefault, RateLimitRule{
+		Algorithm:   cfg.Algorithm,
+		MaxRequests: cfg.MaxRequests,
+		Window:      cfg.Window,
+		KeyFunc:     cfg.KeyFunc,
+	}, RateLimitRule{MaxRequests: 100, Window: time.Minute})
+
+	rules := map[string]RateLimitRule{
+		RateLimitRuleDefault: defaultRule,
+		RateLimitRuleNone:    {},
+	}
+	for name, rule := range cfg.Rules {
+		if name != RateLimitRuleDefault {
+			rules[name] = validRateLimitRule(name, rule, defaultRule)
+		}
+	}
+
+	message := cfg.Message
+	if message == "" {
+		message = "Rate limit exceeded"
+	}
+
+	return &rateLimitSettings{
+		failOpen:        cfg.FailOpen,
+		message:         message,
+		bypass:          parseRateLimitBypass(cfg.Bypass),
+		rules:           rules,
+		planMultipliers: cfg.PlanMultipliers,
+	}
+}
+
+// Reload replaces the rules, plan multipliers, bypass list and fail-open policy while requests
+// are being served; the store is kept, so clients keep their usage. Every rule that routes use
+// must still exist, otherwise the current settings stay in effect.
+func (r *RateLimitMiddleware) Reload(cfg RateLimitConfig) error {
+	settings := newRateLimitSettings(cfg)
+	for _, route := range r.routes {
+		if _, exists := settings.rules[route.rule]; !exists {
+			return fmt.Errorf("rate limit rule %q is used by /%s and cannot be removed", route.rule, strings.Join(route.segments, "/"))
+		}
+	}
+
+	r.settings.Store(settings)
+	return nil
+}
+
+// parseRateLimitBypass sorts bypass entries into networks, user IDs and plain keys
+func parseRateLimitBypass(entries []string) rateLimitBypass {
+	bypass := rateLimitBypass{users: make(map[string]bool), keys: make(map[string]bool)}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			bypass.networks = append(bypass.networks, network)
+		} else if ip := net.ParseIP(entry); ip != nil {
+			bits := 8 * len(ip.To16())
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			bypass.networks = append(bypass.networks, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+		} else if userID, ok := strings.CutPrefix(entry, "user:"); ok {
+			bypass.users[userID] = true
+		} else {
+			bypass.keys[entry] = true
+		}
+	}
+	return bypass
+}
+
+// matches reports whether the request's client is exempt from rate limits
+func (b rateLimitBypass) matches(c fiber.Ctx, key string) bool {
+	if b.keys[key] {
+		return true
+	}
+
+	if userID, _ := c.Locals("user_id").(string); userID != "" && b.users[userID] {
+		return true
+	}
+
+	if len(b.networks) > 0 {
+		if ip := net.ParseIP(c.IP()); ip != nil {
+			for _, network := range b.networks {
+				if network.Contains(ip) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// validRateLimitRule fills in a rule's missing settings and replaces invalid ones with the fallback's
+func validRateLimitRule(name string, rule, fallback RateLimitRule) RateLimitRule {
+	if rule.Algorithm == "" {
+		rule.Algorithm = RateLimitSlidingWindow
+	} else if !isRateLimitAlgorithm(rule.Algorithm) {
+		logger.Warn("Unknown rate limit algorithm, using the sliding window", "rule", name, "algorithm", rule.Algorithm)
+		rule.Algorithm = RateLimitSlidingWindow
+	}
+	if rule.MaxRequests < 0 || (rule.MaxRequests > 0 && rule.Window <= 0) || (name == RateLimitRuleDefault && rule.MaxRequests == 0) {
+		logger.Warn("Invalid rate limit rule, using the fallback", "rule", name, "max_requests", rule.MaxRequests, "window", rule.Window.String(),
+			"fallback_max_requests", fallback.MaxRequests, "fallback_window", fallback.Window.String())
+		rule.MaxRequests, rule.Window = fallback.MaxRequests, fallback.Window
+	}
+	if rule.KeyFunc == nil {
+		rule.KeyFunc = defaultKeyFunc
+	}
+	return rule
+}
+
+// UseRule attaches a named rule to every route under a path prefix and returns the handler that
+// enforces it, to be added to the route group. The most specific matching prefix wins, and the
+// default rule no longer applies there.
+func (r *RateLimitMiddleware) UseRule(prefix, name string) fiber.Handler {
+	if _, exists := r.settings.Load().rules[name]; !exists {
+		panic("unknown rate limit rule: " + name)
+	}
+
+	r.routes = append(r.routes, rateLimitRoute{segments: pathSegments(prefix), rule: name})
+	sort.SliceStable(r.routes, func(i, j int) bool {
+		return len(r.routes[i].segments) > len(r.routes[j].segments)
+	})
+
+	return func(c fiber.Ctx) error {
+		// Groups can overlap; only the handler of the most specific rule applies it, once
+		if rule, _ := r.ruleFor(c.Path()); rule != name || c.Locals(rateLimitAppliedKey) != nil {
+			return c.Next()
+		}
+		return r.enforce(c, name)
 	}
 }
 
-// Handler returns the rate limiting middleware handler
+// UseTenantPlans sets how the plan of a request's tenant is looked up
+func (r *RateLimitMiddleware) UseTenantPlans(resolver TenantPlanResolver) {
+	r.tenantPlans = resolver
+}
+
+// UseMetrics counts rejected requests in the given metrics
+func (r *RateLimitMiddleware) UseMetrics(metrics *MetricsMiddleware) {
+	r.metrics = metrics
+}
+
+// Handler returns the rate limiting middleware handler, which applies the default rule
+// to routes without a rule of their own
 func (r *RateLimitMiddleware) Handler() fiber.Handler {
 	return func(c fiber.Ctx) error {
-		key := r.keyFunc(c)
-		
-		// Get or create rate limiter for this key
-		limiter := r.getRateLimiter(key)
-		
-		// Check if request is allowed
-		if !limiter.Allow() {
-			// Set rate limit headers
-			c.Set("X-RateLimit-Limit", fmt.Sprintf("%d", r.maxRequests))
-			c.Set("X-RateLimit-Remaining", "0")
-			c.Set("X-RateLimit-Reset", fmt.Sprintf("%d", time.Now().Add(r.window).Unix()))
-			
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"success": false,
-				"error": fiber.Map{
-					"code":    fiber.StatusTooManyRequests,
-					"message": "Rate limit exceeded",
-				},
-			})
+		// Route groups with a rule enforce it once routing reaches them
+		if _, exists := r.ruleFor(c.Path()); exists {
+			return c.Next()
 		}
-		
-		// Set rate limit headers for successful requests
-		c.Set("X-RateLimit-Limit", fmt.Sprintf("%d", r.maxRequests))
-		c.Set("X-RateLimit-Remaining", fmt.Sprintf("%d", limiter.tokens))
-		c.Set("X-RateLimit-Reset", fmt.Sprintf("%d", time.Now().Add(r.window).Unix()))
-		
+		return r.enforce(c, RateLimitRuleDefault)
+	}
+}
+
+// ruleFor returns the rule attached to the most specific prefix of a path
+func (r *RateLimitMiddleware) ruleFor(path string) (string, bool) {
+	segments := pathSegments(path)
+	for _, route := range r.routes {
+		if segmentsMatch(route.segments, segments) {
+			return route.rule, true
+		}
+	}
+	return "", false
+}
+
+// enforce applies a rule to the request
+func (r *RateLimitMiddleware) enforce(c fiber.Ctx, name string) error {
+	c.Locals(rateLimitAppliedKey, name)
+
+	// One snapshot per request, so a reload never mixes old and new settings
+	settings := r.settings.Load()
+	rule := settings.rules[name]
+	if rule.MaxRequests == 0 {
+		return c.Next()
+	}
+
+	clientKey := rule.KeyFunc(c)
+	if settings.bypass.matches(c, clientKey) {
+		return c.Next()
+	}
+
+	key := name + ":" + clientKey
+	limit := rule.MaxRequests
+	if rule.ScaleWithPlan {
+		if tenantID, plan := r.tenantPlan(c); plan != "" {
+			// Quotas are per tenant, so members of several tenants get each one's
+			key += ":" + tenantID
+			if multiplier, exists := settings.planMultipliers[plan]; exists {
+				limit = max(1, int(float64(limit)*multiplier))
+			}
+		}
+	}
+
+	cost := 1
+	if rule.Cost != nil {
+		cost = min(max(rule.Cost(c), 1), limit)
+	}
+
+	// Check if request is allowed
+	result, err := r.store.Allow(c.RequestCtx(), key, rule.Algorithm, limit, rule.Window, cost)
+	if err != nil {
+		return r.storeUnavailable(c, settings.failOpen, err)
+	}
+	setRateLimitHeaders(c, name, rule.Window, result)
+
+	if !result.Allowed {
+		r.metrics.rateLimitRejected(name, rateLimitKeyType(c, clientKey))
+		c.Set(fiber.HeaderRetryAfter, strconv.FormatInt(max(1, ceilSeconds(result.RetryAfter)), 10))
+		return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    fiber.StatusTooManyRequests,
+				"message": settings.message,
+			},
+		})
+	}
+
+	return c.Next()
+}
+
+// storeUnavailable lets the request through or rejects it when the store can't be reached,
+// logging at most once every storeErrorLogInterval
+func (r *RateLimitMiddleware) storeUnavailable(c fiber.Ctx, failOpen bool, err error) error {
+	now := time.Now().UnixNano()
+	if last := r.lastStoreError.Load(); now-last >= int64(storeErrorLogInterval) && r.lastStoreError.CompareAndSwap(last, now) {
+		logger.ErrorContext(c.RequestCtx(), "Rate limit store unavailable", "fail_open", failOpen, "error", err)
+	}
+
+	if failOpen {
 		return c.Next()
 	}
+
+	return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+		"success": false,
+		"error": fiber.Map{
+			"code":    fiber.StatusServiceUnavailable,
+			"message": "Service temporarily unavailable",
+		},
+	})
 }
 
-// getRateLimiter gets or creates a rate limiter for the given key
-func (r *RateLimitMiddleware) getRateLimiter(key string) *RateLimiter {
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
-	
-	limiter, exists := r.limiters[key]
-	if !exists {
-		limiter = &RateLimiter{
-			tokens:     r.maxRequests,
-			maxTokens:  r.maxRequests,
-			refillRate: r.maxRequests,
-			lastRefill: time.Now(),
+// tenantPlan returns the tenant the request acts on and its plan, if the user belongs to it
+func (r *RateLimitMiddleware) tenantPlan(c fiber.Ctx) (string, string) {
+	userID, _ := c.Locals("user_id").(string)
+	if r.tenantPlans == nil || userID == "" {
+		return "", ""
+	}
+
+	tenantID := requestTenantID(c)
+	if tenantID == "" {
+		tenantID, _ = defaultTenantResolver(c)
+	}
+	if tenantID == "" {
+		return "", ""
+	}
+
+	return tenantID, r.tenantPlans(c.RequestCtx(), userID, tenantID)
+}
+
+// setRateLimitHeaders reports the client's quota with the RateLimit-Policy and RateLimit headers
+// of the IETF draft, and the legacy X-RateLimit-* headers. Times are rounded up to whole seconds.
+func setRateLimitHeaders(c fiber.Ctx, name string, window time.Duration, result RateLimitResult) {
+	resetAfter := max(0, ceilSeconds(time.Until(result.Reset)))
+
+	c.Set("RateLimit-Policy", fmt.Sprintf("%q;q=%d;w=%d", name, result.Limit, ceilSeconds(window)))
+	c.Set("RateLimit", fmt.Sprintf("%q;r=%d;t=%d", name, result.Remaining, resetAfter))
+
+	c.Set("X-RateLimit-Limit", fmt.Sprintf("%d", result.Limit))
+	c.Set("X-RateLimit-Remaining", fmt.Sprintf("%d", result.Remaining))
+	c.Set("X-RateLimit-Reset", fmt.Sprintf("%d", time.Now().Unix()+resetAfter))
+}
+
+// ceilSeconds returns a duration in whole seconds, rounded up
+func ceilSeconds(d time.Duration) int64 {
+	seconds := int64(d / time.Second)
+	if d%time.Second > 0 {
+		seconds++
+	}
+	return seconds
+}
+
+// pathSegments splits a path into its segments
+func pathSegments(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+// segmentsMatch checks if a route prefix matches the start of a path; :param segments match any segment
+func segmentsMatch(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i, segment := range prefix {
+		if !strings.HasPrefix(segment, ":") && !strings.EqualFold(segment, path[i]) {
+			return false
 		}
-		r.limiters[key] = limiter
-	}
-	
-	return limiter
-}
-
-// Allow checks if a request is allowed under the rate limit
-func (rl *RateLimiter) Allow() bool {
-	rl.mutex.Lock()
-	defer rl.mutex.Unlock()
-	
-	now := time.Now()
-	
-	// Calculate tokens to add based on time elapsed
-	elapsed := now.Sub(rl.lastRefill)
-	tokensToAdd := int(elapsed.Minutes()) * rl.refillRate
-	
-	if tokensToAdd > 0 {
-		rl.tokens = min(rl.maxTokens, rl.tokens+tokensToAdd)
-		rl.lastRefill = now
-	}
-	
-	// Check if we have tokens available
-	if rl.tokens > 0 {
-		rl.tokens--
-		return true
 	}
-	
-	return false
+	return true
 }
 
 // defaultKeyFunc extracts client IP as the rate limiting key
@@ -142,6 +400,18 @@ func defaultKeyFunc(c fiber.Ctx) string {
 	return c.IP()
 }
 
+// rateLimitKeyType names the kind of key a request was limited by, for metrics;
+// key functions fall back to the client IP when their key is missing
+func rateLimitKeyType(c fiber.Ctx, key string) string {
+	switch {
+	case key == c.IP():
+		return "ip"
+	case strings.HasPrefix(key, "user_"):
+		return "user"
+	}
+	return "custom"
+}
+
 // KeyFuncByIP creates a key function that uses client IP
 func KeyFuncByIP() func(fiber.Ctx) string {
 	return func(c fiber.Ctx) string {
@@ -171,26 +441,19 @@ func KeyFuncByHeader(headerName string) func(fiber.Ctx) string {
 	}
 }
 
-// CleanupExpiredLimiters removes old rate limiters (should be called periodically)
-func (r *RateLimitMiddleware) CleanupExpiredLimiters() {
-	r.mutex.Lock()
-	defer r.mutex.Unlock()
-	
-	cutoff := time.Now().Add(-2 * r.window)
-	
-	for key, limiter := range r.limiters {
-		limiter.mutex.Lock()
-		if limiter.lastRefill.Before(cutoff) {
-			delete(r.limiters, key)
-		}
-		limiter.mutex.Unlock()
+// HealthCheck reports whether the rate limit store is reachable; stores that can't be
+// unreachable, such as the memory store, always pass
+func (r *RateLimitMiddleware) HealthCheck(ctx context.Context) error {
+	if store, ok := r.store.(interface{ Ping(context.Context) error }); ok {
+		return store.Ping(ctx)
 	}
+	return nil
 }
 
-// min returns the minimum of two integers
-func min(a, b int) int {
-	if a < b {
-		return a
+// CleanupExpiredLimiters removes old rate limiters (should be called periodically);
+// stores that expire their own state need no cleanup
+func (r *RateLimitMiddleware) CleanupExpiredLimiters() {
+	if store, ok := r.store.(interface{ CleanupExpired() }); ok {
+		store.CleanupExpired()
 	}
-	return b
-}
\ No newline at end of file
+}
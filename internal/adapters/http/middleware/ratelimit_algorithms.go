@@ -0,0 +1,210 @@
+package middleware
+
+import (
+	"math"
+	"time"
+)
+
+// Rate limiting algorithms
+const (
+	// RateLimitFixedWindow counts requests in consecutive windows aligned to the window length;
+	// cheap, but allows up to twice the limit across a window boundary
+	RateLimitFixedWindow = "fixed_window"
+	// RateLimitSlidingLog keeps a timestamp per request and counts those within the last window; exact but memory-heavy
+	RateLimitSlidingLog = "sliding_log"
+	// RateLimitSlidingWindow weights the previous fixed window's count by its overlap with the sliding window
+	RateLimitSlidingWindow = "sliding_window"
+	// RateLimitGCRA is the generic cell rate algorithm, a leaky bucket that spaces requests evenly
+	// and allows a burst of up to the limit
+	RateLimitGCRA = "gcra"
+)
+
+// RateLimitResult is the outcome of a rate limit check
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Time     // When the full quota is available again
+	RetryAfter time.Duration // How long to wait before the next request is allowed; zero when allowed
+}
+
+// rateLimitState holds one client's usage under an algorithm. A request consumes cost units
+// of the limit; callers keep cost between one and the limit.
+type rateLimitState interface {
+	allow(now time.Time, limit int, window time.Duration, cost int) RateLimitResult
+}
+
+// isRateLimitAlgorithm reports whether the name is a supported algorithm
+func isRateLimitAlgorithm(name string) bool {
+	switch name {
+	case RateLimitFixedWindow, RateLimitSlidingLog, RateLimitSlidingWindow, RateLimitGCRA:
+		return true
+	}
+	return false
+}
+
+// newRateLimitState creates the empty state for an algorithm
+func newRateLimitState(algorithm string) rateLimitState {
+	switch algorithm {
+	case RateLimitFixedWindow:
+		return &fixedWindowState{}
+	case RateLimitSlidingLog:
+		return &slidingLogState{}
+	case RateLimitGCRA:
+		return &gcraState{}
+	default:
+		return &slidingWindowState{}
+	}
+}
+
+// fixedWindowState counts requests in the current window
+type fixedWindowState struct {
+	start time.Time
+	count int
+}
+
+func (s *fixedWindowState) allow(now time.Time, limit int, window time.Duration, cost int) RateLimitResult {
+	if !now.Before(s.start.Add(window)) {
+		s.start = now.Truncate(window)
+		s.count = 0
+	}
+
+	reset := s.start.Add(window)
+	if s.count+cost > limit {
+		return RateLimitResult{Limit: limit, Remaining: limit - s.count, Reset: reset, RetryAfter: reset.Sub(now)}
+	}
+
+	s.count += cost
+	return RateLimitResult{Allowed: true, Limit: limit, Remaining: limit - s.count, Reset: reset}
+}
+
+// slidingLogState keeps the time of every request within the last window, oldest first
+type slidingLogState struct {
+	log []time.Time
+}
+
+func (s *slidingLogState) allow(now time.Time, limit int, window time.Duration, cost int) RateLimitResult {
+	cutoff := now.Add(-window)
+	expired := 0
+	for expired < len(s.log) && !s.log[expired].After(cutoff) {
+		expired++
+	}
+	s.log = s.log[expired:]
+
+	if excess := len(s.log) + cost - limit; excess > 0 {
+		// A request is allowed again once enough of the oldest ones leave the window
+		retryAt := s.log[excess-1].Add(window)
+		return RateLimitResult{
+			Limit:      limit,
+			Remaining:  limit - len(s.log),
+			Reset:      s.log[len(s.log)-1].Add(window),
+			RetryAfter: retryAt.Sub(now),
+		}
+	}
+
+	for i := 0; i < cost; i++ {
+		s.log = append(s.log, now)
+	}
+	return RateLimitResult{Allowed: true, Limit: limit, Remaining: limit - len(s.log), Reset: now.Add(window)}
+}
+
+// slidingWindowState counts requests in the current and previous fixed windows
+type slidingWindowState struct {
+	start    time.Time
+	current  int
+	previous int
+}
+
+func (s *slidingWindowState) allow(now time.Time, limit int, window time.Duration, cost int) RateLimitResult {
+	if !now.Before(s.start.Add(window)) {
+		start := now.Truncate(window)
+		if start.Equal(s.start.Add(window)) {
+			s.previous = s.current
+		} else {
+			s.previous = 0
+		}
+		s.start = start
+		s.current = 0
+	}
+
+	elapsed := now.Sub(s.start)
+	overlap := 1 - float64(elapsed)/float64(window)
+	estimate := float64(s.previous)*overlap + float64(s.current)
+
+	if estimate+float64(cost) > float64(limit) {
+		return RateLimitResult{
+			Limit:      limit,
+			Remaining:  max(0, int(math.Floor(float64(limit)-estimate))),
+			Reset:      s.resetAt(window),
+			RetryAfter: s.retryAfter(elapsed, limit, window, cost),
+		}
+	}
+
+	s.current += cost
+	return RateLimitResult{
+		Allowed:   true,
+		Limit:     limit,
+		Remaining: int(math.Floor(float64(limit) - estimate - float64(cost))),
+		Reset:     s.resetAt(window),
+	}
+}
+
+// resetAt returns when neither window's count weighs on the estimate any more
+func (s *slidingWindowState) resetAt(window time.Duration) time.Time {
+	if s.current > 0 {
+		return s.start.Add(2 * window)
+	}
+	return s.start.Add(window)
+}
+
+// retryAfter returns how long until the estimate drops enough to allow another request
+func (s *slidingWindowState) retryAfter(elapsed time.Duration, limit int, window time.Duration, cost int) time.Duration {
+	spare := float64(limit - cost - s.current)
+
+	// Within the current window, the previous window's weight decays linearly
+	if spare >= 0 && s.previous > 0 {
+		needed := time.Duration(math.Ceil((1 - spare/float64(s.previous)) * float64(window)))
+		if needed < window {
+			return needed - elapsed
+		}
+	}
+
+	// Otherwise the current count becomes the previous window's and decays from there
+	wait := window - elapsed
+	if s.current > limit-cost {
+		wait += time.Duration(math.Ceil((1 - float64(limit-cost)/float64(s.current)) * float64(window)))
+	}
+	return wait
+}
+
+// gcraState holds the theoretical arrival time: when the client's bucket is empty again
+type gcraState struct {
+	tat time.Time
+}
+
+func (s *gcraState) allow(now time.Time, limit int, window time.Duration, cost int) RateLimitResult {
+	// Each unit of cost fills the bucket by one emission interval; a full bucket holds the whole window
+	interval := window / time.Duration(limit)
+	tat := s.tat
+	if tat.Before(now) {
+		tat = now
+	}
+
+	next := tat.Add(interval * time.Duration(cost))
+	if allowAt := next.Add(-window); now.Before(allowAt) {
+		return RateLimitResult{
+			Limit:      limit,
+			Remaining:  int((window - tat.Sub(now)) / interval),
+			Reset:      tat,
+			RetryAfter: allowAt.Sub(now),
+		}
+	}
+
+	s.tat = next
+	return RateLimitResult{
+		Allowed:   true,
+		Limit:     limit,
+		Remaining: int((window - next.Sub(now)) / interval),
+		Reset:     next,
+	}
+}
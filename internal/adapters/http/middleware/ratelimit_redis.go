@@ -0,0 +1,190 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// rateLimitScriptPrelude reads the arguments shared by every algorithm. Times are in microseconds
+// of the server's clock, so replicas with skewed clocks agree. Lua numbers are doubles, so integers
+// are formatted explicitly before they are stored.
+const rateLimitScriptPrelude = `
+local key = KEYS[1]
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local cost = tonumber(ARGV[3])
+local time = redis.call('TIME')
+local now = tonumber(time[1]) * 1000000 + tonumber(time[2])
+local function int(x) return string.format('%.0f', x) end
+local function ttl(us) return math.max(1, math.ceil(us / 1000)) end
+`
+
+// Each script returns {allowed, remaining, reset after, retry after}, with durations in microseconds
+var rateLimitScripts = map[string]*redis.Script{
+	RateLimitFixedWindow: redis.NewScript(rateLimitScriptPrelude + `
+local start = now - (now % window)
+local state = redis.call('HMGET', key, 'start', 'count')
+local count = 0
+if tonumber(state[1]) == start then
+  count = tonumber(state[2]) or 0
+end
+
+local reset = start + window - now
+if count + cost > limit then
+  return {0, limit - count, reset, reset}
+end
+
+count = count + cost
+redis.call('HMSET', key, 'start', int(start), 'count', count)
+redis.call('PEXPIRE', key, ttl(reset))
+return {1, limit - count, reset, 0}
+`),
+
+	RateLimitSlidingLog: redis.NewScript(rateLimitScriptPrelude + `
+redis.call('ZREMRANGEBYSCORE', key, '-inf', int(now - window))
+local count = redis.call('ZCARD', key)
+
+local excess = count + cost - limit
+if excess > 0 then
+  -- A request is allowed again once enough of the oldest ones leave the window
+  local oldest = redis.call('ZRANGE', key, excess - 1, excess - 1, 'WITHSCORES')
+  local newest = redis.call('ZRANGE', key, -1, -1, 'WITHSCORES')
+  return {0, limit - count, tonumber(newest[2]) + window - now, tonumber(oldest[2]) + window - now}
+end
+
+for i = 1, cost do
+  redis.call('ZADD', key, int(now), ARGV[4] .. ':' .. i)
+end
+redis.call('PEXPIRE', key, ttl(window))
+return {1, limit - count - cost, window, 0}
+`),
+
+	RateLimitSlidingWindow: redis.NewScript(rateLimitScriptPrelude + `
+local start = now - (now % window)
+local state = redis.call('HMGET', key, 'start', 'current', 'previous')
+local stored = tonumber(state[1])
+local current = tonumber(state[2]) or 0
+local previous = tonumber(state[3]) or 0
+if stored ~= start then
+  if stored == start - window then
+    previous = current
+  else
+    previous = 0
+  end
+  current = 0
+end
+
+local elapsed = now - start
+local estimate = previous * (1 - elapsed / window) + current
+
+if estimate + cost > limit then
+  local reset = start + window - now
+  if current > 0 then
+    reset = reset + window
+  end
+
+  -- Within the current window, the previous window's weight decays linearly
+  local spare = limit - cost - current
+  local retry = -1
+  if spare >= 0 and previous > 0 then
+    local needed = math.ceil((1 - spare / previous) * window)
+    if needed < window then
+      retry = needed - elapsed
+    end
+  end
+  -- Otherwise the current count becomes the previous window's and decays from there
+  if retry < 0 then
+    retry = window - elapsed
+    if current > limit - cost then
+      retry = retry + math.ceil((1 - (limit - cost) / current) * window)
+    end
+  end
+
+  return {0, math.max(0, math.floor(limit - estimate)), reset, retry}
+end
+
+current = current + cost
+redis.call('HMSET', key, 'start', int(start), 'current', current, 'previous', previous)
+redis.call('PEXPIRE', key, ttl(start + 2 * window - now))
+return {1, math.floor(limit - estimate - cost), start + 2 * window - now, 0}
+`),
+
+	RateLimitGCRA: redis.NewScript(rateLimitScriptPrelude + `
+-- Each unit of cost fills the bucket by one emission interval; a full bucket holds the whole window
+local interval = window / limit
+local tat = tonumber(redis.call('GET', key) or '0')
+if tat < now then
+  tat = now
+end
+
+local newTat = tat + interval * cost
+local allowAt = newTat - window
+if now < allowAt then
+  return {0, math.floor((window - (tat - now)) / interval), tat - now, allowAt - now}
+end
+
+redis.call('SET', key, int(newTat), 'PX', ttl(newTat - now))
+return {1, math.floor((window - (newTat - now)) / interval), newTat - now, 0}
+`),
+}
+
+// RedisRateLimitStore keeps rate limit state on a Redis-protocol server, updated atomically by
+// Lua scripts. Scripts read the server clock, which needs Redis 5 or later or a compatible server.
+type RedisRateLimitStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisRateLimitStore creates a rate limit store; keys are prefixed with the given prefix
+func NewRedisRateLimitStore(client *redis.Client, prefix string) *RedisRateLimitStore {
+	return &RedisRateLimitStore{client: client, prefix: prefix}
+}
+
+// Ping checks that the server is reachable
+func (s *RedisRateLimitStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+// Allow checks and records a request with the algorithm's script
+func (s *RedisRateLimitStore) Allow(ctx context.Context, key, algorithm string, limit int, window time.Duration, cost int) (RateLimitResult, error) {
+	script, exists := rateLimitScripts[algorithm]
+	if !exists {
+		return RateLimitResult{}, fmt.Errorf("unknown rate limit algorithm %q", algorithm)
+	}
+
+	// Sliding log entries need unique members
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return RateLimitResult{}, err
+	}
+
+	reply, err := script.Run(ctx, s.client, []string{s.prefix + key + ":" + algorithm},
+		limit, window.Microseconds(), cost, hex.EncodeToString(nonce)).Result()
+	if err != nil {
+		return RateLimitResult{}, err
+	}
+
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 4 {
+		return RateLimitResult{}, fmt.Errorf("unexpected rate limit script reply %v", reply)
+	}
+	numbers := make([]int64, len(values))
+	for i, value := range values {
+		if numbers[i], ok = value.(int64); !ok {
+			return RateLimitResult{}, fmt.Errorf("unexpected rate limit script reply %v", reply)
+		}
+	}
+
+	return RateLimitResult{
+		Allowed:    numbers[0] == 1,
+		Limit:      limit,
+		Remaining:  int(numbers[1]),
+		Reset:      time.Now().Add(time.Duration(numbers[2]) * time.Microsecond),
+		RetryAfter: time.Duration(numbers[3]) * time.Microsecond,
+	}, nil
+}
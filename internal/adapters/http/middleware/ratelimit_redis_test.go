@@ -0,0 +1,156 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/cloudparallax/parallax/internal/config"
+	"github.com/gofiber/fiber/v3"
+)
+
+// newTestRedisStore starts an in-process Redis server and a store configured like in production
+func newTestRedisStore(t *testing.T) (*miniredis.Miniredis, RateLimitStore) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	store := rateLimitStore(config.RateLimit{
+		Store:        "redis",
+		RedisURL:     "redis://" + server.Addr() + "/0",
+		RedisPrefix:  "test:",
+		RedisTimeout: time.Second,
+	})
+	if store == nil {
+		t.Fatal("redis store was not created")
+	}
+	return server, store
+}
+
+func TestRedisRateLimitStoreAlgorithms(t *testing.T) {
+	for _, algorithm := range []string{RateLimitFixedWindow, RateLimitSlidingLog, RateLimitSlidingWindow, RateLimitGCRA} {
+		t.Run(algorithm, func(t *testing.T) {
+			_, store := newTestRedisStore(t)
+			ctx := context.Background()
+
+			for i := 0; i < 3; i++ {
+				result, err := store.Allow(ctx, "client", algorithm, 3, time.Minute, 1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !result.Allowed {
+					t.Fatalf("request %d was limited", i+1)
+				}
+				if result.Remaining != 2-i {
+					t.Fatalf("request %d: remaining = %d, want %d", i+1, result.Remaining, 2-i)
+				}
+			}
+
+			result, err := store.Allow(ctx, "client", algorithm, 3, time.Minute, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Allowed {
+				t.Fatal("request over the limit was allowed")
+			}
+			// The sliding window counter's count decays through the next window, so a retry
+			// may be up to two windows away
+			if result.RetryAfter <= 0 || result.RetryAfter > 2*time.Minute {
+				t.Fatalf("retry after = %v, want within two windows", result.RetryAfter)
+			}
+
+			// Other clients have their own limit
+			result, err = store.Allow(ctx, "other", algorithm, 3, time.Minute, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !result.Allowed {
+				t.Fatal("another client was limited")
+			}
+		})
+	}
+}
+
+func TestRedisRateLimitStoreCost(t *testing.T) {
+	_, store := newTestRedisStore(t)
+	ctx := context.Background()
+
+	result, err := store.Allow(ctx, "client", RateLimitSlidingLog, 10, time.Minute, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !result.Allowed || result.Remaining != 2 {
+		t.Fatalf("result = %+v, want allowed with 2 remaining", result)
+	}
+
+	result, err = store.Allow(ctx, "client", RateLimitSlidingLog, 10, time.Minute, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Allowed {
+		t.Fatal("request costing more than the remaining limit was allowed")
+	}
+}
+
+func TestRedisRateLimitStoreReconnects(t *testing.T) {
+	server, store := newTestRedisStore(t)
+	ctx := context.Background()
+
+	if _, err := store.Allow(ctx, "client", RateLimitFixedWindow, 3, time.Minute, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// Restarting the server closes the pooled connection; the next request must not fail
+	server.Restart()
+	if _, err := store.Allow(ctx, "client", RateLimitFixedWindow, 3, time.Minute, 1); err != nil {
+		t.Fatalf("request after the server closed the pooled connection: %v", err)
+	}
+}
+
+func TestRedisRateLimitStoreUnavailable(t *testing.T) {
+	for _, failOpen := range []bool{true, false} {
+		server, store := newTestRedisStore(t)
+		limiter := NewRateLimitMiddleware(RateLimitConfig{
+			MaxRequests: 1,
+			Window:      time.Minute,
+			Store:       store,
+			FailOpen:    failOpen,
+		})
+
+		app := fiber.New()
+		app.Use(limiter.Handler())
+		app.Get("/", func(c fiber.Ctx) error {
+			return c.SendString("ok")
+		})
+
+		status := func() int {
+			t.Helper()
+			response, err := app.Test(httptest.NewRequest(http.MethodGet, "/", nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return response.StatusCode
+		}
+
+		if got := status(); got != fiber.StatusOK {
+			t.Fatalf("fail open %v: first request status = %d, want 200", failOpen, got)
+		}
+		if got := status(); got != fiber.StatusTooManyRequests {
+			t.Fatalf("fail open %v: second request status = %d, want 429", failOpen, got)
+		}
+
+		server.Close()
+		want := fiber.StatusServiceUnavailable
+		if failOpen {
+			want = fiber.StatusOK
+		}
+		if got := status(); got != want {
+			t.Fatalf("fail open %v: status with the store down = %d, want %d", failOpen, got, want)
+		}
+		if err := limiter.HealthCheck(context.Background()); err == nil {
+			t.Fatalf("fail open %v: health check passed with the store down", failOpen)
+		}
+	}
+}
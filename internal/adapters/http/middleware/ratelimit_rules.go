@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudparallax/parallax/internal/config"
+	"github.com/gofiber/fiber/v3"
+	"github.com/redis/go-redis/v9"
+)
+
+// Named rate limit rules
+const (
+	// RateLimitRuleDefault applies to routes without a more specific rule
+	RateLimitRuleDefault = "default"
+	// RateLimitRuleAuth applies to sign-in and account endpoints, per client IP
+	RateLimitRuleAuth = "auth"
+	// RateLimitRuleAPI applies to the tenant API, per user and scaled by the tenant's plan
+	RateLimitRuleAPI = "api"
+	// RateLimitRuleBulk applies to listings, where larger pages cost more
+	RateLimitRuleBulk = "bulk"
+	// RateLimitRuleNone doesn't limit requests, for health checks
+	RateLimitRuleNone = "none"
+)
+
+// RateLimitRule is a rate limit that route groups can use. A rule without MaxRequests doesn't limit.
+type RateLimitRule struct {
+	Algorithm     string                 // One of the RateLimit* algorithms; defaults to the sliding window counter
+	MaxRequests   int                    // Maximum requests allowed per window
+	Window        time.Duration          // Time window for rate limiting
+	KeyFunc       func(fiber.Ctx) string // Function to extract client identifier; the client IP when nil
+	Cost          func(fiber.Ctx) int    // How much of the limit a request uses; one when nil
+	ScaleWithPlan bool                   // Multiply MaxRequests by the plan multiplier of the user's tenant
+}
+
+// DefaultRateLimitConfig returns the default rate limit configuration
+func DefaultRateLimitConfig() RateLimitConfig {
+	defaults := config.Default().RateLimit
+	cfg := rateLimitConfig(defaults)
+	cfg.Store = rateLimitStore(defaults)
+	return cfg
+}
+
+// rateLimitConfig maps the rate limit settings to the middleware configuration, without the
+// store, which is only created at startup
+func rateLimitConfig(cfg config.RateLimit) RateLimitConfig {
+	defaultRule := rateLimitRule(cfg.Default, RateLimitRule{
+		Algorithm: RateLimitSlidingWindow,
+		KeyFunc:   defaultKeyFunc,
+	})
+
+	multipliers := make(map[string]float64, len(cfg.PlanMultipliers))
+	for plan, multiplier := range cfg.PlanMultipliers {
+		multipliers[strings.ToLower(strings.TrimSpace(plan))] = multiplier
+	}
+
+	return RateLimitConfig{
+		Algorithm:   defaultRule.Algorithm,
+		MaxRequests: defaultRule.MaxRequests,
+		Window:      defaultRule.Window,
+		KeyFunc:     defaultRule.KeyFunc,
+		Message:     "Rate limit exceeded",
+		Rules: map[string]RateLimitRule{
+			RateLimitRuleAuth: rateLimitRule(cfg.Auth, RateLimitRule{
+				Algorithm: defaultRule.Algorithm,
+				KeyFunc:   KeyFuncByIP(),
+			}),
+			RateLimitRuleAPI: rateLimitRule(cfg.API, RateLimitRule{
+				Algorithm:     defaultRule.Algorithm,
+				KeyFunc:       KeyFuncByUserID(),
+				ScaleWithPlan: true,
+			}),
+			RateLimitRuleBulk: rateLimitRule(cfg.Bulk, RateLimitRule{
+				Algorithm:     defaultRule.Algorithm,
+				KeyFunc:       KeyFuncByUserID(),
+				Cost:          CostPerItems("limit", cfg.BulkItemsPerRequest),
+				ScaleWithPlan: true,
+			}),
+		},
+		PlanMultipliers: multipliers,
+		FailOpen:        cfg.FailOpen,
+		Bypass:          cfg.Bypass,
+	}
+}
+
+// rateLimitStore creates the configured store: memory (nil), or redis at the configured URL
+func rateLimitStore(cfg config.RateLimit) RateLimitStore {
+	switch cfg.Store {
+	case "memory":
+		return nil
+	case "redis":
+		client, err := redisClient(cfg)
+		if err != nil {
+			logger.Warn("Invalid rate limit Redis URL, keeping rate limits in memory", "error", err)
+			return nil
+		}
+		return NewRedisRateLimitStore(client, cfg.RedisPrefix)
+	default:
+		logger.Warn("Unknown rate limit store, keeping rate limits in memory", "store", cfg.Store)
+		return nil
+	}
+}
+
+// redisClient connects to the rate limit Redis server, which refresh tokens may share
+func redisClient(cfg config.RateLimit) (*redis.Client, error) {
+	opts, err := redis.ParseURL(cfg.RedisURL)
+	if err != nil {
+		return nil, err
+	}
+	opts.DialTimeout = cfg.RedisTimeout
+	opts.ReadTimeout = cfg.RedisTimeout
+	opts.WriteTimeout = cfg.RedisTimeout
+	opts.ContextTimeoutEnabled = true
+	// A command on a pooled connection the server has closed is retried once on a new one
+	opts.MaxRetries = 1
+	return redis.NewClient(opts), nil
+}
+
+// rateLimitRule applies a rule's settings to a rule; an empty algorithm or key keeps the rule's own
+func rateLimitRule(cfg config.RateLimitRule, rule RateLimitRule) RateLimitRule {
+	if cfg.Algorithm != "" {
+		rule.Algorithm = cfg.Algorithm
+	}
+	rule.MaxRequests = cfg.MaxRequests
+	rule.Window = cfg.Window
+
+	if cfg.Key != "" {
+		keyFunc, err := ParseKeyFunc(cfg.Key)
+		if err != nil {
+			logger.Warn("Invalid rate limit key", "key", cfg.Key, "error", err)
+		} else {
+			rule.KeyFunc = keyFunc
+		}
+	}
+
+	return rule
+}
+
+// ParseKeyFunc parses a rate limit key: "ip", "user" or "header:<name>"
+func ParseKeyFunc(spec string) (func(fiber.Ctx) string, error) {
+	kind, name, _ := strings.Cut(strings.TrimSpace(spec), ":")
+	switch strings.ToLower(kind) {
+	case "ip":
+		return KeyFuncByIP(), nil
+	case "user":
+		return KeyFuncByUserID(), nil
+	case "header":
+		if name = strings.TrimSpace(name); name == "" {
+			return nil, fmt.Errorf("rate limit key %q needs a header name", spec)
+		}
+		return KeyFuncByHeader(name), nil
+	}
+	return nil, fmt.Errorf("unknown rate limit key %q, expected ip, user or header:<name>", spec)
+}
+
+// CostPerItems creates a cost function for listings: a request costs one per itemsPerRequest
+// items asked for in the query parameter, and at least one
+func CostPerItems(param string, itemsPerRequest int) func(fiber.Ctx) int {
+	return func(c fiber.Ctx) int {
+		items, err := strconv.Atoi(c.Query(param))
+		if err != nil || items <= itemsPerRequest || itemsPerRequest <= 0 {
+			return 1
+		}
+		return (items + itemsPerRequest - 1) / itemsPerRequest
+	}
+}
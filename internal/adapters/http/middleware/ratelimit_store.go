@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RateLimitStore keeps rate limit state; replicas sharing a store share their limits
+type RateLimitStore interface {
+	// Allow checks a request of the given cost against the limit for a key, and records it if allowed
+	Allow(ctx context.Context, key, algorithm string, limit int, window time.Duration, cost int) (RateLimitResult, error)
+}
+
+// RateLimiter represents a rate limiter for a specific client
+type RateLimiter struct {
+	state   rateLimitState
+	resetAt time.Time
+	mutex   sync.Mutex
+}
+
+// Allow checks if a request of the given cost is allowed under the limit and records it if so
+func (rl *RateLimiter) Allow(limit int, window time.Duration, cost int) RateLimitResult {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	result := rl.state.allow(time.Now(), limit, window, cost)
+	rl.resetAt = result.Reset
+
+	return result
+}
+
+// MemoryRateLimitStore keeps rate limit state in process memory, for a single replica
+type MemoryRateLimitStore struct {
+	limiters map[string]*RateLimiter
+	mutex    sync.RWMutex
+}
+
+// NewMemoryRateLimitStore creates a new in-memory rate limit store
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		limiters: make(map[string]*RateLimiter),
+	}
+}
+
+// Allow checks and records a request against the key's limiter
+func (s *MemoryRateLimitStore) Allow(ctx context.Context, key, algorithm string, limit int, window time.Duration, cost int) (RateLimitResult, error) {
+	return s.getRateLimiter(key+":"+algorithm, algorithm).Allow(limit, window, cost), nil
+}
+
+// getRateLimiter gets or creates a rate limiter for the given key
+func (s *MemoryRateLimitStore) getRateLimiter(key, algorithm string) *RateLimiter {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	limiter, exists := s.limiters[key]
+	if !exists {
+		limiter = &RateLimiter{state: newRateLimitState(algorithm)}
+		s.limiters[key] = limiter
+	}
+
+	return limiter
+}
+
+// CleanupExpired removes limiters whose quota has fully reset, as they hold nothing worth keeping
+func (s *MemoryRateLimitStore) CleanupExpired() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	for key, limiter := range s.limiters {
+		limiter.mutex.Lock()
+		if !limiter.resetAt.After(now) {
+			delete(s.limiters, key)
+		}
+		limiter.mutex.Unlock()
+	}
+}
@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/cloudparallax/parallax/internal/config"
+	"github.com/cloudparallax/parallax/pkg/logging"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+var logger = logging.Logger("middleware")
+
+// maxRequestIDLength bounds request IDs taken from clients
+const maxRequestIDLength = 128
+
+// RequestLogConfig holds configuration for request IDs and request logs
+type RequestLogConfig struct {
+	Header    string   // Header carrying the request ID in requests and responses
+	SkipPaths []string // Paths whose successful requests aren't logged, such as health checks
+}
+
+// DefaultRequestLogConfig returns default request log configuration
+func DefaultRequestLogConfig() RequestLogConfig {
+	return requestLogConfig(config.Default().Logging)
+}
+
+// requestLogConfig maps the logging settings to the request log configuration
+func requestLogConfig(cfg config.Logging) RequestLogConfig {
+	return RequestLogConfig{
+		Header:    cfg.RequestIDHeader,
+		SkipPaths: cfg.SkipPaths,
+	}
+}
+
+// RequestLogMiddleware assigns each request an ID and logs it once it completes
+type RequestLogMiddleware struct {
+	header    string
+	skipPaths map[string]bool
+}
+
+// NewRequestLogMiddleware creates a new request log middleware
+func NewRequestLogMiddleware(config ...RequestLogConfig) *RequestLogMiddleware {
+	cfg := DefaultRequestLogConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Header == "" {
+		cfg.Header = fiber.HeaderXRequestID
+	}
+
+	skipPaths := make(map[string]bool, len(cfg.SkipPaths))
+	for _, path := range cfg.SkipPaths {
+		skipPaths[path] = true
+	}
+
+	return &RequestLogMiddleware{header: cfg.Header, skipPaths: skipPaths}
+}
+
+// Handler returns the request log middleware handler. The request ID is stored in Locals as
+// "request_id", so loggers given the request context include it.
+func (m *RequestLogMiddleware) Handler() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		start := time.Now()
+
+		// Keep the caller's ID so a request can be followed across services
+		requestID := c.Get(m.header)
+		if !validRequestID(requestID) {
+			requestID = uuid.NewString()
+		}
+		c.Set(m.header, requestID)
+		c.Locals("request_id", requestID)
+
+		if err := c.Next(); err != nil {
+			// Write the error response now, so the log has its status
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := c.Response().StatusCode()
+		if status < fiber.StatusBadRequest && m.skipPaths[c.Path()] {
+			return nil
+		}
+
+		level := slog.LevelInfo
+		if status >= fiber.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		logger.LogAttrs(c.RequestCtx(), level, "Request completed",
+			slog.String("method", c.Method()),
+			slog.String("path", c.Path()),
+			slog.String("route", c.Route().Path),
+			slog.Int("status", status),
+			slog.Float64("duration_ms", float64(time.Since(start).Microseconds())/1000),
+			slog.Int("bytes", len(c.Response().Body())),
+			slog.String("ip", c.IP()),
+			slog.String("user_agent", c.Get(fiber.HeaderUserAgent)),
+		)
+		return nil
+	}
+}
+
+// validRequestID reports whether a client's request ID is safe to reuse in logs and headers
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	return strings.IndexFunc(id, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.:/+=", r))
+	}) < 0
+}
@@ -0,0 +1,586 @@
+package middleware
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudparallax/parallax/internal/config"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Supported token signing algorithms
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+var (
+	// ErrInvalidToken is returned when an access token cannot be verified
+	ErrInvalidToken = errors.New("invalid or expired token")
+	// ErrInvalidRefreshToken is returned when a refresh token is unknown or expired
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	// ErrRefreshTokenReused is returned when a rotated refresh token is presented again
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+	// ErrScopeNotAllowed is returned when a requested scope is not granted to the subject
+	ErrScopeNotAllowed = errors.New("requested scope is not allowed")
+)
+
+// TokenConfig holds configuration for signed access and refresh tokens
+type TokenConfig struct {
+	Algorithm  string            // "HS256" or "EdDSA"
+	Secret     []byte            // HMAC secret used for HS256 (generated when empty)
+	PrivateKey []byte            // Ed25519 seed used for EdDSA (generated when empty)
+	Keys       map[string][]byte // Secrets or seeds by key ID; replace Secret and PrivateKey when set
+	ActiveKey  string            // Key in Keys that signs new tokens
+	Issuer     string            // Value of the "iss" claim
+	AccessTTL  time.Duration     // Lifetime of access tokens
+	RefreshTTL time.Duration     // Lifetime of refresh tokens
+	Store      RefreshTokenStore // Where refresh tokens are kept (in memory when nil)
+}
+
+// DefaultTokenConfig returns default token configuration
+func DefaultTokenConfig() TokenConfig {
+	return tokenConfig(config.Default().JWT)
+}
+
+// tokenConfig maps the JWT settings to the token configuration
+func tokenConfig(cfg config.JWT) TokenConfig {
+	tokens := TokenConfig{
+		Algorithm:  cfg.Algorithm,
+		Secret:     []byte(cfg.Secret),
+		Issuer:     cfg.Issuer,
+		AccessTTL:  cfg.AccessTTL,
+		RefreshTTL: cfg.RefreshTTL,
+	}
+
+	if cfg.Ed25519Seed != "" {
+		if decoded, err := base64.StdEncoding.DecodeString(cfg.Ed25519Seed); err == nil {
+			tokens.PrivateKey = decoded
+		}
+	}
+
+	if len(cfg.Keys) > 0 {
+		tokens.Keys = make(map[string][]byte, len(cfg.Keys))
+		tokens.ActiveKey = cfg.ActiveKeyID
+		for id, material := range cfg.Keys {
+			if cfg.Algorithm != AlgorithmEdDSA {
+				tokens.Keys[id] = []byte(material)
+			} else if decoded, err := base64.StdEncoding.DecodeString(material); err == nil {
+				tokens.Keys[id] = decoded
+			} else {
+				tokens.Keys[id] = nil
+			}
+		}
+	}
+
+	return tokens
+}
+
+// refreshTokenStore creates the configured refresh token store: memory, or redis on the rate
+// limit Redis server
+func refreshTokenStore(cfg config.JWT, redisCfg config.RateLimit) RefreshTokenStore {
+	switch strings.ToLower(cfg.RefreshStore) {
+	case "memory":
+		return NewMemoryRefreshTokenStore()
+	case "redis":
+		client, err := redisClient(redisCfg)
+		if err != nil {
+			logger.Warn("Invalid Redis URL, keeping refresh tokens in memory", "error", err)
+			return NewMemoryRefreshTokenStore()
+		}
+		return NewRedisRefreshTokenStore(client, cfg.RefreshRedisPrefix)
+	default:
+		logger.Warn("Unknown refresh token store, keeping refresh tokens in memory", "store", cfg.RefreshStore)
+		return NewMemoryRefreshTokenStore()
+	}
+}
+
+// TokenClaims represents the claims carried by an access token
+type TokenClaims struct {
+	TenantID string   `json:"tenant,omitempty"`
+	Role     string   `json:"role,omitempty"`
+	Scopes   []string `json:"scopes,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// TokenPair is the result of issuing or refreshing tokens
+type TokenPair struct {
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type"`
+	ExpiresIn    int64     `json:"expires_in"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	RefreshToken string    `json:"refresh_token"`
+}
+
+// JSONWebKey represents a public key in a JWKS document
+type JSONWebKey struct {
+	KeyType   string `json:"kty"`
+	Curve     string `json:"crv"`
+	X         string `json:"x"`
+	KeyID     string `json:"kid"`
+	Algorithm string `json:"alg"`
+	Use       string `json:"use"`
+}
+
+// JSONWebKeySet represents a JWKS document
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// signingKey is a key used to sign and verify access tokens
+type signingKey struct {
+	id         string
+	algorithm  string
+	secret     []byte
+	privateKey ed25519.PrivateKey
+	publicKey  ed25519.PublicKey
+	retiredAt  time.Time
+}
+
+// TokenSubject is what a subject's tokens are issued with
+type TokenSubject struct {
+	TenantID string
+	Role     string
+	Scopes   []string // Scopes the subject may request
+}
+
+// TokenSubjectResolver returns the current tenant, role and grantable scopes of a token
+// subject, or an error when the subject may no longer hold tokens
+type TokenSubjectResolver func(ctx context.Context, subject string) (TokenSubject, error)
+
+// TokenManager issues and verifies signed access tokens and rotating refresh tokens
+type TokenManager struct {
+	algorithm   string
+	issuer      string
+	accessTTL   time.Duration
+	refreshTTL  time.Duration
+	keys        map[string]*signingKey
+	activeKeyID string
+	store       RefreshTokenStore
+	subjects    TokenSubjectResolver
+	mutex       sync.RWMutex
+}
+
+// NewTokenManager creates a new token manager
+func NewTokenManager(config ...TokenConfig) *TokenManager {
+	cfg := DefaultTokenConfig()
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+
+	if cfg.Algorithm != AlgorithmEdDSA {
+		cfg.Algorithm = AlgorithmHS256
+	}
+
+	tm := &TokenManager{
+		algorithm:  cfg.Algorithm,
+		issuer:     cfg.Issuer,
+		accessTTL:  cfg.AccessTTL,
+		refreshTTL: cfg.RefreshTTL,
+		keys:       make(map[string]*signingKey),
+		store:      cfg.Store,
+	}
+	if tm.store == nil {
+		tm.store = NewMemoryRefreshTokenStore()
+	}
+
+	if len(cfg.Keys) > 0 {
+		keys, err := tm.configuredKeys(cfg)
+		if err == nil {
+			tm.keys = keys
+			tm.activeKeyID = cfg.ActiveKey
+			return tm
+		}
+		logger.Warn("Invalid signing keys, generating an ephemeral key", "algorithm", cfg.Algorithm, "error", err)
+	}
+
+	var material []byte
+	if cfg.Algorithm == AlgorithmHS256 {
+		material = cfg.Secret
+	} else {
+		material = cfg.PrivateKey
+	}
+
+	if len(material) == 0 {
+		logger.Warn("No signing key configured, generating an ephemeral key", "algorithm", cfg.Algorithm)
+	}
+
+	key, err := tm.newSigningKey(material)
+	if err != nil {
+		logger.Warn("Invalid signing key, generating an ephemeral key", "algorithm", cfg.Algorithm, "error", err)
+		key, _ = tm.newSigningKey(nil)
+	}
+	tm.keys[key.id] = key
+	tm.activeKeyID = key.id
+
+	return tm
+}
+
+// configuredKeys builds the signing keys of a configuration under their configured IDs
+func (tm *TokenManager) configuredKeys(cfg TokenConfig) (map[string]*signingKey, error) {
+	if _, exists := cfg.Keys[cfg.ActiveKey]; !exists {
+		return nil, fmt.Errorf("active key %q is not configured", cfg.ActiveKey)
+	}
+
+	keys := make(map[string]*signingKey, len(cfg.Keys))
+	for id, material := range cfg.Keys {
+		if len(material) == 0 {
+			return nil, fmt.Errorf("key %q is empty or invalid", id)
+		}
+		key, err := tm.newSigningKey(material)
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", id, err)
+		}
+		key.id = id
+		keys[id] = key
+	}
+
+	return keys, nil
+}
+
+// newSigningKey builds a signing key from the given material, generating it when empty
+func (tm *TokenManager) newSigningKey(material []byte) (*signingKey, error) {
+	key := &signingKey{algorithm: tm.algorithm}
+
+	switch tm.algorithm {
+	case AlgorithmEdDSA:
+		if len(material) == 0 {
+			material = make([]byte, ed25519.SeedSize)
+			if _, err := rand.Read(material); err != nil {
+				return nil, err
+			}
+		}
+		if len(material) != ed25519.SeedSize {
+			return nil, fmt.Errorf("ed25519 seed must be %d bytes", ed25519.SeedSize)
+		}
+		key.privateKey = ed25519.NewKeyFromSeed(material)
+		key.publicKey = key.privateKey.Public().(ed25519.PublicKey)
+		key.id = keyID(key.publicKey)
+	default:
+		if len(material) == 0 {
+			material = make([]byte, 32)
+			if _, err := rand.Read(material); err != nil {
+				return nil, err
+			}
+		}
+		if len(material) < 32 {
+			return nil, errors.New("HS256 secret must be at least 32 bytes")
+		}
+		key.secret = material
+		key.id = keyID(material)
+	}
+
+	return key, nil
+}
+
+// keyID derives a stable key identifier from key material
+func keyID(material []byte) string {
+	sum := sha256.Sum256(material)
+	return hex.EncodeToString(sum[:8])
+}
+
+// AccessTTL returns the lifetime of issued access tokens
+func (tm *TokenManager) AccessTTL() time.Duration {
+	return tm.accessTTL
+}
+
+// UseSubjects sets how the tenant, role and grantable scopes of token subjects are looked up
+func (tm *TokenManager) UseSubjects(resolver TokenSubjectResolver) {
+	tm.subjects = resolver
+}
+
+// resolveSubject looks up the subject's current tenant, role and grantable scopes
+func (tm *TokenManager) resolveSubject(ctx context.Context, subject string) (TokenSubject, error) {
+	if tm.subjects == nil {
+		return TokenSubject{}, errors.New("token subjects cannot be resolved")
+	}
+	return tm.subjects(ctx, subject)
+}
+
+// IssueTokens issues a new access token and starts a new refresh token family, with the
+// subject's current tenant and role. Every requested scope must be grantable to the subject.
+func (tm *TokenManager) IssueTokens(ctx context.Context, subject string, scopes []string) (*TokenPair, error) {
+	current, err := tm.resolveSubject(ctx, subject)
+	if err != nil {
+		return nil, err
+	}
+	for _, scope := range scopes {
+		if !hasPermission(current.Scopes, scope) {
+			return nil, fmt.Errorf("%w: %s", ErrScopeNotAllowed, scope)
+		}
+	}
+
+	familyID, err := generateTokenID()
+	if err != nil {
+		return nil, err
+	}
+
+	return tm.issue(ctx, familyID, subject, current.TenantID, current.Role, scopes)
+}
+
+// issue signs an access token and stores a refresh token in the given family
+func (tm *TokenManager) issue(ctx context.Context, familyID, subject, tenantID, role string, scopes []string) (*TokenPair, error) {
+	tm.mutex.RLock()
+	key := tm.keys[tm.activeKeyID]
+	tm.mutex.RUnlock()
+
+	now := time.Now()
+
+	tokenID, err := generateTokenID()
+	if err != nil {
+		return nil, err
+	}
+
+	claims := TokenClaims{
+		TenantID: tenantID,
+		Role:     role,
+		Scopes:   scopes,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tm.issuer,
+			Subject:   subject,
+			ID:        tokenID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(tm.accessTTL)),
+		},
+	}
+
+	token := jwt.NewWithClaims(signingMethod(key.algorithm), claims)
+	token.Header["kid"] = key.id
+
+	var signed string
+	if key.algorithm == AlgorithmEdDSA {
+		signed, err = token.SignedString(key.privateKey)
+	} else {
+		signed, err = token.SignedString(key.secret)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, err := generateSessionID()
+	if err != nil {
+		return nil, err
+	}
+
+	err = tm.store.Save(ctx, hashToken(refresh), RefreshRecord{
+		FamilyID:  familyID,
+		Subject:   subject,
+		TenantID:  tenantID,
+		Role:      role,
+		Scopes:    scopes,
+		ExpiresAt: now.Add(tm.refreshTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  signed,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(tm.accessTTL.Seconds()),
+		ExpiresAt:    claims.ExpiresAt.Time,
+		RefreshToken: refresh,
+	}, nil
+}
+
+// ParseAccessToken verifies an access token and returns its claims
+func (tm *TokenManager) ParseAccessToken(tokenString string) (*TokenClaims, error) {
+	claims := &TokenClaims{}
+
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+
+		tm.mutex.RLock()
+		key, exists := tm.keys[kid]
+		tm.mutex.RUnlock()
+
+		if !exists || token.Method.Alg() != key.algorithm {
+			return nil, ErrInvalidToken
+		}
+		if key.algorithm == AlgorithmEdDSA {
+			return key.publicKey, nil
+		}
+		return key.secret, nil
+	},
+		jwt.WithValidMethods([]string{tm.algorithm}),
+		jwt.WithIssuer(tm.issuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// Refresh rotates a refresh token and issues a new token pair. Presenting an already rotated
+// refresh token revokes its whole family, and so does a subject whose tenant, role or granted
+// scopes have changed since the family was issued, or who may no longer hold tokens.
+func (tm *TokenManager) Refresh(ctx context.Context, refresh string) (*TokenPair, error) {
+	hash := hashToken(refresh)
+
+	record, err := tm.store.Use(ctx, hash)
+	if errors.Is(err, ErrRefreshTokenReused) {
+		logger.Warn("Refresh token reuse detected, family revoked", "subject", record.Subject)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := tm.resolveSubject(ctx, record.Subject)
+	if err == nil && !current.grants(record) {
+		err = errors.New("tenant, role or scopes changed")
+	}
+	if err != nil {
+		logger.Info("Refresh token family revoked, subject is stale", "subject", record.Subject, "reason", err)
+		if err := tm.store.RevokeFamily(ctx, hash); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidRefreshToken
+	}
+
+	return tm.issue(ctx, record.FamilyID, record.Subject, record.TenantID, record.Role, record.Scopes)
+}
+
+// grants checks that a refresh token family was issued with the subject's current tenant and
+// role, and only with scopes the subject may still request
+func (s TokenSubject) grants(record RefreshRecord) bool {
+	if s.TenantID != record.TenantID || s.Role != record.Role {
+		return false
+	}
+	for _, scope := range record.Scopes {
+		if !hasPermission(s.Scopes, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// RevokeRefreshToken revokes the family the given refresh token belongs to
+func (tm *TokenManager) RevokeRefreshToken(ctx context.Context, refresh string) error {
+	return tm.store.RevokeFamily(ctx, hashToken(refresh))
+}
+
+// RevokeSubject revokes all refresh tokens issued to a subject
+func (tm *TokenManager) RevokeSubject(ctx context.Context, subject string) error {
+	return tm.store.RevokeSubject(ctx, subject)
+}
+
+// HealthCheck reports whether the refresh token store is reachable
+func (tm *TokenManager) HealthCheck(ctx context.Context) error {
+	if store, ok := tm.store.(interface{ Ping(context.Context) error }); ok {
+		return store.Ping(ctx)
+	}
+	return nil
+}
+
+// Reload replaces the signing keys with configured ones and activates the configured key.
+// Every replica sharing the configuration rotates to the same key; keys that are no longer
+// configured keep verifying tokens until they have all expired.
+func (tm *TokenManager) Reload(cfg TokenConfig) error {
+	if len(cfg.Keys) == 0 {
+		return nil
+	}
+
+	keys, err := tm.configuredKeys(cfg)
+	if err != nil {
+		return err
+	}
+
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	now := time.Now()
+	for id, key := range tm.keys {
+		if _, configured := keys[id]; configured {
+			continue
+		}
+		if key.retiredAt.IsZero() {
+			key.retiredAt = now
+		}
+		keys[id] = key
+	}
+	if tm.activeKeyID != cfg.ActiveKey {
+		logger.Info("Signing key rotated", "kid", cfg.ActiveKey, "previous_kid", tm.activeKeyID)
+	}
+	tm.keys = keys
+	tm.activeKeyID = cfg.ActiveKey
+
+	return nil
+}
+
+// JWKS returns the public verification keys as a JSON Web Key Set.
+// Symmetric HS256 keys are never published.
+func (tm *TokenManager) JWKS() JSONWebKeySet {
+	tm.mutex.RLock()
+	defer tm.mutex.RUnlock()
+
+	set := JSONWebKeySet{Keys: []JSONWebKey{}}
+	for _, key := range tm.keys {
+		if key.algorithm != AlgorithmEdDSA {
+			continue
+		}
+		set.Keys = append(set.Keys, JSONWebKey{
+			KeyType:   "OKP",
+			Curve:     "Ed25519",
+			X:         base64.RawURLEncoding.EncodeToString(key.publicKey),
+			KeyID:     key.id,
+			Algorithm: AlgorithmEdDSA,
+			Use:       "sig",
+		})
+	}
+
+	return set
+}
+
+// CleanupExpiredTokens removes expired refresh tokens and retired keys (should be called
+// periodically); stores that expire their own tokens need no cleanup
+func (tm *TokenManager) CleanupExpiredTokens() {
+	if store, ok := tm.store.(interface{ CleanupExpired() }); ok {
+		store.CleanupExpired()
+	}
+
+	tm.mutex.Lock()
+	defer tm.mutex.Unlock()
+
+	now := time.Now()
+
+	for id, key := range tm.keys {
+		if id != tm.activeKeyID && !key.retiredAt.IsZero() && now.After(key.retiredAt.Add(tm.accessTTL)) {
+			delete(tm.keys, id)
+		}
+	}
+}
+
+// signingMethod maps an algorithm name to its JWT signing method
+func signingMethod(algorithm string) jwt.SigningMethod {
+	if algorithm == AlgorithmEdDSA {
+		return jwt.SigningMethodEdDSA
+	}
+	return jwt.SigningMethodHS256
+}
+
+// generateTokenID creates a random token identifier
+func generateTokenID() (string, error) {
+	bytes := make([]byte, 16)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// hashToken returns the SHA-256 hex digest of an opaque token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
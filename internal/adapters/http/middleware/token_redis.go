@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Each refresh token is a hash of its record and whether it was rotated, expiring with the
+// token. Each family is a hash holding whether it was revoked, expiring with its newest token,
+// and each subject a set of its families.
+
+// useRefreshTokenScript marks a refresh token as rotated. It returns {"ok", record fields...},
+// {"reused", family, subject} after revoking the family, or {"invalid"}.
+var useRefreshTokenScript = redis.NewScript(`
+local token = redis.call('HMGET', KEYS[1], 'family', 'subject', 'tenant', 'role', 'scopes', 'used')
+if not token[1] then
+  return {'invalid'}
+end
+
+local family = ARGV[1] .. token[1]
+if redis.call('HGET', family, 'revoked') ~= '0' then
+  return {'invalid'}
+end
+
+if token[6] == '1' then
+  redis.call('HSET', family, 'revoked', '1')
+  return {'reused', token[1], token[2]}
+end
+
+redis.call('HSET', KEYS[1], 'used', '1')
+return {'ok', token[1], token[2], token[3], token[4], token[5]}
+`)
+
+// revokeRefreshFamilyScript marks a family as revoked unless it has already expired
+var revokeRefreshFamilyScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+  redis.call('HSET', KEYS[1], 'revoked', '1')
+end
+return 0
+`)
+
+// RedisRefreshTokenStore keeps refresh tokens on a Redis-protocol server, shared by every
+// replica. Scripts build the keys of families from token records, so the server must not be
+// a cluster.
+type RedisRefreshTokenStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisRefreshTokenStore creates a refresh token store; keys are prefixed with the given prefix
+func NewRedisRefreshTokenStore(client *redis.Client, prefix string) *RedisRefreshTokenStore {
+	return &RedisRefreshTokenStore{client: client, prefix: prefix}
+}
+
+// Ping checks that the server is reachable
+func (s *RedisRefreshTokenStore) Ping(ctx context.Context) error {
+	return s.client.Ping(ctx).Err()
+}
+
+// Save stores the record of a new refresh token
+func (s *RedisRefreshTokenStore) Save(ctx context.Context, hash string, record RefreshRecord) error {
+	tokenKey := s.prefix + "token:" + hash
+	familyKey := s.prefix + "family:" + record.FamilyID
+	subjectKey := s.prefix + "subject:" + record.Subject
+
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, tokenKey,
+			"family", record.FamilyID,
+			"subject", record.Subject,
+			"tenant", record.TenantID,
+			"role", record.Role,
+			"scopes", strings.Join(record.Scopes, " "),
+			"used", "0",
+		)
+		pipe.PExpireAt(ctx, tokenKey, record.ExpiresAt)
+		pipe.HSetNX(ctx, familyKey, "revoked", "0")
+		pipe.PExpireAt(ctx, familyKey, record.ExpiresAt)
+		pipe.SAdd(ctx, subjectKey, record.FamilyID)
+		pipe.PExpireAt(ctx, subjectKey, record.ExpiresAt)
+		return nil
+	})
+	return err
+}
+
+// Use marks a refresh token as rotated and returns its record
+func (s *RedisRefreshTokenStore) Use(ctx context.Context, hash string) (RefreshRecord, error) {
+	reply, err := useRefreshTokenScript.Run(ctx, s.client, []string{s.prefix + "token:" + hash}, s.prefix+"family:").StringSlice()
+	if err != nil {
+		return RefreshRecord{}, err
+	}
+
+	switch {
+	case len(reply) == 6 && reply[0] == "ok":
+		record := RefreshRecord{FamilyID: reply[1], Subject: reply[2], TenantID: reply[3], Role: reply[4]}
+		if reply[5] != "" {
+			record.Scopes = strings.Split(reply[5], " ")
+		}
+		return record, nil
+	case len(reply) == 3 && reply[0] == "reused":
+		return RefreshRecord{FamilyID: reply[1], Subject: reply[2]}, ErrRefreshTokenReused
+	case len(reply) == 1 && reply[0] == "invalid":
+		return RefreshRecord{}, ErrInvalidRefreshToken
+	}
+	return RefreshRecord{}, fmt.Errorf("unexpected refresh token script reply %v", reply)
+}
+
+// RevokeFamily revokes the family of a refresh token
+func (s *RedisRefreshTokenStore) RevokeFamily(ctx context.Context, hash string) error {
+	familyID, err := s.client.HGet(ctx, s.prefix+"token:"+hash, "family").Result()
+	if err == redis.Nil {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return s.revokeFamily(ctx, familyID)
+}
+
+// RevokeSubject revokes every refresh token issued to a subject
+func (s *RedisRefreshTokenStore) RevokeSubject(ctx context.Context, subject string) error {
+	subjectKey := s.prefix + "subject:" + subject
+	families, err := s.client.SMembers(ctx, subjectKey).Result()
+	if err != nil || len(families) == 0 {
+		return err
+	}
+
+	for _, familyID := range families {
+		if err := s.revokeFamily(ctx, familyID); err != nil {
+			return err
+		}
+	}
+	return s.client.SRem(ctx, subjectKey, stringsToAny(families)...).Err()
+}
+
+// revokeFamily marks a family as revoked
+func (s *RedisRefreshTokenStore) revokeFamily(ctx context.Context, familyID string) error {
+	return revokeRefreshFamilyScript.Run(ctx, s.client, []string{s.prefix + "family:" + familyID}).Err()
+}
+
+// stringsToAny converts strings to command arguments
+func stringsToAny(values []string) []interface{} {
+	args := make([]interface{}, len(values))
+	for i, value := range values {
+		args[i] = value
+	}
+	return args
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RefreshRecord is the server-side record of an issued refresh token
+type RefreshRecord struct {
+	FamilyID  string
+	Subject   string
+	TenantID  string
+	Role      string
+	Scopes    []string
+	ExpiresAt time.Time
+}
+
+// RefreshTokenStore keeps the records of issued refresh tokens by the SHA-256 hash of the
+// token. Replicas sharing a store refresh each other's tokens and detect reuse together.
+type RefreshTokenStore interface {
+	// Save stores the record of a new refresh token
+	Save(ctx context.Context, hash string, record RefreshRecord) error
+	// Use marks a refresh token as rotated and returns its record. A token that was rotated
+	// before revokes its family and returns ErrRefreshTokenReused with its record; an unknown,
+	// expired or revoked one returns ErrInvalidRefreshToken.
+	Use(ctx context.Context, hash string) (RefreshRecord, error)
+	// RevokeFamily revokes a refresh token and every token rotated from or into it
+	RevokeFamily(ctx context.Context, hash string) error
+	// RevokeSubject revokes every refresh token issued to a subject
+	RevokeSubject(ctx context.Context, subject string) error
+}
+
+// memoryRefreshToken is a refresh record and whether it was rotated
+type memoryRefreshToken struct {
+	RefreshRecord
+	used bool
+}
+
+// MemoryRefreshTokenStore keeps refresh tokens in process memory, for a single replica
+type MemoryRefreshTokenStore struct {
+	tokens map[string]*memoryRefreshToken
+	mutex  sync.Mutex
+}
+
+// NewMemoryRefreshTokenStore creates a new in-memory refresh token store
+func NewMemoryRefreshTokenStore() *MemoryRefreshTokenStore {
+	return &MemoryRefreshTokenStore{
+		tokens: make(map[string]*memoryRefreshToken),
+	}
+}
+
+// Save stores the record of a new refresh token
+func (s *MemoryRefreshTokenStore) Save(ctx context.Context, hash string, record RefreshRecord) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.tokens[hash] = &memoryRefreshToken{RefreshRecord: record}
+	return nil
+}
+
+// Use marks a refresh token as rotated and returns its record
+func (s *MemoryRefreshTokenStore) Use(ctx context.Context, hash string) (RefreshRecord, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	token, exists := s.tokens[hash]
+	if !exists || time.Now().After(token.ExpiresAt) {
+		return RefreshRecord{}, ErrInvalidRefreshToken
+	}
+
+	if token.used {
+		s.revokeFamilyLocked(token.FamilyID)
+		return token.RefreshRecord, ErrRefreshTokenReused
+	}
+
+	token.used = true
+	return token.RefreshRecord, nil
+}
+
+// RevokeFamily revokes the family of a refresh token
+func (s *MemoryRefreshTokenStore) RevokeFamily(ctx context.Context, hash string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if token, exists := s.tokens[hash]; exists {
+		s.revokeFamilyLocked(token.FamilyID)
+	}
+	return nil
+}
+
+// RevokeSubject revokes every refresh token issued to a subject
+func (s *MemoryRefreshTokenStore) RevokeSubject(ctx context.Context, subject string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for hash, token := range s.tokens {
+		if token.Subject == subject {
+			delete(s.tokens, hash)
+		}
+	}
+	return nil
+}
+
+// revokeFamilyLocked deletes every refresh token of a family; the caller must hold the lock
+func (s *MemoryRefreshTokenStore) revokeFamilyLocked(familyID string) {
+	for hash, token := range s.tokens {
+		if token.FamilyID == familyID {
+			delete(s.tokens, hash)
+		}
+	}
+}
+
+// CleanupExpired removes expired refresh tokens
+func (s *MemoryRefreshTokenStore) CleanupExpired() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	for hash, token := range s.tokens {
+		if now.After(token.ExpiresAt) {
+			delete(s.tokens, hash)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+)
+
+// testSubjects holds the current token subjects, changed by tests as users are
+type testSubjects struct {
+	subjects map[string]TokenSubject
+	mutex    sync.Mutex
+}
+
+func (s *testSubjects) set(subject string, current TokenSubject) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.subjects[subject] = current
+}
+
+func (s *testSubjects) resolve(ctx context.Context, subject string) (TokenSubject, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	current, exists := s.subjects[subject]
+	if !exists {
+		return TokenSubject{}, errors.New("user not found")
+	}
+	return current, nil
+}
+
+// newTestReplicas creates two token managers sharing signing keys and a Redis refresh token store
+func newTestReplicas(t *testing.T) (*TokenManager, *TokenManager, *testSubjects) {
+	t.Helper()
+
+	server := miniredis.RunT(t)
+	subjects := &testSubjects{subjects: map[string]TokenSubject{
+		"user-1": {TenantID: "tenant-1", Role: "user", Scopes: []string{"customer.read", "customer.write"}},
+	}}
+
+	replica := func() *TokenManager {
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		t.Cleanup(func() { client.Close() })
+
+		tokens := NewTokenManager(TokenConfig{
+			Algorithm:  AlgorithmHS256,
+			Keys:       map[string][]byte{"k1": []byte("0123456789abcdef0123456789abcdef")},
+			ActiveKey:  "k1",
+			Issuer:     "test",
+			AccessTTL:  time.Minute,
+			RefreshTTL: time.Hour,
+			Store:      NewRedisRefreshTokenStore(client, "test:"),
+		})
+		tokens.UseSubjects(subjects.resolve)
+		return tokens
+	}
+
+	return replica(), replica(), subjects
+}
+
+func TestTokenRefreshAcrossReplicas(t *testing.T) {
+	first, second, _ := newTestReplicas(t)
+	ctx := context.Background()
+
+	issued, err := first.IssueTokens(ctx, "user-1", []string{"customer.read"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	refreshed, err := second.Refresh(ctx, issued.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh on another replica: %v", err)
+	}
+	claims, err := first.ParseAccessToken(refreshed.AccessToken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if claims.Subject != "user-1" || claims.TenantID != "tenant-1" || len(claims.Scopes) != 1 || claims.Scopes[0] != "customer.read" {
+		t.Fatalf("claims = %+v", claims)
+	}
+
+	// Replaying the rotated token on the first replica revokes the family on both
+	if _, err := first.Refresh(ctx, issued.RefreshToken); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("reused token: err = %v, want ErrRefreshTokenReused", err)
+	}
+	if _, err := second.Refresh(ctx, refreshed.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("token of a revoked family: err = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestTokenRefreshRejectsStaleSubject(t *testing.T) {
+	for name, change := range map[string]func(*testSubjects){
+		"deactivated": func(s *testSubjects) {
+			s.mutex.Lock()
+			delete(s.subjects, "user-1")
+			s.mutex.Unlock()
+		},
+		"demoted": func(s *testSubjects) {
+			s.set("user-1", TokenSubject{TenantID: "tenant-1", Role: "user", Scopes: []string{"customer.read"}})
+		},
+		"moved": func(s *testSubjects) {
+			s.set("user-1", TokenSubject{TenantID: "tenant-2", Role: "user", Scopes: []string{"customer.read", "customer.write"}})
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			first, second, subjects := newTestReplicas(t)
+			ctx := context.Background()
+
+			issued, err := first.IssueTokens(ctx, "user-1", []string{"customer.write"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			change(subjects)
+			if _, err := second.Refresh(ctx, issued.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+				t.Fatalf("err = %v, want ErrInvalidRefreshToken", err)
+			}
+		})
+	}
+}
+
+func TestTokenRevokeSubject(t *testing.T) {
+	first, second, _ := newTestReplicas(t)
+	ctx := context.Background()
+
+	issued, err := first.IssueTokens(ctx, "user-1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := second.RevokeSubject(ctx, "user-1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := first.Refresh(ctx, issued.RefreshToken); !errors.Is(err, ErrInvalidRefreshToken) {
+		t.Fatalf("err = %v, want ErrInvalidRefreshToken", err)
+	}
+}
+
+func TestTokenIssueRejectsScopeNotGranted(t *testing.T) {
+	tokens, _, _ := newTestReplicas(t)
+
+	if _, err := tokens.IssueTokens(context.Background(), "user-1", []string{"member.manage"}); !errors.Is(err, ErrScopeNotAllowed) {
+		t.Fatalf("err = %v, want ErrScopeNotAllowed", err)
+	}
+}
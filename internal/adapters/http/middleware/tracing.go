@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"github.com/cloudparallax/parallax/pkg/tracing"
+	"github.com/gofiber/fiber/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/cloudparallax/parallax/internal/adapters/http")
+
+// requestHeaders exposes a request's headers to OpenTelemetry propagators
+type requestHeaders struct {
+	c fiber.Ctx
+}
+
+// Get returns a request header
+func (h requestHeaders) Get(key string) string {
+	return h.c.Get(key)
+}
+
+// Set sets a request header
+func (h requestHeaders) Set(key, value string) {
+	h.c.Request().Header.Set(key, value)
+}
+
+// Keys lists the request's header names
+func (h requestHeaders) Keys() []string {
+	headers := h.c.GetReqHeaders()
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// TracingHandler returns a handler that records a server span for each request, continuing the
+// caller's trace from its traceparent header. The span is kept in Locals, where tracing.Context
+// finds it, so use cases and repositories given the request context record child spans. Its trace ID is
+// stored in Locals as "trace_id" for logs.
+func TracingHandler() fiber.Handler {
+	return func(c fiber.Ctx) error {
+		ctx := otel.GetTextMapPropagator().Extract(c.RequestCtx(), requestHeaders{c})
+
+		_, span := tracer.Start(ctx, c.Method(), trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+			attribute.String("http.request.method", c.Method()),
+			attribute.String("url.path", c.Path()),
+			attribute.String("client.address", c.IP()),
+			attribute.String("user_agent.original", c.Get(fiber.HeaderUserAgent)),
+		))
+		defer span.End()
+
+		tracing.SetRequestSpan(c, span)
+		c.Locals("trace_id", span.SpanContext().TraceID().String())
+
+		err := c.Next()
+
+		// The route template is only known once routing is done
+		status := c.Response().StatusCode()
+		if err != nil {
+			span.RecordError(err)
+			status = fiber.StatusInternalServerError
+			if e, ok := err.(*fiber.Error); ok {
+				status = e.Code
+			}
+		}
+		span.SetName(c.Method() + " " + c.Route().Path)
+		span.SetAttributes(
+			attribute.String("http.route", c.Route().Path),
+			attribute.Int("http.response.status_code", status),
+		)
+		if userID, ok := c.Locals("user_id").(string); ok && userID != "" {
+			span.SetAttributes(attribute.String("enduser.id", userID))
+		}
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, "")
+		}
+
+		return err
+	}
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/cloudparallax/parallax/pkg/tracing"
+	"github.com/gofiber/fiber/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// spans records the spans of every test. Tracers obtained before the global provider is set
+// only ever delegate to the first one, so it is set once.
+var spans = func() *tracetest.InMemoryExporter {
+	exporter := tracetest.NewInMemoryExporter()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)))
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	return exporter
+}()
+
+// newTracedApp serves a route that starts a child span from the request context, as use
+// cases do
+func newTracedApp(t *testing.T) (*fiber.App, *tracetest.InMemoryExporter) {
+	t.Helper()
+	spans.Reset()
+
+	app := fiber.New()
+	app.Use(TracingHandler())
+	app.Get("/items/:id", func(c fiber.Ctx) error {
+		_, span := otel.Tracer("test").Start(tracing.Context(c.RequestCtx()), "ItemUseCase.GetItem")
+		span.End()
+		return c.SendString(c.Locals("trace_id").(string))
+	})
+	app.Get("/fail", func(c fiber.Ctx) error {
+		return fiber.ErrServiceUnavailable
+	})
+
+	return app, spans
+}
+
+func TestTracingHandlerRecordsServerSpan(t *testing.T) {
+	app, exporter := newTracedApp(t)
+
+	response, err := app.Test(httptest.NewRequest(http.MethodGet, "/items/42", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if response.StatusCode != fiber.StatusOK {
+		t.Fatalf("status = %d, want 200", response.StatusCode)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(spans))
+	}
+	child, server := spans[0], spans[1]
+	if server.Name != "GET /items/:id" || server.SpanKind != trace.SpanKindServer {
+		t.Fatalf("server span = %q (%v), want GET /items/:id (server)", server.Name, server.SpanKind)
+	}
+	if child.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Fatal("span started from the request context is not a child of the server span")
+	}
+
+	attributes := map[string]string{}
+	for _, attribute := range server.Attributes {
+		attributes[string(attribute.Key)] = attribute.Value.Emit()
+	}
+	if attributes["http.route"] != "/items/:id" || attributes["http.response.status_code"] != "200" {
+		t.Fatalf("server span attributes = %v", attributes)
+	}
+}
+
+func TestTracingHandlerContinuesCallerTrace(t *testing.T) {
+	app, exporter := newTracedApp(t)
+
+	request := httptest.NewRequest(http.MethodGet, "/items/42", nil)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if _, err := app.Test(request); err != nil {
+		t.Fatal(err)
+	}
+
+	server := exporter.GetSpans()[1]
+	if got := server.SpanContext.TraceID().String(); got != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("trace ID = %s, want the caller's", got)
+	}
+	if got := server.Parent.SpanID().String(); got != "00f067aa0ba902b7" || !server.Parent.IsRemote() {
+		t.Fatalf("parent span = %s, want the caller's remote span", got)
+	}
+}
+
+func TestTracingHandlerMarksServerErrors(t *testing.T) {
+	app, exporter := newTracedApp(t)
+
+	if _, err := app.Test(httptest.NewRequest(http.MethodGet, "/fail", nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+	if spans[0].Status.Code.String() != "Error" {
+		t.Fatalf("status = %v, want Error", spans[0].Status.Code)
+	}
+}
@@ -1,81 +1,268 @@
 package http
 
 import (
+	"context"
+	"errors"
+
 	"github.com/cloudparallax/parallax/internal/adapters/controllers"
 	"github.com/cloudparallax/parallax/internal/adapters/http/middleware"
 	"github.com/cloudparallax/parallax/internal/adapters/repositories"
+	"github.com/cloudparallax/parallax/internal/adapters/sso"
+	"github.com/cloudparallax/parallax/internal/config"
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	domainrepos "github.com/cloudparallax/parallax/internal/domain/repositories"
 	"github.com/cloudparallax/parallax/internal/usecases"
+	"github.com/cloudparallax/parallax/pkg/health"
+	"github.com/cloudparallax/parallax/pkg/lifecycle"
+	"github.com/cloudparallax/parallax/pkg/logging"
+	"github.com/cloudparallax/parallax/pkg/mailer"
+	"github.com/cloudparallax/parallax/pkg/metrics"
+	"github.com/cloudparallax/parallax/pkg/version"
 	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
 )
 
+var logger = logging.Logger("http")
+
 // Router handles API route setup
 type Router struct {
 	app        *fiber.App
 	middleware *middleware.MiddlewareManager
+	health     *health.Registry
+	background *lifecycle.Manager
+	config     *config.Config
 }
 
-// NewRouter creates a new router instance
-func NewRouter(app *fiber.App) *Router {
+// NewRouter creates a new router instance; background tasks are started on the given lifecycle manager
+func NewRouter(app *fiber.App, background *lifecycle.Manager, cfg *config.Config) *Router {
 	return &Router{
 		app:        app,
-		middleware: middleware.NewMiddlewareManager(),
+		middleware: middleware.NewMiddlewareManager(middleware.NewMiddlewareConfig(cfg)),
+		health:     health.NewRegistry(health.Config{Timeout: cfg.Server.HealthCheckTimeout}),
+		background: background,
+		config:     cfg,
 	}
 }
 
+// Health returns the health check registry, whose state decides readiness
+func (r *Router) Health() *health.Registry {
+	return r.health
+}
+
+// Reload applies the reloadable settings of a configuration to the routes' middlewares
+func (r *Router) Reload(cfg *config.Config) error {
+	return r.middleware.Reload(cfg)
+}
+
 // SetupRoutes configures all API routes
 func (r *Router) SetupRoutes() {
 	// Setup global middleware
 	r.middleware.SetupGlobalMiddleware(r.app)
 	
 	// Start cleanup routine for middlewares
-	r.middleware.StartCleanupRoutine()
+	if err := r.middleware.StartCleanupRoutine(r.background); err != nil {
+		logger.Error("Failed to start middleware cleanup", "error", err)
+	}
 
-	// Initialize repositories
-	tenantRepo := repositories.NewMemoryTenantRepository()
-	locationRepo := repositories.NewMemoryLocationRepository()
-	customerRepo := repositories.NewMemoryCustomerRepository()
+	// Initialize repositories; the metrics read the untraced ones, so scrapes don't start traces
+	tenantStore := repositories.NewMemoryTenantRepository()
+	locationStore := repositories.NewMemoryLocationRepository()
+	customerStore := repositories.NewMemoryCustomerRepository()
+	tenantRepo := repositories.NewTracedTenantRepository(tenantStore)
+	locationRepo := repositories.NewTracedLocationRepository(locationStore)
+	customerRepo := repositories.NewTracedCustomerRepository(customerStore)
+	userRepo := repositories.NewMemoryUserRepository()
+	oidcConfigRepo := repositories.NewMemoryOIDCConfigRepository()
+	membershipRepo := repositories.NewMemoryMembershipRepository()
+	userTokenRepo := repositories.NewMemoryUserTokenRepository()
+	invitationRepo := repositories.NewMemoryInvitationRepository()
+	scimConfigRepo := repositories.NewMemorySCIMConfigRepository()
+	groupRepo := repositories.NewMemoryGroupRepository()
 
 	// Initialize use cases
 	tenantUseCase := usecases.NewTenantUseCase(tenantRepo)
 	locationUseCase := usecases.NewLocationUseCase(locationRepo, tenantRepo)
 	customerUseCase := usecases.NewCustomerUseCase(customerRepo, tenantRepo)
+	ssoUseCase := usecases.NewSSOUseCase(oidcConfigRepo, userRepo, tenantRepo, membershipRepo)
+	authUseCase := usecases.NewAuthUseCase(userRepo, tenantRepo)
+	membershipUseCase := usecases.NewMembershipUseCase(membershipRepo, userRepo, tenantRepo)
+	mail := newMailer(r.config.Mail)
+	accountUseCase := usecases.NewAccountUseCase(userRepo, userTokenRepo, mail, r.background, accountConfig(r.config.Account))
+	invitationUseCase := usecases.NewInvitationUseCase(invitationRepo, membershipRepo, userRepo, tenantRepo, mail, invitationConfig(r.config.Account))
+	scimUseCase := usecases.NewSCIMUseCase(scimConfigRepo, groupRepo, userRepo, membershipRepo, tenantRepo)
+
+	// Resolve tenant permissions from memberships
+	r.middleware.SetPermissionResolver(r.permissionResolver(membershipUseCase))
+
+	// Issue and refresh tokens with the user's current role and tenant permissions
+	r.middleware.SetTokenSubjectResolver(r.tokenSubjects(authUseCase, membershipUseCase))
+
+	// Allow each tenant's own browser origins
+	r.middleware.SetTenantOriginResolver(r.tenantOrigins(tenantUseCase))
+
+	// Scale rate limits with the plan of the member's tenant
+	r.middleware.SetTenantPlanResolver(r.tenantPlans(membershipUseCase, tenantUseCase))
+
+	// Report tenant, location and customer counts with the metrics
+	r.registerDomainMetrics(tenantStore, locationStore, customerStore)
+
+	// Remove expired password reset and email verification tokens
+	r.startAccountCleanup(accountUseCase)
+
+	// Check the session and rate limit stores and the repositories for readiness
+	r.middleware.RegisterHealthChecks(r.health)
+	r.health.Register("tenant_repository", func(ctx context.Context) error {
+		_, err := tenantRepo.GetActiveCount(ctx)
+		return err
+	})
+	r.health.Register("user_repository", func(ctx context.Context) error {
+		_, err := userRepo.CountByTenantID(ctx, uuid.Nil)
+		return err
+	})
+	r.health.Register("membership_repository", func(ctx context.Context) error {
+		_, err := membershipRepo.CountByTenantID(ctx, uuid.Nil)
+		return err
+	})
+	r.health.Register("location_repository", func(ctx context.Context) error {
+		_, err := locationRepo.CountByTenantID(ctx, uuid.Nil)
+		return err
+	})
+	r.health.Register("customer_repository", func(ctx context.Context) error {
+		_, err := customerRepo.CountByTenantID(ctx, uuid.Nil)
+		return err
+	})
+
+	// Seed the initial platform admin account
+	r.bootstrapAdmin(authUseCase)
 
 	// Initialize controllers
-	tenantController := controllers.NewTenantController(tenantUseCase)
+	tenantController := controllers.NewTenantController(tenantUseCase, membershipUseCase)
+	membershipController := controllers.NewMembershipController(membershipUseCase)
 	locationController := controllers.NewLocationController(locationUseCase)
 	customerController := controllers.NewCustomerController(customerUseCase)
+	ssoController := controllers.NewSSOController(ssoUseCase, sso.NewOIDCClient(), r.middleware)
+	authController := controllers.NewAuthController(authUseCase, r.middleware)
+	accountController := controllers.NewAccountController(accountUseCase, r.middleware)
+	invitationController := controllers.NewInvitationController(invitationUseCase)
+	scimController := controllers.NewSCIMController(scimUseCase, r.middleware)
 
 	// Setup API routes
 	api := r.app.Group("/api/v1")
 
 	// Health check (no auth required)
-	api.Get("/health", r.healthCheck)
+	health := api.Group("/health")
+	r.middleware.UseCORSPolicy(health, middleware.CORSPolicyPublic)
+	r.middleware.UseRateLimitRule(health, middleware.RateLimitRuleNone)
+	health.Get("/", r.healthCheck)
+
+	// Liveness and readiness probes (no auth required)
+	for path, handler := range map[string]fiber.Handler{"/livez": r.liveness, "/readyz": r.readiness} {
+		probe := r.app.Group(path)
+		r.middleware.UseCORSPolicy(probe, middleware.CORSPolicyNone)
+		r.middleware.UseRateLimitRule(probe, middleware.RateLimitRuleNone)
+		probe.Get("/", handler)
+	}
+
+	// Metrics endpoint (metrics token when configured)
+	if path, handler, enabled := r.middleware.MetricsEndpoint(); enabled {
+		metricsRoute := r.app.Group(path)
+		r.middleware.UseCORSPolicy(metricsRoute, middleware.CORSPolicyNone)
+		r.middleware.UseRateLimitRule(metricsRoute, middleware.RateLimitRuleNone)
+		metricsRoute.Get("/", handler)
+	}
+
+	// JWKS discovery endpoint (no auth required)
+	wellKnown := r.app.Group("/.well-known")
+	r.middleware.UseCORSPolicy(wellKnown, middleware.CORSPolicyPublic)
+	wellKnown.Get("/jwks.json", r.getJWKS)
 
 	// Hello World endpoint (no auth required)
 	api.Get("/hello", r.helloWorld)
 
+	// SCIM provisioning (tenant bearer token)
+	r.setupSCIMRoutes(scimController)
+
 	// Auth routes (no auth required)
-	r.setupAuthRoutes(api)
+	r.setupAuthRoutes(api, authController, accountController, invitationController, ssoController)
 
 	// Public routes (optional auth)
 	r.setupPublicRoutes(api, tenantController, locationController, customerController)
 
 	// Protected routes (auth required)
-	r.setupProtectedRoutes(api, tenantController, locationController, customerController)
+	r.setupProtectedRoutes(api, authController, tenantController, locationController, customerController, membershipController, invitationController, ssoController, scimController, locationUseCase, customerUseCase)
+}
+
+// setupSCIMRoutes configures the SCIM 2.0 provisioning API, authenticated by tenant bearer tokens
+func (r *Router) setupSCIMRoutes(scimController *controllers.SCIMController) {
+	scimAPI := r.app.Group("/scim/v2", scimController.Authenticate)
+	r.middleware.UseCORSPolicy(scimAPI, middleware.CORSPolicyNone)
+	scimAPI.Get("/ServiceProviderConfig", scimController.GetServiceProviderConfig)
+
+	users := scimAPI.Group("/Users")
+	users.Get("/", scimController.GetUsers)
+	users.Post("/", scimController.CreateUser)
+	users.Get("/:id", scimController.GetUser)
+	users.Put("/:id", scimController.ReplaceUser)
+	users.Patch("/:id", scimController.PatchUser)
+	users.Delete("/:id", scimController.DeleteUser)
+
+	groups := scimAPI.Group("/Groups")
+	groups.Get("/", scimController.GetGroups)
+	groups.Post("/", scimController.CreateGroup)
+	groups.Get("/:id", scimController.GetGroup)
+	groups.Put("/:id", scimController.ReplaceGroup)
+	groups.Patch("/:id", scimController.PatchGroup)
+	groups.Delete("/:id", scimController.DeleteGroup)
 }
 
 // setupAuthRoutes configures authentication routes
-func (r *Router) setupAuthRoutes(api fiber.Router) {
+func (r *Router) setupAuthRoutes(api fiber.Router, authController *controllers.AuthController, accountController *controllers.AccountController, invitationController *controllers.InvitationController, ssoController *controllers.SSOController) {
 	auth := api.Group("/auth")
+	r.middleware.UseCORSPolicy(auth, middleware.CORSPolicyAuth)
+	r.middleware.UseRateLimitRule(auth, middleware.RateLimitRuleAuth)
 
 	// Login endpoint
-	auth.Post("/login", r.login)
+	auth.Post("/login", authController.Login)
 	
 	// Logout endpoint
-	auth.Post("/logout", r.logout)
+	auth.Post("/logout", authController.Logout)
+
+	// Token endpoints (stateless auth for mobile and service clients)
+	auth.Post("/token", authController.IssueToken)
+	auth.Post("/refresh", authController.RefreshToken)
+	auth.Post("/revoke", authController.RevokeToken)
+
+	// Two-factor authentication (accepts sessions still awaiting their second factor)
+	mfa := auth.Group("/mfa", r.middleware.RequireSession(), r.middleware.SetupCSRF(), r.middleware.BlockImpersonation())
+	mfa.Post("/verify", authController.VerifyMFA)
+	mfa.Post("/totp/enroll", authController.EnrollTOTP)
+	mfa.Post("/totp/confirm", authController.ConfirmTOTP)
+	// Changing an enrolled second factor needs a completed sign-in
+	mfa.Post("/totp/disable", r.middleware.RequireAuth(), authController.DisableTOTP)
+	mfa.Post("/recovery-codes", r.middleware.RequireAuth(), authController.RegenerateRecoveryCodes)
+	auth.Get("/jwks", r.getJWKS)
+
+	// Password reset and email verification
+	auth.Post("/password/forgot", accountController.ForgotPassword)
+	auth.Post("/password/reset", accountController.ResetPassword)
+	auth.Post("/verify-email", accountController.VerifyEmail)
+	auth.Post("/verify-email/resend", r.middleware.RequireAuth(), r.middleware.SetupCSRF(), r.middleware.BlockImpersonation(), accountController.ResendVerification)
+
+	// Change password (never on behalf of someone else)
+	auth.Post("/password/change", r.middleware.RequireAuth(), r.middleware.SetupCSRF(), r.middleware.BlockImpersonation(), authController.ChangePassword)
+
+	// End an impersonation and return to the admin's own session
+	auth.Post("/impersonation/stop", r.middleware.RequireAuth(), r.middleware.SetupCSRF(), authController.StopImpersonation)
+
+	// Accept a tenant invitation
+	auth.Post("/invitations/accept", invitationController.AcceptInvitation)
+
+	// Single sign-on through the tenant's OpenID Connect provider
+	auth.Get("/oidc/:tenantId/login", ssoController.StartLogin)
+	auth.Get("/oidc/:tenantId/callback", ssoController.Callback)
 	
 	// Get CSRF token
-	auth.Get("/csrf", r.getCSRFToken)
+	auth.Get("/csrf", r.middleware.SetupCSRF(), r.getCSRFToken)
 	
 	// Check auth status
 	auth.Get("/me", r.middleware.OptionalAuth(), r.getAuthStatus)
@@ -88,125 +275,300 @@ func (r *Router) setupPublicRoutes(api fiber.Router, tenantController *controlle
 	
 	// Public tenant routes (read-only)
 	tenants := public.Group("/tenants")
+	r.middleware.UseRateLimitRule(tenants, middleware.RateLimitRuleAPI)
 	tenants.Get("/", tenantController.GetTenants)
 	tenants.Get("/:id", tenantController.GetTenant)
 	
 	// Public location routes (read-only)
 	locations := public.Group("/tenants/:tenantId/locations")
+	r.middleware.UseRateLimitRule(locations, middleware.RateLimitRuleAPI)
 	locations.Get("/", locationController.GetLocationsByTenant)
 	locations.Get("/active", locationController.GetActiveLocationsByTenant)
 	
 	// Individual location routes
 	location := public.Group("/locations")
+	r.middleware.UseRateLimitRule(location, middleware.RateLimitRuleAPI)
 	location.Get("/:id", locationController.GetLocation)
 	
 	// Public customer routes (read-only)
 	customers := public.Group("/tenants/:tenantId/customers")
+	r.middleware.UseRateLimitRule(customers, middleware.RateLimitRuleBulk)
 	customers.Get("/", customerController.GetCustomersByTenant)
 	customers.Get("/search", customerController.SearchCustomers)
 	
 	// Individual customer routes
 	customer := public.Group("/customers")
+	r.middleware.UseRateLimitRule(customer, middleware.RateLimitRuleBulk)
 	customer.Get("/:id", customerController.GetCustomer)
 }
 
 // setupProtectedRoutes configures protected routes (auth required)
-func (r *Router) setupProtectedRoutes(api fiber.Router, tenantController *controllers.TenantController, locationController *controllers.LocationController, customerController *controllers.CustomerController) {
+func (r *Router) setupProtectedRoutes(api fiber.Router, authController *controllers.AuthController, tenantController *controllers.TenantController, locationController *controllers.LocationController, customerController *controllers.CustomerController, membershipController *controllers.MembershipController, invitationController *controllers.InvitationController, ssoController *controllers.SSOController, scimController *controllers.SCIMController, locationUseCase *usecases.LocationUseCase, customerUseCase *usecases.CustomerUseCase) {
 	// Apply auth and CSRF protection to all protected routes
 	protected := api.Group("/", r.middleware.RequireAuth(), r.middleware.SetupCSRF())
+
+	// Resolvers for routes that identify their tenant indirectly
+	tenantParam := middleware.TenantFromParam("id")
+	locationTenant := r.locationTenant(locationUseCase)
+	customerTenant := r.customerTenant(customerUseCase)
 	
 	// Protected tenant routes (write operations)
 	tenants := protected.Group("/tenants")
+	r.middleware.UseRateLimitRule(tenants, middleware.RateLimitRuleAPI)
 	tenants.Post("/", tenantController.CreateTenant)
-	tenants.Put("/:id", tenantController.UpdateTenant)
-	tenants.Delete("/:id", tenantController.DeleteTenant)
-	tenants.Post("/:id/activate", tenantController.ActivateTenant)
-	tenants.Post("/:id/deactivate", tenantController.DeactivateTenant)
+	tenants.Put("/:id", r.middleware.RequirePermission(entities.PermissionTenantWrite, tenantParam), tenantController.UpdateTenant)
+	// Plan and limits gate rate limits, seats and SSO, so tenants cannot change their own
+	tenants.Put("/:id/plan", r.middleware.RequireRole(middleware.PlatformAdminRole), tenantController.SetTenantPlan)
+	tenants.Delete("/:id", r.middleware.RequirePermission(entities.PermissionTenantDelete, tenantParam), tenantController.DeleteTenant)
+	tenants.Post("/:id/activate", r.middleware.RequirePermission(entities.PermissionTenantWrite, tenantParam), tenantController.ActivateTenant)
+	tenants.Post("/:id/deactivate", r.middleware.RequirePermission(entities.PermissionTenantWrite, tenantParam), tenantController.DeactivateTenant)
+	tenants.Post("/:id/mfa-policy", r.middleware.RequirePermission(entities.PermissionTenantWrite, tenantParam), tenantController.SetMFAPolicy)
+	tenants.Put("/:id/cors", r.middleware.RequirePermission(entities.PermissionTenantWrite, tenantParam), tenantController.SetAllowedOrigins)
+
+	// Tenant member management
+	members := protected.Group("/tenants/:tenantId/members")
+	r.middleware.UseRateLimitRule(members, middleware.RateLimitRuleAPI)
+	members.Get("/", r.middleware.RequirePermission(entities.PermissionMemberRead), membershipController.GetMembers)
+	members.Post("/", r.middleware.RequirePermission(entities.PermissionMemberManage), membershipController.AddMember)
+	members.Put("/:userId", r.middleware.RequirePermission(entities.PermissionMemberManage), membershipController.UpdateMemberRole)
+	members.Delete("/:userId", r.middleware.RequirePermission(entities.PermissionMemberManage), membershipController.RemoveMember)
+
+	// Tenant invitations
+	invitations := protected.Group("/tenants/:tenantId/invitations")
+	r.middleware.UseRateLimitRule(invitations, middleware.RateLimitRuleAPI)
+	invitations.Get("/", r.middleware.RequirePermission(entities.PermissionMemberManage), invitationController.GetInvitations)
+	invitations.Post("/", r.middleware.RequirePermission(entities.PermissionMemberManage), invitationController.CreateInvitation)
+	invitations.Delete("/:invitationId", r.middleware.RequirePermission(entities.PermissionMemberManage), invitationController.RevokeInvitation)
 	
 	// Protected location routes (write operations)
 	locations := protected.Group("/tenants/:tenantId/locations")
-	locations.Post("/", locationController.CreateLocation)
+	r.middleware.UseRateLimitRule(locations, middleware.RateLimitRuleAPI)
+	locations.Post("/", r.middleware.RequirePermission(entities.PermissionLocationWrite), locationController.CreateLocation)
 	
 	// Individual location write operations
 	location := protected.Group("/locations")
-	location.Put("/:id", locationController.UpdateLocation)
-	location.Delete("/:id", locationController.DeleteLocation)
-	location.Post("/:id/activate", locationController.ActivateLocation)
-	location.Post("/:id/deactivate", locationController.DeactivateLocation)
+	r.middleware.UseRateLimitRule(location, middleware.RateLimitRuleAPI)
+	location.Put("/:id", r.middleware.RequirePermission(entities.PermissionLocationWrite, locationTenant), locationController.UpdateLocation)
+	location.Delete("/:id", r.middleware.RequirePermission(entities.PermissionLocationDelete, locationTenant), locationController.DeleteLocation)
+	location.Post("/:id/activate", r.middleware.RequirePermission(entities.PermissionLocationWrite, locationTenant), locationController.ActivateLocation)
+	location.Post("/:id/deactivate", r.middleware.RequirePermission(entities.PermissionLocationWrite, locationTenant), locationController.DeactivateLocation)
 	
 	// Protected customer routes (write operations)
 	customers := protected.Group("/tenants/:tenantId/customers")
-	customers.Post("/", customerController.CreateCustomer)
+	r.middleware.UseRateLimitRule(customers, middleware.RateLimitRuleBulk)
+	customers.Post("/", r.middleware.RequirePermission(entities.PermissionCustomerWrite), customerController.CreateCustomer)
 	
 	// Individual customer write operations
 	customer := protected.Group("/customers")
-	customer.Put("/:id", customerController.UpdateCustomer)
-	customer.Delete("/:id", customerController.DeleteCustomer)
-	customer.Post("/:id/activate", customerController.ActivateCustomer)
-	customer.Post("/:id/deactivate", customerController.DeactivateCustomer)
-	customer.Post("/:id/tags", customerController.AddTag)
-	customer.Delete("/:id/tags", customerController.RemoveTag)
+	r.middleware.UseRateLimitRule(customer, middleware.RateLimitRuleBulk)
+	customer.Put("/:id", r.middleware.RequirePermission(entities.PermissionCustomerWrite, customerTenant), customerController.UpdateCustomer)
+	customer.Delete("/:id", r.middleware.RequirePermission(entities.PermissionCustomerDelete, customerTenant), customerController.DeleteCustomer)
+	customer.Post("/:id/activate", r.middleware.RequirePermission(entities.PermissionCustomerWrite, customerTenant), customerController.ActivateCustomer)
+	customer.Post("/:id/deactivate", r.middleware.RequirePermission(entities.PermissionCustomerWrite, customerTenant), customerController.DeactivateCustomer)
+	customer.Post("/:id/tags", r.middleware.RequirePermission(entities.PermissionCustomerWrite, customerTenant), customerController.AddTag)
+	customer.Delete("/:id/tags", r.middleware.RequirePermission(entities.PermissionCustomerWrite, customerTenant), customerController.RemoveTag)
 	
 	// Admin routes (require admin role)
 	admin := protected.Group("/admin", r.middleware.RequireRole("admin"))
+	r.middleware.UseCORSPolicy(admin, middleware.CORSPolicyAdmin)
+	r.middleware.UseRateLimitRule(admin, middleware.RateLimitRuleAPI)
 	admin.Get("/users", r.getUsers)
 	admin.Delete("/users/:id", r.deleteUser)
+	admin.Post("/users/:id/unlock", authController.UnlockUser)
+	admin.Post("/users/:id/impersonate", authController.Impersonate)
+
+	// Tenant single sign-on configuration
+	oidc := admin.Group("/tenants/:tenantId/oidc")
+	oidc.Get("/", ssoController.GetOIDCConfig)
+	oidc.Put("/", ssoController.ConfigureOIDC)
+	oidc.Delete("/", ssoController.DeleteOIDCConfig)
+	oidc.Post("/enable", ssoController.EnableOIDC)
+	oidc.Post("/disable", ssoController.DisableOIDC)
+
+	// Tenant directory provisioning configuration
+	scimConfig := admin.Group("/tenants/:tenantId/scim")
+	scimConfig.Get("/", scimController.GetSCIMConfig)
+	scimConfig.Post("/token", scimController.GenerateToken)
+	scimConfig.Put("/group-roles", scimController.SetGroupRoles)
+	scimConfig.Delete("/", scimController.DeleteSCIMConfig)
 }
 
-// login handles user authentication
-func (r *Router) login(c fiber.Ctx) error {
-	// TODO: Implement actual authentication logic
-	// For now, this is a placeholder that accepts any credentials
-	
-	type LoginRequest struct {
-		Username string `json:"username"`
-		Password string `json:"password"`
-	}
-	
-	var req LoginRequest
-	if err := c.Bind().JSON(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    fiber.StatusBadRequest,
-				"message": "Invalid request body",
-			},
-		})
+// permissionResolver adapts membership lookups to the permission middleware
+func (r *Router) permissionResolver(membershipUseCase *usecases.MembershipUseCase) middleware.PermissionResolver {
+	return func(ctx context.Context, userID, tenantID string) (string, []string, error) {
+		uid, err := uuid.Parse(userID)
+		if err != nil {
+			return "", nil, err
+		}
+		tid, err := uuid.Parse(tenantID)
+		if err != nil {
+			return "", nil, err
+		}
+		return membershipUseCase.GetPermissions(ctx, uid, tid)
 	}
-	
-	// Placeholder authentication (replace with real auth)
-	if req.Username == "" || req.Password == "" {
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    fiber.StatusUnauthorized,
-				"message": "Invalid credentials",
-			},
-		})
+}
+
+// tokenSubjects looks up the tenant, platform role and grantable scopes of token subjects.
+// Deactivated users and users no longer members of their tenant may not hold tokens; tenant
+// members may request the permissions of their role, and platform admins any permission.
+func (r *Router) tokenSubjects(authUseCase *usecases.AuthUseCase, membershipUseCase *usecases.MembershipUseCase) middleware.TokenSubjectResolver {
+	return func(ctx context.Context, subject string) (middleware.TokenSubject, error) {
+		userID, err := uuid.Parse(subject)
+		if err != nil {
+			return middleware.TokenSubject{}, err
+		}
+		user, err := authUseCase.GetUser(ctx, userID)
+		if err != nil {
+			return middleware.TokenSubject{}, err
+		}
+		if !user.IsActive {
+			return middleware.TokenSubject{}, errors.New("user is deactivated")
+		}
+
+		current := middleware.TokenSubject{Role: user.Role}
+		if user.Role == middleware.PlatformAdminRole {
+			current.Scopes = entities.RolePermissions(entities.RoleOwner)
+		}
+		if user.TenantID != uuid.Nil {
+			current.TenantID = user.TenantID.String()
+			if user.Role != middleware.PlatformAdminRole {
+				if _, current.Scopes, err = membershipUseCase.GetPermissions(ctx, user.ID, user.TenantID); err != nil {
+					return middleware.TokenSubject{}, err
+				}
+			}
+		}
+		return current, nil
 	}
-	
-	// Create session
-	userData := map[string]interface{}{
-		"username": req.Username,
-		"role":     "user", // Default role
+}
+
+// tenantOrigins looks up the browser origins a tenant allows
+func (r *Router) tenantOrigins(tenantUseCase *usecases.TenantUseCase) middleware.TenantOriginResolver {
+	return func(ctx context.Context, tenantID string) []string {
+		id, err := uuid.Parse(tenantID)
+		if err != nil {
+			return nil
+		}
+		tenant, err := tenantUseCase.GetTenant(ctx, id)
+		if err != nil || !tenant.IsActive {
+			return nil
+		}
+		return tenant.AllowedOrigins
 	}
-	
-	if err := r.middleware.Login(c, req.Username, userData); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    fiber.StatusInternalServerError,
-				"message": "Failed to create session",
-			},
-		})
+}
+
+// tenantPlans looks up the plan of an active tenant the user is a member of
+func (r *Router) tenantPlans(membershipUseCase *usecases.MembershipUseCase, tenantUseCase *usecases.TenantUseCase) middleware.TenantPlanResolver {
+	return func(ctx context.Context, userID, tenantID string) string {
+		uid, err := uuid.Parse(userID)
+		if err != nil {
+			return ""
+		}
+		tid, err := uuid.Parse(tenantID)
+		if err != nil {
+			return ""
+		}
+		if _, _, err := membershipUseCase.GetPermissions(ctx, uid, tid); err != nil {
+			return ""
+		}
+		tenant, err := tenantUseCase.GetTenant(ctx, tid)
+		if err != nil || !tenant.IsActive {
+			return ""
+		}
+		return tenant.Plan
 	}
-	
-	return c.JSON(fiber.Map{
-		"success": true,
-		"data": fiber.Map{
-			"user": userData,
-		},
+}
+
+// registerDomainMetrics adds gauges of tenants, and of locations and customers per tenant when
+// the metrics endpoint needs a token, read from the repositories on each scrape
+func (r *Router) registerDomainMetrics(tenantRepo domainrepos.TenantRepository, locationRepo domainrepos.LocationRepository, customerRepo domainrepos.CustomerRepository) {
+	registry := r.middleware.Metrics()
+
+	registry.NewGaugeFunc("parallax_tenants", "Tenants by state.", []string{"state"}, func() []metrics.Sample {
+		active, inactive := 0, 0
+		for _, tenant := range allTenants(tenantRepo) {
+			if tenant.IsActive {
+				active++
+			} else {
+				inactive++
+			}
+		}
+		return []metrics.Sample{
+			{Labels: []string{"active"}, Value: float64(active)},
+			{Labels: []string{"inactive"}, Value: float64(inactive)},
+		}
 	})
+
+	// Per-tenant series carry tenant IDs, which an open endpoint must not expose
+	if r.config.Metrics.Token == "" {
+		return
+	}
+
+	perTenant := func(count func(ctx context.Context, tenantID uuid.UUID) (int, error)) func() []metrics.Sample {
+		return func() []metrics.Sample {
+			var samples []metrics.Sample
+			for _, tenant := range allTenants(tenantRepo) {
+				if n, err := count(context.Background(), tenant.ID); err == nil {
+					samples = append(samples, metrics.Sample{Labels: []string{tenant.ID.String()}, Value: float64(n)})
+				}
+			}
+			return samples
+		}
+	}
+	registry.NewGaugeFunc("parallax_locations", "Locations per tenant.", []string{"tenant_id"}, perTenant(locationRepo.CountByTenantID))
+	registry.NewGaugeFunc("parallax_customers", "Customers per tenant.", []string{"tenant_id"}, perTenant(customerRepo.CountByTenantID))
+}
+
+// allTenants lists every tenant, a page at a time
+func allTenants(tenantRepo domainrepos.TenantRepository) []*entities.Tenant {
+	const pageSize = 500
+
+	var tenants []*entities.Tenant
+	for offset := 0; ; offset += pageSize {
+		page, err := tenantRepo.GetAll(context.Background(), pageSize, offset)
+		if err != nil {
+			logger.Warn("Failed to list tenants for metrics", "error", err)
+			return tenants
+		}
+		tenants = append(tenants, page...)
+		if len(page) < pageSize {
+			return tenants
+		}
+	}
+}
+
+// locationTenant resolves the tenant that owns the location in the route
+func (r *Router) locationTenant(locationUseCase *usecases.LocationUseCase) middleware.TenantResolver {
+	return func(c fiber.Ctx) (string, error) {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return "", err
+		}
+		location, err := locationUseCase.GetLocation(c.RequestCtx(), id)
+		if err != nil {
+			return "", err
+		}
+		return location.TenantID.String(), nil
+	}
+}
+
+// customerTenant resolves the tenant that owns the customer in the route
+func (r *Router) customerTenant(customerUseCase *usecases.CustomerUseCase) middleware.TenantResolver {
+	return func(c fiber.Ctx) (string, error) {
+		id, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return "", err
+		}
+		customer, err := customerUseCase.GetCustomer(c.RequestCtx(), id)
+		if err != nil {
+			return "", err
+		}
+		return customer.TenantID.String(), nil
+	}
+}
+
+// getJWKS returns the public keys used to verify access tokens
+func (r *Router) getJWKS(c fiber.Ctx) error {
+	return c.JSON(r.middleware.JWKS())
 }
 
 // helloWorld provides a simple hello world endpoint to test the API
@@ -217,7 +579,7 @@ func (r *Router) helloWorld(c fiber.Ctx) error {
 		"success": true,
 		"message": "Hello, " + name + "!",
 		"api":     "Parallax Workplace Management API",
-		"version": "1.0.0",
+		"version": version.Version,
 		"middleware": fiber.Map{
 			"cors":        "enabled",
 			"csrf":       "ready",
@@ -227,32 +589,98 @@ func (r *Router) helloWorld(c fiber.Ctx) error {
 	})
 }
 
-// logout handles user logout
-func (r *Router) logout(c fiber.Ctx) error {
-	if err := r.middleware.Logout(c); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    fiber.StatusInternalServerError,
-				"message": "Failed to logout",
-			},
+// startAccountCleanup periodically removes expired account tokens as a background task
+func (r *Router) startAccountCleanup(accountUseCase *usecases.AccountUseCase) {
+	err := r.background.Every("account_token_cleanup", r.config.Account.TokenCleanupInterval, func(ctx context.Context) {
+		removed, err := accountUseCase.CleanupExpiredTokens(ctx)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to remove expired account tokens", "error", err)
+			return
+		}
+		if removed > 0 {
+			logger.InfoContext(ctx, "Removed expired account tokens", "count", removed)
+		}
+	})
+	if err != nil {
+		logger.Error("Failed to start account token cleanup", "error", err)
+	}
+}
+
+// bootstrapAdmin creates the configured platform admin account so that a fresh deployment has
+// someone who can sign in
+func (r *Router) bootstrapAdmin(authUseCase *usecases.AuthUseCase) {
+	email := r.config.Bootstrap.AdminEmail
+	password := r.config.Bootstrap.AdminPassword
+	if email == "" || password == "" {
+		return
+	}
+
+	if _, err := authUseCase.EnsureAdmin(context.Background(), email, password); err != nil {
+		logger.Error("Failed to create bootstrap admin", "email", email, "error", err)
+		return
+	}
+	logger.Info("Bootstrap admin is ready", "email", email)
+}
+
+// newMailer creates the configured mailer: smtp, memory, or log (the default)
+func newMailer(cfg config.Mail) mailer.Mailer {
+	switch cfg.Driver {
+	case "smtp":
+		return mailer.NewSMTPMailer(mailer.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.From,
 		})
+	case "memory":
+		return mailer.NewMemoryMailer()
+	default:
+		return mailer.NewLogMailer()
+	}
+}
+
+// accountConfig maps the account settings to the account email configuration
+func accountConfig(cfg config.Account) usecases.AccountConfig {
+	return usecases.AccountConfig{
+		BaseURL:              cfg.BaseURL,
+		PasswordResetTTL:     cfg.PasswordResetTTL,
+		EmailVerificationTTL: cfg.EmailVerificationTTL,
+	}
+}
+
+// invitationConfig maps the account settings to the tenant invitation configuration
+func invitationConfig(cfg config.Account) usecases.InvitationConfig {
+	return usecases.InvitationConfig{
+		BaseURL: cfg.BaseURL,
+		TTL:     cfg.InvitationTTL,
 	}
-	
-	return c.JSON(fiber.Map{
-		"success": true,
-		"message": "Logged out successfully",
-	})
 }
 
 // getCSRFToken returns the CSRF token for the client
 func (r *Router) getCSRFToken(c fiber.Ctx) error {
-	token := r.middleware.GetCSRFToken(c)
+	data := fiber.Map{
+		"csrf_token": r.middleware.GetCSRFToken(c),
+	}
+
+	// A per-form token only works for the form's method and path
+	if method, path := c.Query("method"), c.Query("path"); method != "" && path != "" {
+		formToken, err := r.middleware.GetCSRFFormToken(c, method, path)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    fiber.StatusBadRequest,
+					"message": "No session to issue a form token for",
+				},
+			})
+		}
+		data["form_token"] = formToken
+	}
+
 	return c.JSON(fiber.Map{
 		"success": true,
-		"data": fiber.Map{
-			"csrf_token": token,
-		},
+		"data":    data,
 	})
 }
 
@@ -308,6 +736,39 @@ func (r *Router) healthCheck(c fiber.Ctx) error {
 	return c.JSON(fiber.Map{
 		"status":  "ok",
 		"service": "parallax-workplace-management-api",
-		"version": "1.0.0",
+		"version": version.Version,
+	})
+}
+
+// liveness reports that the process is serving requests; it passes while starting up and
+// shutting down, so the process isn't restarted while it drains
+func (r *Router) liveness(c fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.JSON(fiber.Map{
+		"status": health.StatusOK,
+	})
+}
+
+// readiness reports whether the service should receive traffic, with each check's status
+// and latency; it fails while starting up, shutting down, or when any check fails. The probe
+// is public, so check errors are only logged.
+func (r *Router) readiness(c fiber.Ctx) error {
+	report := r.health.Check(c.RequestCtx())
+	for name, result := range report.Checks {
+		if result.Status != health.StatusOK {
+			logger.WarnContext(c.RequestCtx(), "Readiness check failed", "check", name, "status", result.Status, "error", result.Error)
+		}
+	}
+
+	status := fiber.StatusOK
+	if !report.Ready() {
+		status = fiber.StatusServiceUnavailable
+	}
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(status).JSON(fiber.Map{
+		"status":  report.Status,
+		"state":   report.State,
+		"checks":  report.Checks,
+		"version": version.Get(),
 	})
 }
\ No newline at end of file
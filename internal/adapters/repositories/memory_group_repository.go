@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/cloudparallax/parallax/internal/domain/repositories"
+	"github.com/google/uuid"
+)
+
+// MemoryGroupRepository implements GroupRepository using in-memory storage
+type MemoryGroupRepository struct {
+	groups map[uuid.UUID]*entities.Group
+	mutex  sync.RWMutex
+}
+
+// NewMemoryGroupRepository creates a new memory-based group repository
+func NewMemoryGroupRepository() repositories.GroupRepository {
+	return &MemoryGroupRepository{
+		groups: make(map[uuid.UUID]*entities.Group),
+	}
+}
+
+// Create stores a new group
+func (r *MemoryGroupRepository) Create(ctx context.Context, group *entities.Group) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// Check if display name is already used in this tenant
+	for _, g := range r.groups {
+		if g.TenantID == group.TenantID && strings.EqualFold(g.DisplayName, group.DisplayName) {
+			return errors.New("group with this display name already exists")
+		}
+	}
+
+	r.groups[group.ID] = group
+	return nil
+}
+
+// GetByID retrieves a group by ID
+func (r *MemoryGroupRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.Group, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	group, exists := r.groups[id]
+	if !exists {
+		return nil, errors.New("group not found")
+	}
+
+	return group, nil
+}
+
+// GetByTenantID retrieves all of a tenant's groups ordered by creation
+func (r *MemoryGroupRepository) GetByTenantID(ctx context.Context, tenantID uuid.UUID) ([]*entities.Group, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var groups []*entities.Group
+	for _, group := range r.groups {
+		if group.TenantID == tenantID {
+			groups = append(groups, group)
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].CreatedAt.Before(groups[j].CreatedAt)
+	})
+
+	return groups, nil
+}
+
+// GetByMember retrieves the tenant's groups a user belongs to
+func (r *MemoryGroupRepository) GetByMember(ctx context.Context, tenantID, userID uuid.UUID) ([]*entities.Group, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var groups []*entities.Group
+	for _, group := range r.groups {
+		if group.TenantID == tenantID && group.HasMember(userID) {
+			groups = append(groups, group)
+		}
+	}
+
+	return groups, nil
+}
+
+// Update updates an existing group
+func (r *MemoryGroupRepository) Update(ctx context.Context, group *entities.Group) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.groups[group.ID]; !exists {
+		return errors.New("group not found")
+	}
+
+	for _, g := range r.groups {
+		if g.ID != group.ID && g.TenantID == group.TenantID && strings.EqualFold(g.DisplayName, group.DisplayName) {
+			return errors.New("group with this display name already exists")
+		}
+	}
+
+	r.groups[group.ID] = group
+	return nil
+}
+
+// Delete removes a group
+func (r *MemoryGroupRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.groups[id]; !exists {
+		return errors.New("group not found")
+	}
+
+	delete(r.groups, id)
+	return nil
+}
@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/cloudparallax/parallax/internal/domain/repositories"
+	"github.com/google/uuid"
+)
+
+// MemoryInvitationRepository implements InvitationRepository using in-memory storage
+type MemoryInvitationRepository struct {
+	invitations map[uuid.UUID]*entities.Invitation
+	mutex       sync.RWMutex
+}
+
+// NewMemoryInvitationRepository creates a new memory-based invitation repository
+func NewMemoryInvitationRepository() repositories.InvitationRepository {
+	return &MemoryInvitationRepository{
+		invitations: make(map[uuid.UUID]*entities.Invitation),
+	}
+}
+
+// Create stores a new invitation
+func (r *MemoryInvitationRepository) Create(ctx context.Context, invitation *entities.Invitation) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.invitations[invitation.ID] = invitation
+	return nil
+}
+
+// GetByID retrieves an invitation by ID
+func (r *MemoryInvitationRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.Invitation, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	invitation, exists := r.invitations[id]
+	if !exists {
+		return nil, errors.New("invitation not found")
+	}
+
+	return invitation, nil
+}
+
+// GetByTokenHash retrieves an invitation by the hash of its token
+func (r *MemoryInvitationRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*entities.Invitation, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, invitation := range r.invitations {
+		if invitation.TokenHash == tokenHash {
+			return invitation, nil
+		}
+	}
+
+	return nil, errors.New("invitation not found")
+}
+
+// GetByTenantID retrieves a tenant's invitations, newest first, with pagination
+func (r *MemoryInvitationRepository) GetByTenantID(ctx context.Context, tenantID uuid.UUID, limit, offset int) ([]*entities.Invitation, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var invitations []*entities.Invitation
+	for _, invitation := range r.invitations {
+		if invitation.TenantID == tenantID {
+			invitations = append(invitations, invitation)
+		}
+	}
+
+	sort.Slice(invitations, func(i, j int) bool {
+		return invitations[i].CreatedAt.After(invitations[j].CreatedAt)
+	})
+
+	if offset >= len(invitations) {
+		return []*entities.Invitation{}, nil
+	}
+	invitations = invitations[offset:]
+	if len(invitations) > limit {
+		invitations = invitations[:limit]
+	}
+
+	return invitations, nil
+}
+
+// GetPendingByTenantAndEmail retrieves pending invitations for an email address in a tenant
+func (r *MemoryInvitationRepository) GetPendingByTenantAndEmail(ctx context.Context, tenantID uuid.UUID, email string) ([]*entities.Invitation, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var invitations []*entities.Invitation
+	for _, invitation := range r.invitations {
+		if invitation.TenantID == tenantID && strings.EqualFold(invitation.Email, email) && invitation.IsPending() {
+			invitations = append(invitations, invitation)
+		}
+	}
+
+	return invitations, nil
+}
+
+// Update updates an existing invitation
+func (r *MemoryInvitationRepository) Update(ctx context.Context, invitation *entities.Invitation) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.invitations[invitation.ID]; !exists {
+		return errors.New("invitation not found")
+	}
+
+	r.invitations[invitation.ID] = invitation
+	return nil
+}
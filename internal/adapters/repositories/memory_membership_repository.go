@@ -0,0 +1,152 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/cloudparallax/parallax/internal/domain/repositories"
+	"github.com/google/uuid"
+)
+
+// MemoryMembershipRepository implements MembershipRepository using in-memory storage
+type MemoryMembershipRepository struct {
+	memberships map[uuid.UUID]*entities.Membership
+	mutex       sync.RWMutex
+}
+
+// NewMemoryMembershipRepository creates a new memory-based membership repository
+func NewMemoryMembershipRepository() repositories.MembershipRepository {
+	return &MemoryMembershipRepository{
+		memberships: make(map[uuid.UUID]*entities.Membership),
+	}
+}
+
+// Create stores a new membership
+func (r *MemoryMembershipRepository) Create(ctx context.Context, membership *entities.Membership) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// Check if user is already a member of this tenant
+	for _, m := range r.memberships {
+		if m.TenantID == membership.TenantID && m.UserID == membership.UserID {
+			return errors.New("user is already a member of this tenant")
+		}
+	}
+
+	r.memberships[membership.ID] = membership
+	return nil
+}
+
+// GetByTenantAndUser retrieves a user's membership in a tenant
+func (r *MemoryMembershipRepository) GetByTenantAndUser(ctx context.Context, tenantID, userID uuid.UUID) (*entities.Membership, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, membership := range r.memberships {
+		if membership.TenantID == tenantID && membership.UserID == userID {
+			return membership, nil
+		}
+	}
+
+	return nil, errors.New("membership not found")
+}
+
+// GetByTenantID retrieves memberships by tenant ID with pagination
+func (r *MemoryMembershipRepository) GetByTenantID(ctx context.Context, tenantID uuid.UUID, limit, offset int) ([]*entities.Membership, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var memberships []*entities.Membership
+	count := 0
+
+	for _, membership := range r.memberships {
+		if membership.TenantID == tenantID {
+			if count < offset {
+				count++
+				continue
+			}
+
+			if len(memberships) >= limit {
+				break
+			}
+
+			memberships = append(memberships, membership)
+			count++
+		}
+	}
+
+	return memberships, nil
+}
+
+// GetByUserID retrieves all memberships of a user
+func (r *MemoryMembershipRepository) GetByUserID(ctx context.Context, userID uuid.UUID) ([]*entities.Membership, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var memberships []*entities.Membership
+	for _, membership := range r.memberships {
+		if membership.UserID == userID {
+			memberships = append(memberships, membership)
+		}
+	}
+
+	return memberships, nil
+}
+
+// Update updates an existing membership
+func (r *MemoryMembershipRepository) Update(ctx context.Context, membership *entities.Membership) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.memberships[membership.ID]; !exists {
+		return errors.New("membership not found")
+	}
+
+	r.memberships[membership.ID] = membership
+	return nil
+}
+
+// Delete removes a membership
+func (r *MemoryMembershipRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.memberships[id]; !exists {
+		return errors.New("membership not found")
+	}
+
+	delete(r.memberships, id)
+	return nil
+}
+
+// CountByTenantID returns the count of members in a tenant
+func (r *MemoryMembershipRepository) CountByTenantID(ctx context.Context, tenantID uuid.UUID) (int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	count := 0
+	for _, membership := range r.memberships {
+		if membership.TenantID == tenantID {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// CountByTenantAndRole returns the count of members holding a role in a tenant
+func (r *MemoryMembershipRepository) CountByTenantAndRole(ctx context.Context, tenantID uuid.UUID, role string) (int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	count := 0
+	for _, membership := range r.memberships {
+		if membership.TenantID == tenantID && membership.Role == role {
+			count++
+		}
+	}
+
+	return count, nil
+}
@@ -0,0 +1,59 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/cloudparallax/parallax/internal/domain/repositories"
+	"github.com/google/uuid"
+)
+
+// MemoryOIDCConfigRepository implements OIDCConfigRepository using in-memory storage
+type MemoryOIDCConfigRepository struct {
+	configs map[uuid.UUID]*entities.OIDCConfig
+	mutex   sync.RWMutex
+}
+
+// NewMemoryOIDCConfigRepository creates a new memory-based OIDC configuration repository
+func NewMemoryOIDCConfigRepository() repositories.OIDCConfigRepository {
+	return &MemoryOIDCConfigRepository{
+		configs: make(map[uuid.UUID]*entities.OIDCConfig),
+	}
+}
+
+// Save creates or replaces a tenant's OIDC configuration
+func (r *MemoryOIDCConfigRepository) Save(ctx context.Context, config *entities.OIDCConfig) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.configs[config.TenantID] = config
+	return nil
+}
+
+// GetByTenantID retrieves a tenant's OIDC configuration
+func (r *MemoryOIDCConfigRepository) GetByTenantID(ctx context.Context, tenantID uuid.UUID) (*entities.OIDCConfig, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	config, exists := r.configs[tenantID]
+	if !exists {
+		return nil, errors.New("oidc configuration not found")
+	}
+
+	return config, nil
+}
+
+// Delete removes a tenant's OIDC configuration
+func (r *MemoryOIDCConfigRepository) Delete(ctx context.Context, tenantID uuid.UUID) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.configs[tenantID]; !exists {
+		return errors.New("oidc configuration not found")
+	}
+
+	delete(r.configs, tenantID)
+	return nil
+}
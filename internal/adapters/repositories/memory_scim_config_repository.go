@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/cloudparallax/parallax/internal/domain/repositories"
+	"github.com/google/uuid"
+)
+
+// MemorySCIMConfigRepository implements SCIMConfigRepository using in-memory storage
+type MemorySCIMConfigRepository struct {
+	configs map[uuid.UUID]*entities.SCIMConfig
+	mutex   sync.RWMutex
+}
+
+// NewMemorySCIMConfigRepository creates a new memory-based SCIM configuration repository
+func NewMemorySCIMConfigRepository() repositories.SCIMConfigRepository {
+	return &MemorySCIMConfigRepository{
+		configs: make(map[uuid.UUID]*entities.SCIMConfig),
+	}
+}
+
+// Save creates or replaces a tenant's SCIM configuration
+func (r *MemorySCIMConfigRepository) Save(ctx context.Context, config *entities.SCIMConfig) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.configs[config.TenantID] = config
+	return nil
+}
+
+// GetByTenantID retrieves a tenant's SCIM configuration
+func (r *MemorySCIMConfigRepository) GetByTenantID(ctx context.Context, tenantID uuid.UUID) (*entities.SCIMConfig, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	config, exists := r.configs[tenantID]
+	if !exists {
+		return nil, errors.New("scim configuration not found")
+	}
+
+	return config, nil
+}
+
+// GetByTokenHash retrieves the SCIM configuration a bearer token belongs to
+func (r *MemorySCIMConfigRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*entities.SCIMConfig, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, config := range r.configs {
+		if config.TokenHash == tokenHash {
+			return config, nil
+		}
+	}
+
+	return nil, errors.New("scim configuration not found")
+}
+
+// Delete removes a tenant's SCIM configuration
+func (r *MemorySCIMConfigRepository) Delete(ctx context.Context, tenantID uuid.UUID) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.configs[tenantID]; !exists {
+		return errors.New("scim configuration not found")
+	}
+
+	delete(r.configs, tenantID)
+	return nil
+}
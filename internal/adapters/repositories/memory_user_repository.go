@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/cloudparallax/parallax/internal/domain/repositories"
+	"github.com/google/uuid"
+)
+
+// MemoryUserRepository implements UserRepository using in-memory storage
+type MemoryUserRepository struct {
+	users map[uuid.UUID]*entities.User
+	mutex sync.RWMutex
+}
+
+// NewMemoryUserRepository creates a new memory-based user repository
+func NewMemoryUserRepository() repositories.UserRepository {
+	return &MemoryUserRepository{
+		users: make(map[uuid.UUID]*entities.User),
+	}
+}
+
+// Create stores a new user
+func (r *MemoryUserRepository) Create(ctx context.Context, user *entities.User) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	// Check if email already exists
+	for _, u := range r.users {
+		if strings.EqualFold(u.Email, user.Email) {
+			return errors.New("user with this email already exists")
+		}
+	}
+
+	r.users[user.ID] = user
+	return nil
+}
+
+// GetByID retrieves a user by ID
+func (r *MemoryUserRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.User, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	user, exists := r.users[id]
+	if !exists {
+		return nil, errors.New("user not found")
+	}
+
+	return user, nil
+}
+
+// GetByEmail retrieves a user by email
+func (r *MemoryUserRepository) GetByEmail(ctx context.Context, email string) (*entities.User, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) {
+			return user, nil
+		}
+	}
+
+	return nil, errors.New("user not found")
+}
+
+// GetByExternalID retrieves a user by their identity at an external provider
+func (r *MemoryUserRepository) GetByExternalID(ctx context.Context, tenantID uuid.UUID, provider, externalID string) (*entities.User, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, user := range r.users {
+		if user.TenantID == tenantID && user.AuthProvider == provider && user.ExternalID == externalID {
+			return user, nil
+		}
+	}
+
+	return nil, errors.New("user not found")
+}
+
+// GetByTenantID retrieves users by tenant ID with pagination
+func (r *MemoryUserRepository) GetByTenantID(ctx context.Context, tenantID uuid.UUID, limit, offset int) ([]*entities.User, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var users []*entities.User
+	count := 0
+
+	for _, user := range r.users {
+		if user.TenantID == tenantID {
+			if count < offset {
+				count++
+				continue
+			}
+
+			if len(users) >= limit {
+				break
+			}
+
+			users = append(users, user)
+			count++
+		}
+	}
+
+	return users, nil
+}
+
+// Update updates an existing user
+func (r *MemoryUserRepository) Update(ctx context.Context, user *entities.User) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.users[user.ID]; !exists {
+		return errors.New("user not found")
+	}
+
+	// Check if another user already has this email
+	for _, u := range r.users {
+		if u.ID != user.ID && strings.EqualFold(u.Email, user.Email) {
+			return errors.New("user with this email already exists")
+		}
+	}
+
+	r.users[user.ID] = user
+	return nil
+}
+
+// Delete removes a user
+func (r *MemoryUserRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.users[id]; !exists {
+		return errors.New("user not found")
+	}
+
+	delete(r.users, id)
+	return nil
+}
+
+// CountByTenantID returns the count of users for a tenant
+func (r *MemoryUserRepository) CountByTenantID(ctx context.Context, tenantID uuid.UUID) (int, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	count := 0
+	for _, user := range r.users {
+		if user.TenantID == tenantID {
+			count++
+		}
+	}
+
+	return count, nil
+}
@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/cloudparallax/parallax/internal/domain/repositories"
+	"github.com/google/uuid"
+)
+
+// MemoryUserTokenRepository implements UserTokenRepository using in-memory storage
+type MemoryUserTokenRepository struct {
+	tokens map[uuid.UUID]*entities.UserToken
+	mutex  sync.RWMutex
+}
+
+// NewMemoryUserTokenRepository creates a new memory-based user token repository
+func NewMemoryUserTokenRepository() repositories.UserTokenRepository {
+	return &MemoryUserTokenRepository{
+		tokens: make(map[uuid.UUID]*entities.UserToken),
+	}
+}
+
+// Create stores a new token
+func (r *MemoryUserTokenRepository) Create(ctx context.Context, token *entities.UserToken) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.tokens[token.ID] = token
+	return nil
+}
+
+// GetByHash retrieves a token by purpose and hash
+func (r *MemoryUserTokenRepository) GetByHash(ctx context.Context, purpose, tokenHash string) (*entities.UserToken, error) {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	for _, token := range r.tokens {
+		if token.Purpose == purpose && token.TokenHash == tokenHash {
+			return token, nil
+		}
+	}
+
+	return nil, errors.New("token not found")
+}
+
+// Update updates an existing token
+func (r *MemoryUserTokenRepository) Update(ctx context.Context, token *entities.UserToken) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if _, exists := r.tokens[token.ID]; !exists {
+		return errors.New("token not found")
+	}
+
+	r.tokens[token.ID] = token
+	return nil
+}
+
+// DeleteByUser removes all of a user's tokens for a purpose
+func (r *MemoryUserTokenRepository) DeleteByUser(ctx context.Context, userID uuid.UUID, purpose string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	for id, token := range r.tokens {
+		if token.UserID == userID && token.Purpose == purpose {
+			delete(r.tokens, id)
+		}
+	}
+
+	return nil
+}
+
+// DeleteExpired removes expired tokens and returns how many were removed
+func (r *MemoryUserTokenRepository) DeleteExpired(ctx context.Context) (int, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := time.Now()
+	count := 0
+	for id, token := range r.tokens {
+		if now.After(token.ExpiresAt) {
+			delete(r.tokens, id)
+			count++
+		}
+	}
+
+	return count, nil
+}
@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/cloudparallax/parallax/internal/domain/repositories"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracedCustomerRepository records a span for each call to a CustomerRepository
+type TracedCustomerRepository struct {
+	next repositories.CustomerRepository
+}
+
+// NewTracedCustomerRepository wraps a customer repository with tracing
+func NewTracedCustomerRepository(next repositories.CustomerRepository) repositories.CustomerRepository {
+	return &TracedCustomerRepository{next: next}
+}
+
+// Create stores a new customer
+func (r *TracedCustomerRepository) Create(ctx context.Context, customer *entities.Customer) (err error) {
+	ctx, span := startSpan(ctx, "CustomerRepository.Create")
+	defer endSpan(span, &err)
+
+	return r.next.Create(ctx, customer)
+}
+
+// GetByID retrieves a customer by ID
+func (r *TracedCustomerRepository) GetByID(ctx context.Context, id uuid.UUID) (_ *entities.Customer, err error) {
+	ctx, span := startSpan(ctx, "CustomerRepository.GetByID", trace.WithAttributes(attribute.String("customer.id", id.String())))
+	defer endSpan(span, &err)
+
+	return r.next.GetByID(ctx, id)
+}
+
+// GetByTenantID retrieves customers by tenant ID with pagination
+func (r *TracedCustomerRepository) GetByTenantID(ctx context.Context, tenantID uuid.UUID, limit, offset int) (_ []*entities.Customer, err error) {
+	ctx, span := startSpan(ctx, "CustomerRepository.GetByTenantID", trace.WithAttributes(attribute.String("tenant.id", tenantID.String())))
+	defer endSpan(span, &err)
+
+	return r.next.GetByTenantID(ctx, tenantID, limit, offset)
+}
+
+// GetByEmail retrieves a customer by email within a tenant
+func (r *TracedCustomerRepository) GetByEmail(ctx context.Context, tenantID uuid.UUID, email string) (_ *entities.Customer, err error) {
+	ctx, span := startSpan(ctx, "CustomerRepository.GetByEmail", trace.WithAttributes(attribute.String("tenant.id", tenantID.String())))
+	defer endSpan(span, &err)
+
+	return r.next.GetByEmail(ctx, tenantID, email)
+}
+
+// SearchByName searches customers by name within a tenant
+func (r *TracedCustomerRepository) SearchByName(ctx context.Context, tenantID uuid.UUID, query string, limit, offset int) (_ []*entities.Customer, err error) {
+	ctx, span := startSpan(ctx, "CustomerRepository.SearchByName", trace.WithAttributes(attribute.String("tenant.id", tenantID.String())))
+	defer endSpan(span, &err)
+
+	return r.next.SearchByName(ctx, tenantID, query, limit, offset)
+}
+
+// GetByTags retrieves customers by tags within a tenant
+func (r *TracedCustomerRepository) GetByTags(ctx context.Context, tenantID uuid.UUID, tags []string, limit, offset int) (_ []*entities.Customer, err error) {
+	ctx, span := startSpan(ctx, "CustomerRepository.GetByTags", trace.WithAttributes(attribute.String("tenant.id", tenantID.String())))
+	defer endSpan(span, &err)
+
+	return r.next.GetByTags(ctx, tenantID, tags, limit, offset)
+}
+
+// Update updates an existing customer
+func (r *TracedCustomerRepository) Update(ctx context.Context, customer *entities.Customer) (err error) {
+	ctx, span := startSpan(ctx, "CustomerRepository.Update")
+	defer endSpan(span, &err)
+
+	return r.next.Update(ctx, customer)
+}
+
+// Delete removes a customer
+func (r *TracedCustomerRepository) Delete(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := startSpan(ctx, "CustomerRepository.Delete", trace.WithAttributes(attribute.String("customer.id", id.String())))
+	defer endSpan(span, &err)
+
+	return r.next.Delete(ctx, id)
+}
+
+// CountByTenantID returns the count of customers for a tenant
+func (r *TracedCustomerRepository) CountByTenantID(ctx context.Context, tenantID uuid.UUID) (_ int, err error) {
+	ctx, span := startSpan(ctx, "CustomerRepository.CountByTenantID", trace.WithAttributes(attribute.String("tenant.id", tenantID.String())))
+	defer endSpan(span, &err)
+
+	return r.next.CountByTenantID(ctx, tenantID)
+}
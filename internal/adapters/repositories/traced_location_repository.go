@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/cloudparallax/parallax/internal/domain/repositories"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracedLocationRepository records a span for each call to a LocationRepository
+type TracedLocationRepository struct {
+	next repositories.LocationRepository
+}
+
+// NewTracedLocationRepository wraps a location repository with tracing
+func NewTracedLocationRepository(next repositories.LocationRepository) repositories.LocationRepository {
+	return &TracedLocationRepository{next: next}
+}
+
+// Create stores a new location
+func (r *TracedLocationRepository) Create(ctx context.Context, location *entities.Location) (err error) {
+	ctx, span := startSpan(ctx, "LocationRepository.Create")
+	defer endSpan(span, &err)
+
+	return r.next.Create(ctx, location)
+}
+
+// GetByID retrieves a location by ID
+func (r *TracedLocationRepository) GetByID(ctx context.Context, id uuid.UUID) (_ *entities.Location, err error) {
+	ctx, span := startSpan(ctx, "LocationRepository.GetByID", trace.WithAttributes(attribute.String("location.id", id.String())))
+	defer endSpan(span, &err)
+
+	return r.next.GetByID(ctx, id)
+}
+
+// GetByTenantID retrieves locations by tenant ID with pagination
+func (r *TracedLocationRepository) GetByTenantID(ctx context.Context, tenantID uuid.UUID, limit, offset int) (_ []*entities.Location, err error) {
+	ctx, span := startSpan(ctx, "LocationRepository.GetByTenantID", trace.WithAttributes(attribute.String("tenant.id", tenantID.String())))
+	defer endSpan(span, &err)
+
+	return r.next.GetByTenantID(ctx, tenantID, limit, offset)
+}
+
+// GetActivByTenantID retrieves active locations by tenant ID
+func (r *TracedLocationRepository) GetActivByTenantID(ctx context.Context, tenantID uuid.UUID) (_ []*entities.Location, err error) {
+	ctx, span := startSpan(ctx, "LocationRepository.GetActivByTenantID", trace.WithAttributes(attribute.String("tenant.id", tenantID.String())))
+	defer endSpan(span, &err)
+
+	return r.next.GetActivByTenantID(ctx, tenantID)
+}
+
+// Update updates an existing location
+func (r *TracedLocationRepository) Update(ctx context.Context, location *entities.Location) (err error) {
+	ctx, span := startSpan(ctx, "LocationRepository.Update")
+	defer endSpan(span, &err)
+
+	return r.next.Update(ctx, location)
+}
+
+// Delete removes a location
+func (r *TracedLocationRepository) Delete(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := startSpan(ctx, "LocationRepository.Delete", trace.WithAttributes(attribute.String("location.id", id.String())))
+	defer endSpan(span, &err)
+
+	return r.next.Delete(ctx, id)
+}
+
+// CountByTenantID returns the count of locations for a tenant
+func (r *TracedLocationRepository) CountByTenantID(ctx context.Context, tenantID uuid.UUID) (_ int, err error) {
+	ctx, span := startSpan(ctx, "LocationRepository.CountByTenantID", trace.WithAttributes(attribute.String("tenant.id", tenantID.String())))
+	defer endSpan(span, &err)
+
+	return r.next.CountByTenantID(ctx, tenantID)
+}
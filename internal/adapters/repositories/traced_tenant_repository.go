@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/cloudparallax/parallax/internal/domain/repositories"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracedTenantRepository records a span for each call to a TenantRepository
+type TracedTenantRepository struct {
+	next repositories.TenantRepository
+}
+
+// NewTracedTenantRepository wraps a tenant repository with tracing
+func NewTracedTenantRepository(next repositories.TenantRepository) repositories.TenantRepository {
+	return &TracedTenantRepository{next: next}
+}
+
+// Create stores a new tenant
+func (r *TracedTenantRepository) Create(ctx context.Context, tenant *entities.Tenant) (err error) {
+	ctx, span := startSpan(ctx, "TenantRepository.Create")
+	defer endSpan(span, &err)
+
+	return r.next.Create(ctx, tenant)
+}
+
+// GetByID retrieves a tenant by ID
+func (r *TracedTenantRepository) GetByID(ctx context.Context, id uuid.UUID) (_ *entities.Tenant, err error) {
+	ctx, span := startSpan(ctx, "TenantRepository.GetByID", trace.WithAttributes(attribute.String("tenant.id", id.String())))
+	defer endSpan(span, &err)
+
+	return r.next.GetByID(ctx, id)
+}
+
+// GetByDomain retrieves a tenant by domain
+func (r *TracedTenantRepository) GetByDomain(ctx context.Context, domain string) (_ *entities.Tenant, err error) {
+	ctx, span := startSpan(ctx, "TenantRepository.GetByDomain")
+	defer endSpan(span, &err)
+
+	return r.next.GetByDomain(ctx, domain)
+}
+
+// GetAll retrieves all tenants with pagination
+func (r *TracedTenantRepository) GetAll(ctx context.Context, limit, offset int) (_ []*entities.Tenant, err error) {
+	ctx, span := startSpan(ctx, "TenantRepository.GetAll")
+	defer endSpan(span, &err)
+
+	return r.next.GetAll(ctx, limit, offset)
+}
+
+// Update updates an existing tenant
+func (r *TracedTenantRepository) Update(ctx context.Context, tenant *entities.Tenant) (err error) {
+	ctx, span := startSpan(ctx, "TenantRepository.Update")
+	defer endSpan(span, &err)
+
+	return r.next.Update(ctx, tenant)
+}
+
+// Delete removes a tenant
+func (r *TracedTenantRepository) Delete(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := startSpan(ctx, "TenantRepository.Delete", trace.WithAttributes(attribute.String("tenant.id", id.String())))
+	defer endSpan(span, &err)
+
+	return r.next.Delete(ctx, id)
+}
+
+// GetActiveCount returns the count of active tenants
+func (r *TracedTenantRepository) GetActiveCount(ctx context.Context) (_ int, err error) {
+	ctx, span := startSpan(ctx, "TenantRepository.GetActiveCount")
+	defer endSpan(span, &err)
+
+	return r.next.GetActiveCount(ctx)
+}
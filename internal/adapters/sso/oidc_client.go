@@ -0,0 +1,219 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/cloudparallax/parallax/internal/usecases"
+	"github.com/cloudparallax/parallax/pkg/tracing"
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"golang.org/x/oauth2"
+)
+
+// authRequestTTL is how long a user has to complete a login at the identity provider
+const authRequestTTL = 10 * time.Minute
+
+var (
+	// ErrUnknownState is returned when a callback's state does not match a pending login
+	ErrUnknownState = errors.New("unknown or expired login state")
+)
+
+// authRequest holds the values needed to complete a pending authorization-code login
+type authRequest struct {
+	tenantID  uuid.UUID
+	nonce     string
+	verifier  string
+	returnTo  string
+	expiresAt time.Time
+}
+
+// OIDCClient runs the OpenID Connect authorization-code flow with PKCE against tenant identity providers
+type OIDCClient struct {
+	httpClient *http.Client
+	providers  map[string]*oidc.Provider
+	pending    map[string]*authRequest
+	mutex      sync.Mutex
+}
+
+// NewOIDCClient creates a new OIDC client.
+// A custom HTTP client can be supplied to talk to a mock identity provider in tests.
+func NewOIDCClient(httpClient ...*http.Client) *OIDCClient {
+	client := &http.Client{Timeout: 10 * time.Second, Transport: otelhttp.NewTransport(http.DefaultTransport)}
+	if len(httpClient) > 0 && httpClient[0] != nil {
+		client = httpClient[0]
+	}
+
+	return &OIDCClient{
+		httpClient: client,
+		providers:  make(map[string]*oidc.Provider),
+		pending:    make(map[string]*authRequest),
+	}
+}
+
+// AuthCodeURL starts a login and returns the identity provider URL to redirect the user to,
+// along with the state value the callback must present
+func (c *OIDCClient) AuthCodeURL(ctx context.Context, config *entities.OIDCConfig, redirectURL, returnTo string) (string, string, error) {
+	provider, err := c.provider(config.Issuer)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := randomString()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := randomString()
+	if err != nil {
+		return "", "", err
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	// Drop logins that were abandoned at the identity provider
+	c.CleanupExpiredRequests()
+
+	c.mutex.Lock()
+	c.pending[state] = &authRequest{
+		tenantID:  config.TenantID,
+		nonce:     nonce,
+		verifier:  verifier,
+		returnTo:  returnTo,
+		expiresAt: time.Now().Add(authRequestTTL),
+	}
+	c.mutex.Unlock()
+
+	oauthConfig := c.oauthConfig(provider, config, redirectURL)
+	url := oauthConfig.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier))
+
+	return url, state, nil
+}
+
+// Exchange completes a login by redeeming the authorization code and verifying the ID token.
+// It returns the asserted identity and the return path recorded when the login started.
+func (c *OIDCClient) Exchange(ctx context.Context, config *entities.OIDCConfig, redirectURL, state, code string) (*usecases.OIDCIdentity, string, error) {
+	c.mutex.Lock()
+	request, exists := c.pending[state]
+	delete(c.pending, state)
+	c.mutex.Unlock()
+
+	if !exists || time.Now().After(request.expiresAt) || request.tenantID != config.TenantID {
+		return nil, "", ErrUnknownState
+	}
+
+	provider, err := c.provider(config.Issuer)
+	if err != nil {
+		return nil, "", err
+	}
+
+	ctx = oidc.ClientContext(tracing.Context(ctx), c.httpClient)
+	oauthConfig := c.oauthConfig(provider, config, redirectURL)
+
+	token, err := oauthConfig.Exchange(ctx, code, oauth2.VerifierOption(request.verifier))
+	if err != nil {
+		return nil, "", err
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		return nil, "", errors.New("token response did not contain an id_token")
+	}
+
+	idToken, err := provider.Verifier(&oidc.Config{ClientID: config.ClientID}).Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if idToken.Nonce != request.nonce {
+		return nil, "", errors.New("id_token nonce mismatch")
+	}
+
+	var standard struct {
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		GivenName     string `json:"given_name"`
+		FamilyName    string `json:"family_name"`
+	}
+	if err := idToken.Claims(&standard); err != nil {
+		return nil, "", err
+	}
+
+	claims := map[string]interface{}{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, "", err
+	}
+
+	return &usecases.OIDCIdentity{
+		Subject:       idToken.Subject,
+		Email:         standard.Email,
+		EmailVerified: standard.EmailVerified,
+		GivenName:     standard.GivenName,
+		FamilyName:    standard.FamilyName,
+		Claims:        claims,
+	}, request.returnTo, nil
+}
+
+// CleanupExpiredRequests removes abandoned logins
+func (c *OIDCClient) CleanupExpiredRequests() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	now := time.Now()
+	for state, request := range c.pending {
+		if now.After(request.expiresAt) {
+			delete(c.pending, state)
+		}
+	}
+}
+
+// provider returns the discovered provider for an issuer, fetching its metadata on first use.
+// Providers are cached beyond the request, so discovery is not bound to the request context.
+func (c *OIDCClient) provider(issuer string) (*oidc.Provider, error) {
+	c.mutex.Lock()
+	provider, exists := c.providers[issuer]
+	c.mutex.Unlock()
+	if exists {
+		return provider, nil
+	}
+
+	provider, err := oidc.NewProvider(oidc.ClientContext(context.Background(), c.httpClient), issuer)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.Lock()
+	c.providers[issuer] = provider
+	c.mutex.Unlock()
+
+	return provider, nil
+}
+
+// oauthConfig builds the OAuth2 client configuration for a tenant
+func (c *OIDCClient) oauthConfig(provider *oidc.Provider, config *entities.OIDCConfig, redirectURL string) *oauth2.Config {
+	if config.RedirectURL != "" {
+		redirectURL = config.RedirectURL
+	}
+
+	return &oauth2.Config{
+		ClientID:     config.ClientID,
+		ClientSecret: config.ClientSecret,
+		Endpoint:     provider.Endpoint(),
+		RedirectURL:  redirectURL,
+		Scopes:       config.Scopes,
+	}
+}
+
+// randomString creates a random URL-safe string
+func randomString() (string, error) {
+	bytes := make([]byte, 24)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
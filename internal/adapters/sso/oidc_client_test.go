@@ -0,0 +1,315 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// mockGrant is an authorization code issued by the mock identity provider
+type mockGrant struct {
+	challenge string
+	nonce     string
+}
+
+// mockIdP is a minimal OpenID Connect provider: discovery, JWKS, and a token endpoint that
+// checks the PKCE verifier and signs ID tokens carrying the nonce of the login
+type mockIdP struct {
+	server      *httptest.Server
+	key         *rsa.PrivateKey
+	discoveries int
+	grants      map[string]mockGrant
+	tokenNonce  string // Overrides the nonce put into ID tokens when set
+	mutex       sync.Mutex
+}
+
+func newMockIdP(t *testing.T) *mockIdP {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &mockIdP{key: key, grants: make(map[string]mockGrant)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("/jwks", idp.jwks)
+	mux.HandleFunc("/token", idp.token)
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+
+	return idp
+}
+
+func (idp *mockIdP) discovery(w http.ResponseWriter, r *http.Request) {
+	idp.mutex.Lock()
+	idp.discoveries++
+	idp.mutex.Unlock()
+
+	issuer := idp.server.URL
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"issuer":                                issuer,
+		"authorization_endpoint":                issuer + "/authorize",
+		"token_endpoint":                        issuer + "/token",
+		"jwks_uri":                              issuer + "/jwks",
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+	})
+}
+
+func (idp *mockIdP) jwks(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "test",
+			"alg": "RS256",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(idp.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(idp.key.E)).Bytes()),
+		}},
+	})
+}
+
+func (idp *mockIdP) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	idp.mutex.Lock()
+	grant, exists := idp.grants[r.PostForm.Get("code")]
+	delete(idp.grants, r.PostForm.Get("code"))
+	nonce := grant.nonce
+	if idp.tokenNonce != "" {
+		nonce = idp.tokenNonce
+	}
+	idp.mutex.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !exists || base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	clientID, _, _ := r.BasicAuth()
+	claims := jwt.MapClaims{
+		"iss":            idp.server.URL,
+		"sub":            "idp-user-1",
+		"aud":            clientID,
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          nonce,
+		"email":          "jane@example.com",
+		"email_verified": true,
+		"given_name":     "Jane",
+		"family_name":    "Doe",
+		"groups":         []string{"engineering"},
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = "test"
+	idToken, err := token.SignedString(idp.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+// authorize plays the user approving the login: it records the PKCE challenge and nonce of
+// the authorization URL and returns the code the callback would receive
+func (idp *mockIdP) authorize(t *testing.T, authURL string) string {
+	t.Helper()
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+	if parsed.Path != "/authorize" {
+		t.Fatalf("authorization URL path = %q, want /authorize", parsed.Path)
+	}
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		t.Fatalf("authorization URL has no S256 code challenge: %s", authURL)
+	}
+	if query.Get("nonce") == "" {
+		t.Fatalf("authorization URL has no nonce: %s", authURL)
+	}
+
+	code := uuid.NewString()
+	idp.mutex.Lock()
+	idp.grants[code] = mockGrant{challenge: query.Get("code_challenge"), nonce: query.Get("nonce")}
+	idp.mutex.Unlock()
+
+	return code
+}
+
+func newTestConfig(issuer string) *entities.OIDCConfig {
+	return entities.NewOIDCConfig(uuid.New(), issuer, "parallax", "secret")
+}
+
+func TestOIDCClientLogin(t *testing.T) {
+	idp := newMockIdP(t)
+	client := NewOIDCClient(idp.server.Client())
+	config := newTestConfig(idp.server.URL)
+	ctx := context.Background()
+
+	authURL, state, err := client.AuthCodeURL(ctx, config, "https://app.example.com/callback", "/dashboard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := mustQuery(t, authURL).Get("state"); got != state {
+		t.Fatalf("state = %q, want %q", got, state)
+	}
+	code := idp.authorize(t, authURL)
+
+	identity, returnTo, err := client.Exchange(ctx, config, "https://app.example.com/callback", state, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if identity.Subject != "idp-user-1" || identity.Email != "jane@example.com" || !identity.EmailVerified {
+		t.Fatalf("identity = %+v", identity)
+	}
+	if identity.GivenName != "Jane" || identity.FamilyName != "Doe" {
+		t.Fatalf("identity names = %q %q", identity.GivenName, identity.FamilyName)
+	}
+	if groups, ok := identity.Claims["groups"].([]interface{}); !ok || len(groups) != 1 || groups[0] != "engineering" {
+		t.Fatalf("groups claim = %#v", identity.Claims["groups"])
+	}
+	if returnTo != "/dashboard" {
+		t.Fatalf("returnTo = %q, want /dashboard", returnTo)
+	}
+
+	// The provider metadata is discovered once and cached
+	if _, _, err := client.AuthCodeURL(ctx, config, "https://app.example.com/callback", "/"); err != nil {
+		t.Fatal(err)
+	}
+	if idp.discoveries != 1 {
+		t.Fatalf("discovery requests = %d, want 1", idp.discoveries)
+	}
+}
+
+func TestOIDCClientRejectsReplayedState(t *testing.T) {
+	idp := newMockIdP(t)
+	client := NewOIDCClient(idp.server.Client())
+	config := newTestConfig(idp.server.URL)
+	ctx := context.Background()
+
+	authURL, state, err := client.AuthCodeURL(ctx, config, "https://app.example.com/callback", "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := idp.authorize(t, authURL)
+	if _, _, err := client.Exchange(ctx, config, "https://app.example.com/callback", state, code); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = client.Exchange(ctx, config, "https://app.example.com/callback", state, code)
+	if !errors.Is(err, ErrUnknownState) {
+		t.Fatalf("replayed state: err = %v, want ErrUnknownState", err)
+	}
+}
+
+func TestOIDCClientRejectsUnknownState(t *testing.T) {
+	idp := newMockIdP(t)
+	client := NewOIDCClient(idp.server.Client())
+	config := newTestConfig(idp.server.URL)
+
+	_, _, err := client.Exchange(context.Background(), config, "https://app.example.com/callback", "forged", "code")
+	if !errors.Is(err, ErrUnknownState) {
+		t.Fatalf("err = %v, want ErrUnknownState", err)
+	}
+}
+
+func TestOIDCClientRejectsStateOfAnotherTenant(t *testing.T) {
+	idp := newMockIdP(t)
+	client := NewOIDCClient(idp.server.Client())
+	config := newTestConfig(idp.server.URL)
+	other := newTestConfig(idp.server.URL)
+	ctx := context.Background()
+
+	authURL, state, err := client.AuthCodeURL(ctx, config, "https://app.example.com/callback", "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := idp.authorize(t, authURL)
+
+	_, _, err = client.Exchange(ctx, other, "https://app.example.com/callback", state, code)
+	if !errors.Is(err, ErrUnknownState) {
+		t.Fatalf("err = %v, want ErrUnknownState", err)
+	}
+}
+
+func TestOIDCClientRejectsWrongCodeVerifier(t *testing.T) {
+	idp := newMockIdP(t)
+	client := NewOIDCClient(idp.server.Client())
+	config := newTestConfig(idp.server.URL)
+	ctx := context.Background()
+
+	// The code is bound to the challenge of the first login, so redeeming it with the
+	// verifier of the second one must fail
+	firstURL, _, err := client.AuthCodeURL(ctx, config, "https://app.example.com/callback", "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, secondState, err := client.AuthCodeURL(ctx, config, "https://app.example.com/callback", "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := idp.authorize(t, firstURL)
+
+	if _, _, err := client.Exchange(ctx, config, "https://app.example.com/callback", secondState, code); err == nil {
+		t.Fatal("exchange with another login's code verifier succeeded")
+	}
+}
+
+func TestOIDCClientRejectsNonceMismatch(t *testing.T) {
+	idp := newMockIdP(t)
+	idp.tokenNonce = "replayed-nonce"
+	client := NewOIDCClient(idp.server.Client())
+	config := newTestConfig(idp.server.URL)
+	ctx := context.Background()
+
+	authURL, state, err := client.AuthCodeURL(ctx, config, "https://app.example.com/callback", "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := idp.authorize(t, authURL)
+
+	_, _, err = client.Exchange(ctx, config, "https://app.example.com/callback", state, code)
+	if err == nil || err.Error() != "id_token nonce mismatch" {
+		t.Fatalf("err = %v, want id_token nonce mismatch", err)
+	}
+}
+
+func mustQuery(t *testing.T, rawURL string) url.Values {
+	t.Helper()
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.Query()
+}
@@ -1,13 +1,278 @@
+// Package config defines the application configuration, loaded from defaults, a YAML or TOML
+// file, environment variables and command-line flags, in increasing precedence.
+//
+// Each setting has a file key built from its section and field keys, such as server.port, which
+// is also the name of its flag (--server.port=9090), and an environment variable (SERVER_PORT).
+// Settings tagged reload, or in sections tagged reload, are applied to the running server when
+// the configuration is reloaded; the others need a restart.
 package config
 
-import (
-	"github.com/joho/godotenv"
-)
+import "time"
 
-func LoadEnvConfig() {
-	godotenv.Load(".env")
+// Config is the complete application configuration
+type Config struct {
+	Server    Server    `key:"server"`
+	Logging   Logging   `key:"logging"`
+	Metrics   Metrics   `key:"metrics"`
+	Tracing   Tracing   `key:"tracing"`
+	Session   Session   `key:"session"`
+	Bootstrap Bootstrap `key:"bootstrap"`
+	Account   Account   `key:"account"`
+	Mail      Mail      `key:"mail"`
+	JWT       JWT       `key:"jwt"`
+	CORS      CORS      `key:"cors" reload:"true"`
+	CSRF      CSRF      `key:"csrf"`
+	RateLimit RateLimit `key:"rate_limit"`
+	Login     Login     `key:"login"`
 
-	// if err != nil {
-	// log.Fatalf("The config module cannot load the .env file")
-	// }
+	sources []string
+	args    []string // Command-line flags, parsed again on reload
+	file    string
+}
+
+// Server holds HTTP server and lifecycle settings
+type Server struct {
+	Port                int           `key:"port" env:"SERVER_PORT"`
+	Environment         string        `key:"environment" env:"APP_ENV"`
+	ShutdownDrainDelay  time.Duration `key:"shutdown_drain_delay" env:"SHUTDOWN_DRAIN_DELAY"`   // Readiness fails this long before the listener closes
+	ShutdownTimeout     time.Duration `key:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`           // Longest wait for in-flight requests at shutdown
+	HealthCheckTimeout  time.Duration `key:"health_check_timeout" env:"HEALTH_CHECK_TIMEOUT"`   // Longest time each readiness check may take
+	ConfigWatchInterval time.Duration `key:"config_watch_interval" env:"CONFIG_WATCH_INTERVAL"` // How often the config file is checked for changes; 0 disables
+}
+
+// Logging holds log and request log settings
+type Logging struct {
+	Level           string            `key:"level" env:"LOG_LEVEL" reload:"true"`
+	Levels          map[string]string `key:"levels" env:"LOG_LEVELS" reload:"true"` // Levels by package
+	Format          string            `key:"format" env:"LOG_FORMAT" reload:"true"`
+	RedactKeys      []string          `key:"redact_keys" env:"LOG_REDACT_KEYS" reload:"true"` // Redacted in addition to the default keys
+	RequestIDHeader string            `key:"request_id_header" env:"REQUEST_ID_HEADER"`
+	SkipPaths       []string          `key:"skip_paths" env:"REQUEST_LOG_SKIP_PATHS"` // Paths whose successful requests aren't logged
+}
+
+// Metrics holds metrics endpoint settings
+type Metrics struct {
+	Enabled bool   `key:"enabled" env:"METRICS_ENABLED"`
+	Path    string `key:"path" env:"METRICS_PATH"`
+	Token   string `key:"token" env:"METRICS_TOKEN" secret:"true"`
+}
+
+// Tracing holds span export settings
+type Tracing struct {
+	Exporter     string            `key:"exporter" env:"OTEL_TRACES_EXPORTER"` // none, otlp or stdout
+	ServiceName  string            `key:"service_name" env:"OTEL_SERVICE_NAME"`
+	OTLPEndpoint string            `key:"otlp_endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT"`
+	OTLPHeaders  map[string]string `key:"otlp_headers" env:"OTEL_EXPORTER_OTLP_HEADERS" secret:"true"`
+	SampleRatio  float64           `key:"sample_ratio" env:"OTEL_TRACES_SAMPLER_ARG"`
+}
+
+// Session holds session cookie settings
+type Session struct {
+	CookieName string        `key:"cookie_name" env:"SESSION_COOKIE_NAME"`
+	MaxAge     time.Duration `key:"max_age" env:"SESSION_MAX_AGE"`
+}
+
+// Bootstrap holds the initial platform admin account, created at startup when both are set
+type Bootstrap struct {
+	AdminEmail    string `key:"admin_email" env:"BOOTSTRAP_ADMIN_EMAIL"`
+	AdminPassword string `key:"admin_password" env:"BOOTSTRAP_ADMIN_PASSWORD" secret:"true"`
+}
+
+// Account holds account email and invitation settings
+type Account struct {
+	BaseURL              string        `key:"base_url" env:"APP_BASE_URL"` // Web app that email links point to
+	PasswordResetTTL     time.Duration `key:"password_reset_ttl" env:"PASSWORD_RESET_TTL"`
+	EmailVerificationTTL time.Duration `key:"email_verification_ttl" env:"EMAIL_VERIFICATION_TTL"`
+	InvitationTTL        time.Duration `key:"invitation_ttl" env:"INVITATION_TTL"`
+	TokenCleanupInterval time.Duration `key:"token_cleanup_interval" env:"ACCOUNT_TOKEN_CLEANUP_INTERVAL"`
+}
+
+// Mail holds mail delivery settings
+type Mail struct {
+	Driver       string `key:"driver" env:"MAIL_DRIVER"` // log, smtp or memory
+	From         string `key:"from" env:"MAIL_FROM"`
+	SMTPHost     string `key:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     int    `key:"smtp_port" env:"SMTP_PORT"`
+	SMTPUsername string `key:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `key:"smtp_password" env:"SMTP_PASSWORD" secret:"true"`
+}
+
+// JWT holds access and refresh token settings
+type JWT struct {
+	Algorithm          string            `key:"algorithm" env:"JWT_ALGORITHM"` // HS256 or EdDSA
+	Secret             string            `key:"secret" env:"JWT_SECRET" secret:"true"`
+	Ed25519Seed        string            `key:"ed25519_seed" env:"JWT_ED25519_SEED" secret:"true"` // Base64-encoded
+	Keys               map[string]string `key:"keys" env:"JWT_KEYS" secret:"true" reload:"true"`   // Signing keys by key ID, in the format of Secret or Ed25519Seed; replace them when set
+	ActiveKeyID        string            `key:"active_kid" env:"JWT_ACTIVE_KID" reload:"true"`     // Key in Keys that signs new tokens
+	Issuer             string            `key:"issuer" env:"JWT_ISSUER"`
+	AccessTTL          time.Duration     `key:"access_ttl" env:"JWT_ACCESS_TTL"`
+	RefreshTTL         time.Duration     `key:"refresh_ttl" env:"JWT_REFRESH_TTL"`
+	RefreshStore       string            `key:"refresh_store" env:"JWT_REFRESH_STORE"` // memory or redis, on the rate limit Redis server
+	RefreshRedisPrefix string            `key:"refresh_redis_prefix" env:"JWT_REFRESH_REDIS_PREFIX"`
+}
+
+// CORS holds the default CORS policy and the origins of the named policies
+type CORS struct {
+	AllowOrigins       []string `key:"allow_origins" env:"CORS_ALLOW_ORIGINS"`
+	AllowMethods       []string `key:"allow_methods" env:"CORS_ALLOW_METHODS"`
+	AllowHeaders       []string `key:"allow_headers" env:"CORS_ALLOW_HEADERS"`
+	ExposeHeaders      []string `key:"expose_headers" env:"CORS_EXPOSE_HEADERS"`
+	AllowCredentials   bool     `key:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	MaxAge             int      `key:"max_age" env:"CORS_MAX_AGE"` // Seconds
+	PublicAllowOrigins []string `key:"public_allow_origins" env:"CORS_PUBLIC_ALLOW_ORIGINS"`
+	AuthAllowOrigins   []string `key:"auth_allow_origins" env:"CORS_AUTH_ALLOW_ORIGINS"`   // Default to AllowOrigins
+	AdminAllowOrigins  []string `key:"admin_allow_origins" env:"CORS_ADMIN_ALLOW_ORIGINS"` // Default to AllowOrigins
+}
+
+// CSRF holds CSRF protection settings
+type CSRF struct {
+	Mode           string        `key:"mode" env:"CSRF_MODE"` // double-submit or session
+	CookieName     string        `key:"cookie_name" env:"CSRF_COOKIE_NAME"`
+	HeaderName     string        `key:"header_name" env:"CSRF_HEADER_NAME"`
+	CookieSecure   bool          `key:"cookie_secure" env:"CSRF_COOKIE_SECURE"`
+	CookieHTTPOnly bool          `key:"cookie_httponly" env:"CSRF_COOKIE_HTTPONLY"`
+	CookieSameSite string        `key:"cookie_samesite" env:"CSRF_COOKIE_SAMESITE"`
+	Expiration     time.Duration `key:"expiration" env:"CSRF_EXPIRATION"`
+	TokenLookup    string        `key:"token_lookup" env:"CSRF_TOKEN_LOOKUP"` // Defaults to the header, then the _token form field
+	ExemptPaths    []string      `key:"exempt_paths" env:"CSRF_EXEMPT_PATHS"`
+	TrustedOrigins []string      `key:"trusted_origins" env:"CSRF_TRUSTED_ORIGINS"`
+	OriginCheck    string        `key:"origin_check" env:"CSRF_ORIGIN_CHECK"`         // off, report or enforce
+	FetchSiteCheck string        `key:"fetch_site_check" env:"CSRF_FETCH_SITE_CHECK"` // off, report or enforce
+}
+
+// RateLimitRule holds the settings of a named rate limit rule
+type RateLimitRule struct {
+	Algorithm   string        `key:"algorithm" env:"ALGORITHM"` // Defaults to the default rule's algorithm
+	MaxRequests int           `key:"max_requests" env:"MAX_REQUESTS"`
+	Window      time.Duration `key:"window" env:"WINDOW"`
+	Key         string        `key:"key" env:"KEY"` // ip, user or header:<name>
+}
+
+// RateLimit holds rate limit rules and store settings
+type RateLimit struct {
+	Default             RateLimitRule      `key:"default" env:"RATE_LIMIT" reload:"true"`
+	Auth                RateLimitRule      `key:"auth" env:"RATE_LIMIT_AUTH" reload:"true"`
+	API                 RateLimitRule      `key:"api" env:"RATE_LIMIT_API" reload:"true"`
+	Bulk                RateLimitRule      `key:"bulk" env:"RATE_LIMIT_BULK" reload:"true"`
+	BulkItemsPerRequest int                `key:"bulk_items_per_request" env:"RATE_LIMIT_BULK_ITEMS_PER_REQUEST" reload:"true"`
+	PlanMultipliers     map[string]float64 `key:"plan_multipliers" env:"RATE_LIMIT_PLAN_MULTIPLIERS" reload:"true"`
+	Store               string             `key:"store" env:"RATE_LIMIT_STORE"` // memory or redis
+	RedisURL            string             `key:"redis_url" env:"RATE_LIMIT_REDIS_URL"`
+	RedisPrefix         string             `key:"redis_prefix" env:"RATE_LIMIT_REDIS_PREFIX"`
+	RedisTimeout        time.Duration      `key:"redis_timeout" env:"RATE_LIMIT_REDIS_TIMEOUT"`
+	FailOpen            bool               `key:"fail_open" env:"RATE_LIMIT_FAIL_OPEN" reload:"true"`
+	Bypass              []string           `key:"bypass" env:"RATE_LIMIT_BYPASS" reload:"true"`
+}
+
+// Login holds sign-in brute-force protection settings
+type Login struct {
+	MaxFailures     int           `key:"max_failures" env:"LOGIN_MAX_FAILURES"`
+	MaxIPFailures   int           `key:"max_ip_failures" env:"LOGIN_MAX_IP_FAILURES"`
+	LockoutDuration time.Duration `key:"lockout_duration" env:"LOGIN_LOCKOUT_DURATION"`
+	BackoffBase     time.Duration `key:"backoff_base" env:"LOGIN_BACKOFF_BASE"`
+	BackoffMax      time.Duration `key:"backoff_max" env:"LOGIN_BACKOFF_MAX"`
+	FailureWindow   time.Duration `key:"failure_window" env:"LOGIN_FAILURE_WINDOW"`
+}
+
+// Default returns the built-in configuration
+func Default() *Config {
+	return &Config{
+		Server: Server{
+			Port:                8080,
+			Environment:         "development",
+			ShutdownTimeout:     20 * time.Second,
+			HealthCheckTimeout:  2 * time.Second,
+			ConfigWatchInterval: 5 * time.Second,
+		},
+		Logging: Logging{
+			Level:           "info",
+			Format:          "json",
+			RequestIDHeader: "X-Request-ID",
+			SkipPaths:       []string{"/livez", "/readyz"},
+		},
+		Metrics: Metrics{
+			Enabled: false,
+			Path:    "/metrics",
+		},
+		Tracing: Tracing{
+			Exporter:     "none",
+			ServiceName:  "parallax",
+			OTLPEndpoint: "http://localhost:4318",
+			SampleRatio:  1,
+		},
+		Session: Session{
+			CookieName: "session_id",
+			MaxAge:     24 * time.Hour,
+		},
+		Account: Account{
+			BaseURL:              "http://localhost:3000",
+			PasswordResetTTL:     time.Hour,
+			EmailVerificationTTL: 48 * time.Hour,
+			InvitationTTL:        7 * 24 * time.Hour,
+			TokenCleanupInterval: time.Hour,
+		},
+		Mail: Mail{
+			Driver:   "log",
+			From:     "Parallax <no-reply@localhost>",
+			SMTPPort: 587,
+		},
+		JWT: JWT{
+			Algorithm:          "HS256",
+			Issuer:             "parallax",
+			AccessTTL:          15 * time.Minute,
+			RefreshTTL:         30 * 24 * time.Hour,
+			RefreshStore:       "memory",
+			RefreshRedisPrefix: "parallax:refresh:",
+		},
+		CORS: CORS{
+			AllowOrigins:       []string{"*"},
+			AllowMethods:       []string{"GET", "POST", "HEAD", "PUT", "DELETE", "PATCH", "OPTIONS"},
+			AllowHeaders:       []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Requested-With", "X-Request-ID"},
+			ExposeHeaders:      []string{"Content-Length", "RateLimit", "RateLimit-Policy", "Retry-After", "X-Request-ID"},
+			MaxAge:             86400,
+			PublicAllowOrigins: []string{"*"},
+		},
+		CSRF: CSRF{
+			Mode:           "double-submit",
+			CookieName:     "csrf_token",
+			HeaderName:     "X-CSRF-Token",
+			CookieHTTPOnly: true,
+			CookieSameSite: "Lax",
+			Expiration:     24 * time.Hour,
+			OriginCheck:    "report",
+			FetchSiteCheck: "report",
+		},
+		RateLimit: RateLimit{
+			Default:             RateLimitRule{Algorithm: "sliding_window", MaxRequests: 100, Window: time.Minute, Key: "ip"},
+			Auth:                RateLimitRule{MaxRequests: 30, Window: time.Minute, Key: "ip"},
+			API:                 RateLimitRule{MaxRequests: 300, Window: time.Minute, Key: "user"},
+			Bulk:                RateLimitRule{MaxRequests: 300, Window: time.Minute, Key: "user"},
+			BulkItemsPerRequest: 50,
+			PlanMultipliers:     map[string]float64{"basic": 1, "premium": 3, "enterprise": 10},
+			Store:               "memory",
+			RedisURL:            "redis://localhost:6379/0",
+			RedisPrefix:         "parallax:ratelimit:",
+			RedisTimeout:        100 * time.Millisecond,
+			FailOpen:            true,
+		},
+		Login: Login{
+			MaxFailures:     5,
+			MaxIPFailures:   20,
+			LockoutDuration: 15 * time.Minute,
+			BackoffBase:     time.Second,
+			BackoffMax:      time.Minute,
+			FailureWindow:   15 * time.Minute,
+		},
+	}
+}
+
+// Sources lists where the configuration was read from besides the defaults, such as the config
+// file and the .env file
+func (c *Config) Sources() []string {
+	return c.sources
+}
+
+// File returns the path of the config file, or an empty string when there is none
+func (c *Config) File() string {
+	return c.file
 }
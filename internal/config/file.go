@@ -0,0 +1,197 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Config files are read into flat maps from dotted keys, such as server.port, to a string or a
+// list of strings, which are then parsed like environment variables. Nested sections become
+// dotted keys, so tables such as rate_limit.plan_multipliers hold one key per name.
+
+// flatValues holds a config file's values by dotted key
+type flatValues map[string]interface{}
+
+// put stores a value, rejecting keys given twice
+func (v flatValues) put(key string, value interface{}) error {
+	if _, exists := v[key]; exists {
+		return fmt.Errorf("%s is set more than once", key)
+	}
+	v[key] = value
+	return nil
+}
+
+// parseYAML reads a YAML config file. Scalars keep their text as written, so a value such as
+// 0123 is not read as a number; anchors, aliases and merge keys are resolved.
+func parseYAML(data string) (map[string]interface{}, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal([]byte(data), &document); err != nil {
+		return nil, err
+	}
+
+	values := flatValues{}
+	if len(document.Content) == 0 {
+		return values, nil
+	}
+	root := resolveAlias(document.Content[0])
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: expected a mapping of settings", root.Line)
+	}
+	if err := flattenYAML(root, "", values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// resolveAlias returns the node an alias refers to
+func resolveAlias(node *yaml.Node) *yaml.Node {
+	for node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	return node
+}
+
+// flattenYAML stores the values of a mapping under the given key prefix. Keys written in the
+// mapping win over those merged in with <<, and earlier merged mappings over later ones.
+func flattenYAML(mapping *yaml.Node, prefix string, values flatValues) error {
+	var merged []*yaml.Node
+	taken := make(map[string]bool)
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		name, value := mapping.Content[i], resolveAlias(mapping.Content[i+1])
+		if name.Tag == "!!merge" {
+			if value.Kind == yaml.SequenceNode {
+				for _, item := range value.Content {
+					merged = append(merged, resolveAlias(item))
+				}
+			} else {
+				merged = append(merged, value)
+			}
+			continue
+		}
+		if err := putYAML(prefix+name.Value, value, values); err != nil {
+			return err
+		}
+		taken[name.Value] = true
+	}
+
+	for _, source := range merged {
+		if source.Kind != yaml.MappingNode {
+			return fmt.Errorf("line %d: only mappings can be merged", source.Line)
+		}
+		for i := 0; i+1 < len(source.Content); i += 2 {
+			name := source.Content[i].Value
+			if taken[name] {
+				continue
+			}
+			taken[name] = true
+			if err := putYAML(prefix+name, resolveAlias(source.Content[i+1]), values); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// putYAML stores a scalar, a list of scalars, or the values of a nested mapping
+func putYAML(key string, node *yaml.Node, values flatValues) error {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return flattenYAML(node, key+".", values)
+	case yaml.SequenceNode:
+		items := make([]string, 0, len(node.Content))
+		for _, item := range node.Content {
+			item = resolveAlias(item)
+			if item.Kind != yaml.ScalarNode {
+				return fmt.Errorf("line %d: %s: list items must be single values", item.Line, key)
+			}
+			items = append(items, yamlScalar(item))
+		}
+		return lineError(node.Line, values.put(key, items))
+	}
+	return lineError(node.Line, values.put(key, yamlScalar(node)))
+}
+
+// yamlScalar returns a scalar's text, or an empty string for null
+func yamlScalar(node *yaml.Node) string {
+	if node.Tag == "!!null" {
+		return ""
+	}
+	return node.Value
+}
+
+// lineError adds a line number to an error
+func lineError(line int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("line %d: %w", line, err)
+}
+
+// parseTOML reads a TOML config file
+func parseTOML(data string) (map[string]interface{}, error) {
+	var document map[string]interface{}
+	if _, err := toml.Decode(data, &document); err != nil {
+		return nil, err
+	}
+
+	values := flatValues{}
+	if err := flattenTOML(document, "", values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// flattenTOML stores the values of a table under the given key prefix
+func flattenTOML(table map[string]interface{}, prefix string, values flatValues) error {
+	for name, value := range table {
+		key := prefix + name
+		switch value := value.(type) {
+		case map[string]interface{}:
+			if err := flattenTOML(value, key+".", values); err != nil {
+				return err
+			}
+		case []map[string]interface{}:
+			return fmt.Errorf("%s: arrays of tables aren't supported", key)
+		case []interface{}:
+			items := make([]string, 0, len(value))
+			for _, item := range value {
+				text, err := tomlScalar(item)
+				if err != nil {
+					return fmt.Errorf("%s: %w", key, err)
+				}
+				items = append(items, text)
+			}
+			if err := values.put(key, items); err != nil {
+				return err
+			}
+		default:
+			text, err := tomlScalar(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			if err := values.put(key, text); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// tomlScalar formats a string, number or boolean as it would be written in an environment variable
+func tomlScalar(value interface{}) (string, error) {
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	case int64:
+		return strconv.FormatInt(value, 10), nil
+	case float64:
+		return strconv.FormatFloat(value, 'g', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(value), nil
+	}
+	return "", errors.New("expected a string, number or boolean")
+}
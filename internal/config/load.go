@@ -0,0 +1,347 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// EnvFile is the file of environment variables loaded at startup when it exists; variables
+// already set in the environment take precedence
+const EnvFile = ".env"
+
+// ConfigFileEnv names the environment variable holding the config file path, used when the
+// --config flag isn't given
+const ConfigFileEnv = "CONFIG_FILE"
+
+// setting is one configurable value of a Config
+type setting struct {
+	key    string // File key and flag name, such as server.port
+	env    string // Environment variable, such as SERVER_PORT
+	secret bool   // Redacted when printed
+	reload bool   // Applied to the running server on reload
+	value  reflect.Value
+}
+
+// settings lists the configurable values of a Config, in declaration order
+func (c *Config) settings() []setting {
+	return collectSettings(reflect.ValueOf(c).Elem(), "", "", false)
+}
+
+// collectSettings walks a section; nested structs extend the key with their own key and, when
+// tagged with one, prefix their fields' environment variables with their own. Fields of a
+// section tagged reload are reloadable.
+func collectSettings(v reflect.Value, keyPrefix, envPrefix string, reload bool) []setting {
+	var settings []setting
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		key, tagged := field.Tag.Lookup("key")
+		if !tagged {
+			continue
+		}
+		key = keyPrefix + key
+		env := field.Tag.Get("env")
+		if env != "" && envPrefix != "" {
+			env = envPrefix + "_" + env
+		}
+		reloadable := reload || field.Tag.Get("reload") == "true"
+
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Duration(0)) {
+			settings = append(settings, collectSettings(v.Field(i), key+".", env, reloadable)...)
+			continue
+		}
+		settings = append(settings, setting{
+			key:    key,
+			env:    env,
+			secret: field.Tag.Get("secret") == "true",
+			reload: reloadable,
+			value:  v.Field(i),
+		})
+	}
+	return settings
+}
+
+// set parses a string into the setting. Lists are comma-separated, and maps are
+// comma-separated name=value pairs.
+func (s setting) set(raw string) error {
+	raw = strings.TrimSpace(raw)
+	switch s.value.Interface().(type) {
+	case string:
+		s.value.SetString(raw)
+	case bool:
+		value, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", raw)
+		}
+		s.value.SetBool(value)
+	case int:
+		value, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a whole number", raw)
+		}
+		s.value.SetInt(int64(value))
+	case float64:
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number", raw)
+		}
+		s.value.SetFloat(value)
+	case time.Duration:
+		value, err := parseDuration(raw)
+		if err != nil {
+			return err
+		}
+		s.value.SetInt(int64(value))
+	case []string:
+		s.value.Set(reflect.ValueOf(splitList(raw)))
+	case map[string]string, map[string]float64:
+		values := make(map[string]string)
+		for _, pair := range splitList(raw) {
+			name, value, found := strings.Cut(pair, "=")
+			if !found || strings.TrimSpace(name) == "" {
+				return fmt.Errorf("%q must look like name=value", pair)
+			}
+			values[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+		return s.setMap(values)
+	default:
+		return fmt.Errorf("unsupported setting type %s", s.value.Type())
+	}
+	return nil
+}
+
+// setList sets a list setting from a file's list value
+func (s setting) setList(values []string) error {
+	if _, ok := s.value.Interface().([]string); !ok {
+		return errors.New("expected a single value, not a list")
+	}
+	s.value.Set(reflect.ValueOf(append([]string{}, values...)))
+	return nil
+}
+
+// setMap merges entries into a map setting, so that setting one name keeps the defaults and
+// earlier sources for the others; values are parsed for the map's element type
+func (s setting) setMap(values map[string]string) error {
+	switch current := s.value.Interface().(type) {
+	case map[string]string:
+		merged := make(map[string]string, len(current)+len(values))
+		for name, value := range current {
+			merged[name] = value
+		}
+		for name, value := range values {
+			merged[name] = value
+		}
+		s.value.Set(reflect.ValueOf(merged))
+	case map[string]float64:
+		merged := make(map[string]float64, len(current)+len(values))
+		for name, value := range current {
+			merged[name] = value
+		}
+		for name, value := range values {
+			number, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("%s: %q is not a number", name, value)
+			}
+			merged[name] = number
+		}
+		s.value.Set(reflect.ValueOf(merged))
+	default:
+		return errors.New("expected a single value, not a table")
+	}
+	return nil
+}
+
+// isMap reports whether the setting holds name=value pairs
+func (s setting) isMap() bool {
+	return s.value.Kind() == reflect.Map
+}
+
+// parseDuration parses a duration such as 15m or 24h; a bare number is taken as hours, as
+// earlier releases accepted
+func parseDuration(raw string) (time.Duration, error) {
+	if duration, err := time.ParseDuration(raw); err == nil {
+		return duration, nil
+	}
+	if hours, err := strconv.Atoi(raw); err == nil {
+		return time.Duration(hours) * time.Hour, nil
+	}
+	return 0, fmt.Errorf("%q is not a duration such as 30s, 15m or 24h", raw)
+}
+
+// splitList splits a comma-separated list, dropping empty items
+func splitList(raw string) []string {
+	items := []string{}
+	for _, item := range strings.Split(raw, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// Load builds the configuration from the defaults, the config file, environment variables
+// (including those in the .env file) and the command-line flags in args, each overriding the
+// ones before, then validates it. Every problem found is returned, joined; the configuration
+// is returned even when invalid so that it can be printed. flag.ErrHelp is returned as is
+// when args ask for help.
+func Load(args []string) (*Config, error) {
+	cfg := Default()
+	cfg.args = append([]string{}, args...)
+	settings := cfg.settings()
+
+	var problems []error
+	if err := godotenv.Load(EnvFile); err == nil {
+		cfg.sources = append(cfg.sources, EnvFile)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		problems = append(problems, fmt.Errorf("%s: %w", EnvFile, err))
+	}
+
+	// Flags are parsed first to find the config file, and applied last
+	flags := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv(ConfigFileEnv), "path of a YAML or TOML config file (env "+ConfigFileEnv+")")
+	type flagValue struct {
+		setting setting
+		raw     string
+	}
+	var flagValues []flagValue
+	for _, s := range settings {
+		usage := "sets " + s.key
+		if s.env != "" {
+			usage += " (env " + s.env + ")"
+		}
+		flags.Func(s.key, usage, func(raw string) error {
+			flagValues = append(flagValues, flagValue{setting: s, raw: raw})
+			return nil
+		})
+	}
+	if err := flags.Parse(args); err != nil {
+		return cfg, err
+	}
+	if flags.NArg() > 0 {
+		problems = append(problems, fmt.Errorf("unexpected arguments %q", flags.Args()))
+	}
+
+	if cfg.file = *configFile; cfg.file != "" {
+		if err := cfg.loadFile(*configFile, settings); err != nil {
+			problems = append(problems, err)
+		} else {
+			cfg.sources = append(cfg.sources, *configFile)
+		}
+	}
+
+	// Empty variables are treated as unset, as .env files list every variable
+	for _, s := range settings {
+		if raw := os.Getenv(s.env); s.env != "" && raw != "" {
+			if err := s.set(raw); err != nil {
+				problems = append(problems, fmt.Errorf("%s: %w", s.env, err))
+			}
+		}
+	}
+
+	for _, value := range flagValues {
+		if err := value.setting.set(value.raw); err != nil {
+			problems = append(problems, fmt.Errorf("--%s: %w", value.setting.key, err))
+		}
+	}
+
+	// Values that failed to parse keep their previous, valid value, so they aren't reported twice
+	problems = append(problems, cfg.Validate())
+	return cfg, errors.Join(problems...)
+}
+
+// loadFile applies a YAML or TOML config file, chosen by its extension
+func (c *Config) loadFile(path string, settings []setting) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var values map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		values, err = parseYAML(string(data))
+	case ".toml":
+		values, err = parseTOML(string(data))
+	default:
+		return fmt.Errorf("%s: unknown config file type, expected .yaml, .yml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	var problems []error
+	used := make(map[string]bool, len(values))
+	for _, s := range settings {
+		if s.isMap() {
+			// Tables hold one value per name, under keys such as rate_limit.plan_multipliers.basic
+			entries := make(map[string]string)
+			for key, value := range values {
+				if name, found := strings.CutPrefix(key, s.key+"."); found {
+					used[key] = true
+					text, ok := value.(string)
+					if !ok {
+						problems = append(problems, fmt.Errorf("%s: %s: expected a single value, not a list", path, key))
+						continue
+					}
+					entries[name] = text
+				}
+			}
+			if raw, exists := values[s.key]; exists {
+				// A table may also be written as a name=value list
+				used[s.key] = true
+				text, _ := raw.(string)
+				if list, ok := raw.([]string); ok {
+					text = strings.Join(list, ",")
+				}
+				if err := s.set(text); err != nil {
+					problems = append(problems, fmt.Errorf("%s: %s: %w", path, s.key, err))
+				}
+				continue
+			}
+			if len(entries) > 0 {
+				if err := s.setMap(entries); err != nil {
+					problems = append(problems, fmt.Errorf("%s: %s: %w", path, s.key, err))
+				}
+			}
+			continue
+		}
+
+		raw, exists := values[s.key]
+		if !exists {
+			continue
+		}
+		used[s.key] = true
+		var err error
+		switch value := raw.(type) {
+		case []string:
+			err = s.setList(value)
+		case string:
+			err = s.set(value)
+		}
+		if err != nil {
+			problems = append(problems, fmt.Errorf("%s: %s: %w", path, s.key, err))
+		}
+	}
+
+	var unknown []string
+	for key := range values {
+		if !used[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		problems = append(problems, fmt.Errorf("%s: unknown setting %s", path, key))
+	}
+	return errors.Join(problems...)
+}
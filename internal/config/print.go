@@ -0,0 +1,116 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudparallax/parallax/pkg/logging"
+)
+
+// Print writes the configuration as a YAML config file. Secrets and credentials in URLs are
+// redacted, so the output can be shared, but it has to be edited before it can be loaded.
+func (c *Config) Print(w io.Writer) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "# Sources: defaults")
+	for _, source := range c.sources {
+		fmt.Fprintf(out, "#   %s\n", source)
+	}
+	fmt.Fprintln(out, "#   environment and flags")
+
+	section := ""
+	for _, s := range c.settings() {
+		// Keys are at most three levels deep, such as rate_limit.default.window
+		parts := strings.Split(s.key, ".")
+		parent := strings.Join(parts[:len(parts)-1], ".")
+		if parent != section {
+			for depth := 0; depth < len(parts)-1; depth++ {
+				heading := strings.Join(parts[:depth+1], ".")
+				if !strings.HasPrefix(section+".", heading+".") {
+					fmt.Fprintf(out, "%s%s:\n", strings.Repeat("  ", depth), parts[depth])
+				}
+			}
+			section = parent
+		}
+
+		indent := strings.Repeat("  ", len(parts)-1)
+		name := parts[len(parts)-1]
+		switch value := s.value.Interface().(type) {
+		case map[string]string, map[string]float64:
+			printMap(out, indent, name, s.value, s.secret)
+		default:
+			fmt.Fprintf(out, "%s%s: %s\n", indent, name, formatValue(value, s.secret))
+		}
+	}
+	return out.Flush()
+}
+
+// printMap writes a map setting as a nested block, sorted by name
+func printMap(out io.Writer, indent, name string, value reflect.Value, secret bool) {
+	if value.Len() == 0 {
+		fmt.Fprintf(out, "%s%s: {}\n", indent, name)
+		return
+	}
+	fmt.Fprintf(out, "%s%s:\n", indent, name)
+
+	names := make([]string, 0, value.Len())
+	for _, key := range value.MapKeys() {
+		names = append(names, key.String())
+	}
+	sort.Strings(names)
+	for _, entry := range names {
+		fmt.Fprintf(out, "%s  %s: %s\n", indent, quote(entry), formatValue(value.MapIndex(reflect.ValueOf(entry)).Interface(), secret))
+	}
+}
+
+// formatValue formats a scalar or list for YAML, redacting it when secret
+func formatValue(value interface{}, secret bool) string {
+	switch value := value.(type) {
+	case string:
+		if secret && value != "" {
+			return quote(logging.Redacted)
+		}
+		return quote(redactUserinfo(value))
+	case time.Duration:
+		return value.String()
+	case float64:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	case []string:
+		items := make([]string, len(value))
+		for i, item := range value {
+			items[i] = formatValue(item, secret)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	}
+	return fmt.Sprint(value)
+}
+
+// redactUserinfo hides the whole user info of a URL, such as the username alone that some
+// servers take as a password, and keeps the rest of it
+func redactUserinfo(value string) string {
+	if !strings.Contains(value, "://") || !strings.Contains(value, "@") {
+		return value
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.User == nil {
+		return logging.Redact("", value)
+	}
+	u.User = url.User("xxxxx")
+	return u.String()
+}
+
+// quote double-quotes strings that would otherwise be read back differently, including those
+// starting with a YAML indicator such as * or &
+func quote(value string) string {
+	if value == "" || value == "~" || value == "null" || strings.TrimSpace(value) != value ||
+		strings.ContainsAny(value, "#:,[]{}\"'\\") || strings.ContainsAny(value[:1], "*&!|>%@`-?") {
+		return strconv.Quote(value)
+	}
+	return value
+}
@@ -0,0 +1,54 @@
+package config
+
+import "reflect"
+
+// Changes lists the keys of the settings that differ between two configurations
+type Changes struct {
+	Reloaded []string // Applied to the running server
+	Restart  []string // Only take effect after a restart
+}
+
+// Empty reports whether no setting changed
+func (c Changes) Empty() bool {
+	return len(c.Reloaded) == 0 && len(c.Restart) == 0
+}
+
+// Reload loads the configuration again from the same sources and flags, reading the config
+// file anew. Like Load, it returns every problem found; an invalid configuration must not be
+// applied.
+func (c *Config) Reload() (*Config, error) {
+	return Load(c.args)
+}
+
+// Merge returns the configuration to run with once next is applied: next's reloadable
+// settings, and c's other settings, which keep their running values until a restart
+func (c *Config) Merge(next *Config) (*Config, Changes) {
+	merged := *c
+	merged.sources = next.sources
+
+	var changes Changes
+	nextSettings := next.settings()
+	for i, s := range merged.settings() {
+		if equalValues(s.value, nextSettings[i].value) {
+			continue
+		}
+		if s.reload {
+			s.value.Set(nextSettings[i].value)
+			changes.Reloaded = append(changes.Reloaded, s.key)
+		} else {
+			changes.Restart = append(changes.Restart, s.key)
+		}
+	}
+	return &merged, changes
+}
+
+// equalValues compares two setting values; empty and missing lists and maps are equal
+func equalValues(a, b reflect.Value) bool {
+	switch a.Kind() {
+	case reflect.Slice, reflect.Map:
+		if a.Len() == 0 && b.Len() == 0 {
+			return true
+		}
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
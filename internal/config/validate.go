@@ -0,0 +1,249 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cloudparallax/parallax/pkg/logging"
+	"github.com/redis/go-redis/v9"
+)
+
+// validator collects problems with settings, named by their file keys
+type validator struct {
+	problems []error
+}
+
+// check records a problem with a setting unless ok
+func (v *validator) check(ok bool, key, format string, args ...interface{}) {
+	if !ok {
+		v.problems = append(v.problems, fmt.Errorf("%s: %s", key, fmt.Sprintf(format, args...)))
+	}
+}
+
+// oneOf checks that a setting has one of the allowed values, ignoring case
+func (v *validator) oneOf(key, value string, allowed ...string) {
+	for _, candidate := range allowed {
+		if strings.EqualFold(value, candidate) {
+			return
+		}
+	}
+	v.check(false, key, "%q must be one of %s", value, strings.Join(allowed, ", "))
+}
+
+// positive checks that a duration is above zero
+func (v *validator) positive(key string, value time.Duration) {
+	v.check(value > 0, key, "must be more than zero")
+}
+
+// port checks a TCP port number
+func (v *validator) port(key string, value int) {
+	v.check(value > 0 && value <= 65535, key, "%d is not a port number", value)
+}
+
+// origins checks CORS and CSRF origins: "*", regex:<pattern>, or a host with an optional
+// scheme and port, where the leftmost label of the host may be a * wildcard
+func (v *validator) origins(key string, values []string) {
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "*" {
+			continue
+		}
+		if expression, ok := strings.CutPrefix(value, "regex:"); ok {
+			_, err := regexp.Compile(expression)
+			v.check(err == nil, key, "origin pattern %q is not a valid regular expression", value)
+			continue
+		}
+
+		rest := value
+		if _, remainder, found := strings.Cut(value, "://"); found {
+			rest = remainder
+		}
+		rest = strings.TrimSuffix(rest, "/")
+		host, port := rest, ""
+		if i := strings.LastIndex(rest, ":"); i >= 0 && !strings.HasSuffix(rest, "]") {
+			host, port = rest[:i], rest[i+1:]
+		}
+		_, err := strconv.Atoi(port)
+		valid := host != "" && !strings.ContainsAny(rest, "/?#@") &&
+			!strings.Contains(strings.TrimPrefix(host, "*."), "*") && (port == "" || port == "*" || err == nil)
+		v.check(valid, key, "origin %q must be a host with an optional scheme and port, such as https://app.example.com", value)
+	}
+}
+
+// rule checks a rate limit rule; algorithm may be empty when it is inherited
+func (v *validator) rule(key string, rule RateLimitRule, inherits bool) {
+	if rule.Algorithm != "" || !inherits {
+		v.oneOf(key+".algorithm", rule.Algorithm, "fixed_window", "sliding_log", "sliding_window", "gcra")
+	}
+	v.check(rule.MaxRequests >= 0, key+".max_requests", "must not be negative")
+	v.positive(key+".window", rule.Window)
+
+	kind, name, _ := strings.Cut(rule.Key, ":")
+	switch strings.ToLower(strings.TrimSpace(kind)) {
+	case "ip", "user":
+	case "header":
+		v.check(strings.TrimSpace(name) != "", key+".key", "header:<name> needs a header name")
+	default:
+		v.check(false, key+".key", "%q must be ip, user or header:<name>", rule.Key)
+	}
+}
+
+// Validate checks every setting and returns all problems found, joined
+func (c *Config) Validate() error {
+	v := &validator{}
+
+	v.port("server.port", c.Server.Port)
+	v.check(c.Server.ShutdownDrainDelay >= 0, "server.shutdown_drain_delay", "must not be negative")
+	v.positive("server.shutdown_timeout", c.Server.ShutdownTimeout)
+	v.positive("server.health_check_timeout", c.Server.HealthCheckTimeout)
+	v.check(c.Server.ConfigWatchInterval >= 0, "server.config_watch_interval", "must not be negative")
+
+	_, err := logging.ParseLevel(c.Logging.Level)
+	v.check(err == nil, "logging.level", "%q must be debug, info, warn or error", c.Logging.Level)
+	for pkg, name := range c.Logging.Levels {
+		_, err := logging.ParseLevel(name)
+		v.check(err == nil, "logging.levels", "%s=%q must be debug, info, warn or error", pkg, name)
+	}
+	v.oneOf("logging.format", c.Logging.Format, "json", "text")
+	v.check(c.Logging.RequestIDHeader != "", "logging.request_id_header", "must not be empty")
+
+	v.check(strings.HasPrefix(c.Metrics.Path, "/"), "metrics.path", "%q must start with /", c.Metrics.Path)
+	if c.Metrics.Enabled && strings.EqualFold(c.Server.Environment, "production") {
+		v.check(c.Metrics.Token != "", "metrics.token", "is required to serve metrics in production")
+	}
+
+	v.oneOf("tracing.exporter", c.Tracing.Exporter, "none", "otlp", "stdout", "console")
+	v.check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio", "%g must be from 0 to 1", c.Tracing.SampleRatio)
+	if strings.EqualFold(c.Tracing.Exporter, "otlp") {
+		u, err := url.Parse(c.Tracing.OTLPEndpoint)
+		v.check(err == nil && u.Scheme != "" && u.Host != "", "tracing.otlp_endpoint", "%q is not a URL", c.Tracing.OTLPEndpoint)
+	}
+
+	v.check(c.Session.CookieName != "", "session.cookie_name", "must not be empty")
+	v.positive("session.max_age", c.Session.MaxAge)
+
+	v.check((c.Bootstrap.AdminEmail == "") == (c.Bootstrap.AdminPassword == ""), "bootstrap",
+		"admin_email and admin_password must be set together")
+
+	baseURL, err := url.Parse(c.Account.BaseURL)
+	v.check(err == nil && baseURL.Scheme != "" && baseURL.Host != "", "account.base_url", "%q is not a URL", c.Account.BaseURL)
+	v.positive("account.password_reset_ttl", c.Account.PasswordResetTTL)
+	v.positive("account.email_verification_ttl", c.Account.EmailVerificationTTL)
+	v.positive("account.invitation_ttl", c.Account.InvitationTTL)
+	v.positive("account.token_cleanup_interval", c.Account.TokenCleanupInterval)
+
+	v.oneOf("mail.driver", c.Mail.Driver, "log", "smtp", "memory")
+	if strings.EqualFold(c.Mail.Driver, "smtp") {
+		v.check(c.Mail.SMTPHost != "", "mail.smtp_host", "is required by the smtp driver")
+		v.port("mail.smtp_port", c.Mail.SMTPPort)
+	}
+
+	v.oneOf("jwt.algorithm", c.JWT.Algorithm, "HS256", "EdDSA")
+	v.check(c.JWT.Secret == "" || len(c.JWT.Secret) >= 32, "jwt.secret", "must be at least 32 bytes")
+	if c.JWT.Ed25519Seed != "" {
+		seed, err := base64.StdEncoding.DecodeString(c.JWT.Ed25519Seed)
+		v.check(err == nil && len(seed) == 32, "jwt.ed25519_seed", "must be a base64-encoded 32-byte seed")
+	}
+	if len(c.JWT.Keys) > 0 {
+		_, active := c.JWT.Keys[c.JWT.ActiveKeyID]
+		v.check(active, "jwt.active_kid", "%q must be one of the jwt.keys", c.JWT.ActiveKeyID)
+		ids := make([]string, 0, len(c.JWT.Keys))
+		for id := range c.JWT.Keys {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			material := c.JWT.Keys[id]
+			if strings.EqualFold(c.JWT.Algorithm, "EdDSA") {
+				seed, err := base64.StdEncoding.DecodeString(material)
+				v.check(err == nil && len(seed) == 32, "jwt.keys", "%s must be a base64-encoded 32-byte seed", id)
+			} else {
+				v.check(len(material) >= 32, "jwt.keys", "%s must be at least 32 bytes", id)
+			}
+		}
+	} else {
+		v.check(c.JWT.ActiveKeyID == "", "jwt.active_kid", "needs jwt.keys")
+	}
+	if strings.EqualFold(c.Server.Environment, "production") {
+		configured := len(c.JWT.Keys) > 0 || c.JWT.Secret != "" && !strings.EqualFold(c.JWT.Algorithm, "EdDSA") ||
+			c.JWT.Ed25519Seed != "" && strings.EqualFold(c.JWT.Algorithm, "EdDSA")
+		v.check(configured, "jwt", "a signing key is required in production, in jwt.keys, jwt.secret or jwt.ed25519_seed")
+	}
+	v.positive("jwt.access_ttl", c.JWT.AccessTTL)
+	v.positive("jwt.refresh_ttl", c.JWT.RefreshTTL)
+	v.oneOf("jwt.refresh_store", c.JWT.RefreshStore, "memory", "redis")
+
+	v.origins("cors.allow_origins", c.CORS.AllowOrigins)
+	v.origins("cors.public_allow_origins", c.CORS.PublicAllowOrigins)
+	v.origins("cors.auth_allow_origins", c.CORS.AuthAllowOrigins)
+	v.origins("cors.admin_allow_origins", c.CORS.AdminAllowOrigins)
+	if c.CORS.AllowCredentials {
+		for key, origins := range map[string][]string{
+			"cors.allow_origins":       c.CORS.AllowOrigins,
+			"cors.auth_allow_origins":  c.CORS.AuthAllowOrigins,
+			"cors.admin_allow_origins": c.CORS.AdminAllowOrigins,
+		} {
+			for _, origin := range origins {
+				v.check(origin != "*", key, "* cannot be used with cors.allow_credentials")
+			}
+		}
+	}
+	v.check(c.CORS.MaxAge >= 0, "cors.max_age", "must not be negative")
+
+	v.oneOf("csrf.mode", c.CSRF.Mode, "double-submit", "session")
+	v.check(c.CSRF.CookieName != "", "csrf.cookie_name", "must not be empty")
+	v.check(c.CSRF.HeaderName != "", "csrf.header_name", "must not be empty")
+	v.oneOf("csrf.cookie_samesite", c.CSRF.CookieSameSite, "Lax", "Strict", "None")
+	v.positive("csrf.expiration", c.CSRF.Expiration)
+	if c.CSRF.TokenLookup != "" {
+		for _, source := range strings.Split(c.CSRF.TokenLookup, ",") {
+			kind, name, _ := strings.Cut(strings.TrimSpace(source), ":")
+			valid := strings.TrimSpace(name) != ""
+			switch strings.ToLower(kind) {
+			case "header", "form", "query", "param":
+			default:
+				valid = false
+			}
+			v.check(valid, "csrf.token_lookup", "%q must be header:<name>, form:<field>, query:<param> or param:<name>", source)
+		}
+	}
+	v.origins("csrf.trusted_origins", c.CSRF.TrustedOrigins)
+	v.oneOf("csrf.origin_check", c.CSRF.OriginCheck, "off", "report", "enforce")
+	v.oneOf("csrf.fetch_site_check", c.CSRF.FetchSiteCheck, "off", "report", "enforce")
+	// Behind a TLS-terminating proxy the API sees its own origin as http, so same-origin
+	// browsers are only recognized through the trusted origins
+	enforced := strings.EqualFold(c.CSRF.OriginCheck, "enforce") || strings.EqualFold(c.CSRF.FetchSiteCheck, "enforce")
+	v.check(!enforced || len(c.CSRF.TrustedOrigins) > 0, "csrf.trusted_origins",
+		"must list the origins the app is served from when a CSRF check is enforced")
+
+	v.rule("rate_limit.default", c.RateLimit.Default, false)
+	v.rule("rate_limit.auth", c.RateLimit.Auth, true)
+	v.rule("rate_limit.api", c.RateLimit.API, true)
+	v.rule("rate_limit.bulk", c.RateLimit.Bulk, true)
+	v.check(c.RateLimit.BulkItemsPerRequest > 0, "rate_limit.bulk_items_per_request", "must be more than zero")
+	for plan, multiplier := range c.RateLimit.PlanMultipliers {
+		v.check(multiplier > 0, "rate_limit.plan_multipliers", "%s=%g must be more than zero", plan, multiplier)
+	}
+	v.oneOf("rate_limit.store", c.RateLimit.Store, "memory", "redis")
+	if strings.EqualFold(c.RateLimit.Store, "redis") || strings.EqualFold(c.JWT.RefreshStore, "redis") {
+		_, err := redis.ParseURL(c.RateLimit.RedisURL)
+		v.check(err == nil, "rate_limit.redis_url", "%v", err)
+		v.positive("rate_limit.redis_timeout", c.RateLimit.RedisTimeout)
+	}
+
+	v.check(c.Login.MaxFailures > 0, "login.max_failures", "must be more than zero")
+	v.check(c.Login.MaxIPFailures > 0, "login.max_ip_failures", "must be more than zero")
+	v.positive("login.lockout_duration", c.Login.LockoutDuration)
+	v.positive("login.backoff_base", c.Login.BackoffBase)
+	v.check(c.Login.BackoffMax >= c.Login.BackoffBase, "login.backoff_max", "must not be less than login.backoff_base")
+	v.positive("login.failure_window", c.Login.FailureWindow)
+
+	return errors.Join(v.problems...)
+}
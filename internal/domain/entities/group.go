@@ -0,0 +1,73 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Group represents a directory group provisioned into a tenant
+type Group struct {
+	ID          uuid.UUID   `json:"id" db:"id"`
+	TenantID    uuid.UUID   `json:"tenant_id" db:"tenant_id"`
+	DisplayName string      `json:"display_name" db:"display_name"`
+	ExternalID  string      `json:"external_id,omitempty" db:"external_id"`
+	MemberIDs   []uuid.UUID `json:"member_ids" db:"member_ids"`
+	CreatedAt   time.Time   `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time   `json:"updated_at" db:"updated_at"`
+}
+
+// NewGroup creates a new group instance
+func NewGroup(tenantID uuid.UUID, displayName, externalID string) *Group {
+	return &Group{
+		ID:          uuid.New(),
+		TenantID:    tenantID,
+		DisplayName: displayName,
+		ExternalID:  externalID,
+		MemberIDs:   []uuid.UUID{},
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+}
+
+// Update updates group information
+func (g *Group) Update(displayName, externalID string) {
+	g.DisplayName = displayName
+	g.ExternalID = externalID
+	g.UpdatedAt = time.Now()
+}
+
+// SetMembers replaces the group's members, ignoring duplicates
+func (g *Group) SetMembers(memberIDs []uuid.UUID) {
+	seen := make(map[uuid.UUID]bool, len(memberIDs))
+	members := make([]uuid.UUID, 0, len(memberIDs))
+	for _, id := range memberIDs {
+		if !seen[id] {
+			seen[id] = true
+			members = append(members, id)
+		}
+	}
+	g.MemberIDs = members
+	g.UpdatedAt = time.Now()
+}
+
+// HasMember checks whether a user is in the group
+func (g *Group) HasMember(userID uuid.UUID) bool {
+	for _, id := range g.MemberIDs {
+		if id == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveMember removes a user from the group
+func (g *Group) RemoveMember(userID uuid.UUID) {
+	for i, id := range g.MemberIDs {
+		if id == userID {
+			g.MemberIDs = append(g.MemberIDs[:i:i], g.MemberIDs[i+1:]...)
+			g.UpdatedAt = time.Now()
+			return
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package entities
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Invitation statuses
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationRevoked  = "revoked"
+	InvitationExpired  = "expired"
+)
+
+// Invitation represents an emailed invitation to join a tenant with a role.
+// Only a hash of the invitation token is stored.
+type Invitation struct {
+	ID         uuid.UUID  `json:"id" db:"id"`
+	TenantID   uuid.UUID  `json:"tenant_id" db:"tenant_id"`
+	Email      string     `json:"email" db:"email"`
+	Role       string     `json:"role" db:"role"`
+	TokenHash  string     `json:"-" db:"token_hash"`
+	InvitedBy  uuid.UUID  `json:"invited_by" db:"invited_by"`
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`
+	AcceptedAt *time.Time `json:"accepted_at,omitempty" db:"accepted_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// NewInvitation creates a new invitation instance
+func NewInvitation(tenantID uuid.UUID, email, role, tokenHash string, invitedBy uuid.UUID, ttl time.Duration) *Invitation {
+	now := time.Now()
+	return &Invitation{
+		ID:        uuid.New(),
+		TenantID:  tenantID,
+		Email:     strings.ToLower(strings.TrimSpace(email)),
+		Role:      role,
+		TokenHash: tokenHash,
+		InvitedBy: invitedBy,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+}
+
+// Status returns the invitation's current status
+func (i *Invitation) Status() string {
+	switch {
+	case i.AcceptedAt != nil:
+		return InvitationAccepted
+	case i.RevokedAt != nil:
+		return InvitationRevoked
+	case time.Now().After(i.ExpiresAt):
+		return InvitationExpired
+	default:
+		return InvitationPending
+	}
+}
+
+// IsPending checks whether the invitation can still be accepted
+func (i *Invitation) IsPending() bool {
+	return i.Status() == InvitationPending
+}
+
+// Accept marks the invitation as accepted
+func (i *Invitation) Accept() {
+	now := time.Now()
+	i.AcceptedAt = &now
+}
+
+// Revoke marks the invitation as revoked
+func (i *Invitation) Revoke() {
+	now := time.Now()
+	i.RevokedAt = &now
+}
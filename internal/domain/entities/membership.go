@@ -0,0 +1,131 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Tenant roles, from most to least privileged
+const (
+	RoleOwner   = "owner"
+	RoleAdmin   = "admin"
+	RoleManager = "manager"
+	RoleMember  = "member"
+	RoleViewer  = "viewer"
+)
+
+// Permissions that can be granted within a tenant
+const (
+	PermissionTenantRead     = "tenant.read"
+	PermissionTenantWrite    = "tenant.write"
+	PermissionTenantDelete   = "tenant.delete"
+	PermissionLocationRead   = "location.read"
+	PermissionLocationWrite  = "location.write"
+	PermissionLocationDelete = "location.delete"
+	PermissionCustomerRead   = "customer.read"
+	PermissionCustomerWrite  = "customer.write"
+	PermissionCustomerDelete = "customer.delete"
+	PermissionMemberRead     = "member.read"
+	PermissionMemberManage   = "member.manage"
+)
+
+// rolePermissions maps each tenant role to the permissions it grants
+var rolePermissions = map[string][]string{
+	RoleOwner: {
+		PermissionTenantRead, PermissionTenantWrite, PermissionTenantDelete,
+		PermissionLocationRead, PermissionLocationWrite, PermissionLocationDelete,
+		PermissionCustomerRead, PermissionCustomerWrite, PermissionCustomerDelete,
+		PermissionMemberRead, PermissionMemberManage,
+	},
+	RoleAdmin: {
+		PermissionTenantRead, PermissionTenantWrite,
+		PermissionLocationRead, PermissionLocationWrite, PermissionLocationDelete,
+		PermissionCustomerRead, PermissionCustomerWrite, PermissionCustomerDelete,
+		PermissionMemberRead, PermissionMemberManage,
+	},
+	RoleManager: {
+		PermissionTenantRead,
+		PermissionLocationRead, PermissionLocationWrite,
+		PermissionCustomerRead, PermissionCustomerWrite, PermissionCustomerDelete,
+		PermissionMemberRead,
+	},
+	RoleMember: {
+		PermissionTenantRead,
+		PermissionLocationRead,
+		PermissionCustomerRead, PermissionCustomerWrite,
+	},
+	RoleViewer: {
+		PermissionTenantRead,
+		PermissionLocationRead,
+		PermissionCustomerRead,
+	},
+}
+
+// roleRanks orders tenant roles so that a member can only grant roles up to their own
+var roleRanks = map[string]int{
+	RoleOwner:   5,
+	RoleAdmin:   4,
+	RoleManager: 3,
+	RoleMember:  2,
+	RoleViewer:  1,
+}
+
+// IsValidRole checks whether a role is a known tenant role
+func IsValidRole(role string) bool {
+	_, exists := roleRanks[role]
+	return exists
+}
+
+// RolePermissions returns the permissions granted by a tenant role
+func RolePermissions(role string) []string {
+	return rolePermissions[role]
+}
+
+// RoleHasPermission checks whether a tenant role grants a permission
+func RoleHasPermission(role, permission string) bool {
+	for _, granted := range rolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// RoleOutranks checks whether role is at least as privileged as other
+func RoleOutranks(role, other string) bool {
+	return roleRanks[role] >= roleRanks[other]
+}
+
+// Membership represents a user's role within a tenant
+type Membership struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	TenantID  uuid.UUID `json:"tenant_id" db:"tenant_id"`
+	UserID    uuid.UUID `json:"user_id" db:"user_id"`
+	Role      string    `json:"role" db:"role"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// NewMembership creates a new membership instance
+func NewMembership(tenantID, userID uuid.UUID, role string) *Membership {
+	return &Membership{
+		ID:        uuid.New(),
+		TenantID:  tenantID,
+		UserID:    userID,
+		Role:      role,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+}
+
+// SetRole changes the member's role
+func (m *Membership) SetRole(role string) {
+	m.Role = role
+	m.UpdatedAt = time.Now()
+}
+
+// HasPermission checks whether the membership grants a permission
+func (m *Membership) HasPermission(permission string) bool {
+	return RoleHasPermission(m.Role, permission)
+}
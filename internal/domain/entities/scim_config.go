@@ -0,0 +1,68 @@
+package entities
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SCIMConfig holds a tenant's SCIM provisioning settings.
+// Only a hash of the bearer token is stored.
+type SCIMConfig struct {
+	TenantID    uuid.UUID         `json:"tenant_id" db:"tenant_id"`
+	TokenHash   string            `json:"-" db:"token_hash"`
+	GroupRoles  map[string]string `json:"group_roles" db:"group_roles"`
+	DefaultRole string            `json:"default_role" db:"default_role"`
+	LastUsedAt  *time.Time        `json:"last_used_at,omitempty" db:"last_used_at"`
+	CreatedAt   time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// NewSCIMConfig creates a new SCIM configuration instance
+func NewSCIMConfig(tenantID uuid.UUID, tokenHash string) *SCIMConfig {
+	return &SCIMConfig{
+		TenantID:    tenantID,
+		TokenHash:   tokenHash,
+		GroupRoles:  map[string]string{},
+		DefaultRole: RoleMember,
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+	}
+}
+
+// SetToken replaces the bearer token hash
+func (c *SCIMConfig) SetToken(tokenHash string) {
+	c.TokenHash = tokenHash
+	c.UpdatedAt = time.Now()
+}
+
+// SetGroupRoles replaces the mapping from group display names to tenant roles
+func (c *SCIMConfig) SetGroupRoles(groupRoles map[string]string, defaultRole string) {
+	c.GroupRoles = groupRoles
+	if defaultRole != "" {
+		c.DefaultRole = defaultRole
+	}
+	c.UpdatedAt = time.Now()
+}
+
+// ManagesRoles reports whether group membership determines tenant roles
+func (c *SCIMConfig) ManagesRoles() bool {
+	return len(c.GroupRoles) > 0
+}
+
+// RoleForGroup returns the tenant role mapped to a group display name
+func (c *SCIMConfig) RoleForGroup(displayName string) (string, bool) {
+	for group, role := range c.GroupRoles {
+		if strings.EqualFold(group, displayName) {
+			return role, true
+		}
+	}
+	return "", false
+}
+
+// RecordUse records that the bearer token was used
+func (c *SCIMConfig) RecordUse() {
+	now := time.Now()
+	c.LastUsedAt = &now
+}
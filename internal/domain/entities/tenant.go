@@ -15,10 +15,19 @@ type Tenant struct {
 	Plan        string    `json:"plan" db:"plan"`
 	MaxUsers    int       `json:"max_users" db:"max_users"`
 	MaxLocations int      `json:"max_locations" db:"max_locations"`
+	RequireMFA  bool      `json:"require_mfa" db:"require_mfa"`
+	AllowedOrigins []string `json:"allowed_origins" db:"allowed_origins"`
 	CreatedAt   time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// Plan and limits of a tenant created by a user who is not a platform admin
+const (
+	DefaultTenantPlan         = "basic"
+	DefaultTenantMaxUsers     = 5
+	DefaultTenantMaxLocations = 1
+)
+
 // NewTenant creates a new tenant instance
 func NewTenant(name, domain, plan string, maxUsers, maxLocations int) *Tenant {
 	return &Tenant{
@@ -35,14 +44,31 @@ func NewTenant(name, domain, plan string, maxUsers, maxLocations int) *Tenant {
 }
 
 // Update updates tenant information
-func (t *Tenant) Update(name, plan string, maxUsers, maxLocations int) {
+func (t *Tenant) Update(name string) {
 	t.Name = name
+	t.UpdatedAt = time.Now()
+}
+
+// SetPlan sets the tenant's plan and the limits that come with it
+func (t *Tenant) SetPlan(plan string, maxUsers, maxLocations int) {
 	t.Plan = plan
 	t.MaxUsers = maxUsers
 	t.MaxLocations = maxLocations
 	t.UpdatedAt = time.Now()
 }
 
+// SetMFARequired sets whether all of the tenant's users must use two-factor authentication
+func (t *Tenant) SetMFARequired(required bool) {
+	t.RequireMFA = required
+	t.UpdatedAt = time.Now()
+}
+
+// SetAllowedOrigins sets the browser origins allowed to call the API for this tenant
+func (t *Tenant) SetAllowedOrigins(origins []string) {
+	t.AllowedOrigins = origins
+	t.UpdatedAt = time.Now()
+}
+
 // Activate activates the tenant
 func (t *Tenant) Activate() {
 	t.IsActive = true
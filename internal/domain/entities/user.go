@@ -0,0 +1,176 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Authentication providers a user account can originate from
+const (
+	AuthProviderPassword = "password"
+	AuthProviderOIDC     = "oidc"
+)
+
+// User represents a staff member who can sign in to a tenant
+type User struct {
+	ID                 uuid.UUID  `json:"id" db:"id"`
+	TenantID           uuid.UUID  `json:"tenant_id" db:"tenant_id"`
+	Email              string     `json:"email" db:"email"`
+	FirstName          string     `json:"first_name" db:"first_name"`
+	LastName           string     `json:"last_name" db:"last_name"`
+	PasswordHash       string     `json:"-" db:"password_hash"`
+	Role               string     `json:"role" db:"role"`
+	AuthProvider       string     `json:"auth_provider" db:"auth_provider"`
+	ExternalID         string     `json:"external_id,omitempty" db:"external_id"`
+	DirectoryID        string     `json:"directory_id,omitempty" db:"directory_id"`
+	EmailVerified      bool       `json:"email_verified" db:"email_verified"`
+	EmailVerifiedAt    *time.Time `json:"email_verified_at,omitempty" db:"email_verified_at"`
+	IsActive           bool       `json:"is_active" db:"is_active"`
+	TOTPEnabled        bool       `json:"totp_enabled" db:"totp_enabled"`
+	TOTPSecret         string     `json:"-" db:"totp_secret"`
+	TOTPPendingSecret  string     `json:"-" db:"totp_pending_secret"`
+	TOTPLastCounter    int64      `json:"-" db:"totp_last_counter"`
+	RecoveryCodeHashes []string   `json:"-" db:"recovery_code_hashes"`
+	LastLoginAt        *time.Time `json:"last_login_at,omitempty" db:"last_login_at"`
+	CreatedAt          time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// NewUser creates a new user instance
+func NewUser(tenantID uuid.UUID, email, firstName, lastName, role string) *User {
+	return &User{
+		ID:           uuid.New(),
+		TenantID:     tenantID,
+		Email:        email,
+		FirstName:    firstName,
+		LastName:     lastName,
+		Role:         role,
+		AuthProvider: AuthProviderPassword,
+		IsActive:     true,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+}
+
+// Update updates user profile information
+func (u *User) Update(firstName, lastName string) {
+	u.FirstName = firstName
+	u.LastName = lastName
+	u.UpdatedAt = time.Now()
+}
+
+// SetEmail changes the user's email address, which then needs verifying again
+func (u *User) SetEmail(email string) {
+	if u.Email == email {
+		return
+	}
+	u.Email = email
+	u.EmailVerified = false
+	u.EmailVerifiedAt = nil
+	u.UpdatedAt = time.Now()
+}
+
+// SetRole changes the user's role
+func (u *User) SetRole(role string) {
+	u.Role = role
+	u.UpdatedAt = time.Now()
+}
+
+// SetPasswordHash replaces the user's password hash
+func (u *User) SetPasswordHash(hash string) {
+	u.PasswordHash = hash
+	u.UpdatedAt = time.Now()
+}
+
+// VerifyEmail records that the user proved they own their email address
+func (u *User) VerifyEmail() {
+	if u.EmailVerified {
+		return
+	}
+	now := time.Now()
+	u.EmailVerified = true
+	u.EmailVerifiedAt = &now
+	u.UpdatedAt = now
+}
+
+// StartTOTPEnrollment stores a secret that becomes active once a code from it is confirmed
+func (u *User) StartTOTPEnrollment(secret string) {
+	u.TOTPPendingSecret = secret
+	u.UpdatedAt = time.Now()
+}
+
+// EnableTOTP activates the pending TOTP secret with a fresh set of recovery codes
+func (u *User) EnableTOTP(counter int64, recoveryCodeHashes []string) {
+	u.TOTPSecret = u.TOTPPendingSecret
+	u.TOTPPendingSecret = ""
+	u.TOTPEnabled = true
+	u.TOTPLastCounter = counter
+	u.RecoveryCodeHashes = recoveryCodeHashes
+	u.UpdatedAt = time.Now()
+}
+
+// DisableTOTP removes the user's TOTP authenticator and recovery codes
+func (u *User) DisableTOTP() {
+	u.TOTPEnabled = false
+	u.TOTPSecret = ""
+	u.TOTPPendingSecret = ""
+	u.TOTPLastCounter = 0
+	u.RecoveryCodeHashes = nil
+	u.UpdatedAt = time.Now()
+}
+
+// SetRecoveryCodes replaces the user's recovery codes
+func (u *User) SetRecoveryCodes(recoveryCodeHashes []string) {
+	u.RecoveryCodeHashes = recoveryCodeHashes
+	u.UpdatedAt = time.Now()
+}
+
+// UseRecoveryCode consumes a recovery code, returning false if it is unknown
+func (u *User) UseRecoveryCode(codeHash string) bool {
+	for i, existing := range u.RecoveryCodeHashes {
+		if existing == codeHash {
+			u.RecoveryCodeHashes = append(u.RecoveryCodeHashes[:i:i], u.RecoveryCodeHashes[i+1:]...)
+			u.UpdatedAt = time.Now()
+			return true
+		}
+	}
+	return false
+}
+
+// SetDirectoryID records the user's ID in the tenant's provisioning directory
+func (u *User) SetDirectoryID(directoryID string) {
+	u.DirectoryID = directoryID
+	u.UpdatedAt = time.Now()
+}
+
+// LinkExternalIdentity links the user to an identity at an external provider
+func (u *User) LinkExternalIdentity(provider, externalID string) {
+	u.AuthProvider = provider
+	u.ExternalID = externalID
+	u.UpdatedAt = time.Now()
+}
+
+// RecordLogin records a successful sign-in
+func (u *User) RecordLogin() {
+	now := time.Now()
+	u.LastLoginAt = &now
+	u.UpdatedAt = now
+}
+
+// GetFullName returns the user's full name
+func (u *User) GetFullName() string {
+	return u.FirstName + " " + u.LastName
+}
+
+// Activate activates the user
+func (u *User) Activate() {
+	u.IsActive = true
+	u.UpdatedAt = time.Now()
+}
+
+// Deactivate deactivates the user
+func (u *User) Deactivate() {
+	u.IsActive = false
+	u.UpdatedAt = time.Now()
+}
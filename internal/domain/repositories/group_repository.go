@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+// GroupRepository defines the interface for directory group data operations
+type GroupRepository interface {
+	Create(ctx context.Context, group *entities.Group) error
+	GetByID(ctx context.Context, id uuid.UUID) (*entities.Group, error)
+	GetByTenantID(ctx context.Context, tenantID uuid.UUID) ([]*entities.Group, error)
+	GetByMember(ctx context.Context, tenantID, userID uuid.UUID) ([]*entities.Group, error)
+	Update(ctx context.Context, group *entities.Group) error
+	Delete(ctx context.Context, id uuid.UUID) error
+}
@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+// InvitationRepository defines the interface for invitation data operations
+type InvitationRepository interface {
+	Create(ctx context.Context, invitation *entities.Invitation) error
+	GetByID(ctx context.Context, id uuid.UUID) (*entities.Invitation, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*entities.Invitation, error)
+	GetByTenantID(ctx context.Context, tenantID uuid.UUID, limit, offset int) ([]*entities.Invitation, error)
+	GetPendingByTenantAndEmail(ctx context.Context, tenantID uuid.UUID, email string) ([]*entities.Invitation, error)
+	Update(ctx context.Context, invitation *entities.Invitation) error
+}
@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+// MembershipRepository defines the interface for tenant membership data operations
+type MembershipRepository interface {
+	Create(ctx context.Context, membership *entities.Membership) error
+	GetByTenantAndUser(ctx context.Context, tenantID, userID uuid.UUID) (*entities.Membership, error)
+	GetByTenantID(ctx context.Context, tenantID uuid.UUID, limit, offset int) ([]*entities.Membership, error)
+	GetByUserID(ctx context.Context, userID uuid.UUID) ([]*entities.Membership, error)
+	Update(ctx context.Context, membership *entities.Membership) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	CountByTenantID(ctx context.Context, tenantID uuid.UUID) (int, error)
+	CountByTenantAndRole(ctx context.Context, tenantID uuid.UUID, role string) (int, error)
+}
@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+// OIDCConfigRepository defines the interface for tenant OIDC configuration operations
+type OIDCConfigRepository interface {
+	Save(ctx context.Context, config *entities.OIDCConfig) error
+	GetByTenantID(ctx context.Context, tenantID uuid.UUID) (*entities.OIDCConfig, error)
+	Delete(ctx context.Context, tenantID uuid.UUID) error
+}
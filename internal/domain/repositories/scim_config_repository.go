@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+// SCIMConfigRepository defines the interface for tenant SCIM configuration data operations
+type SCIMConfigRepository interface {
+	Save(ctx context.Context, config *entities.SCIMConfig) error
+	GetByTenantID(ctx context.Context, tenantID uuid.UUID) (*entities.SCIMConfig, error)
+	GetByTokenHash(ctx context.Context, tokenHash string) (*entities.SCIMConfig, error)
+	Delete(ctx context.Context, tenantID uuid.UUID) error
+}
@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+// UserRepository defines the interface for user data operations
+type UserRepository interface {
+	Create(ctx context.Context, user *entities.User) error
+	GetByID(ctx context.Context, id uuid.UUID) (*entities.User, error)
+	GetByEmail(ctx context.Context, email string) (*entities.User, error)
+	GetByExternalID(ctx context.Context, tenantID uuid.UUID, provider, externalID string) (*entities.User, error)
+	GetByTenantID(ctx context.Context, tenantID uuid.UUID, limit, offset int) ([]*entities.User, error)
+	Update(ctx context.Context, user *entities.User) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	CountByTenantID(ctx context.Context, tenantID uuid.UUID) (int, error)
+}
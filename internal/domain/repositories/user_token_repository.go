@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/google/uuid"
+)
+
+// UserTokenRepository defines the interface for single-use user token data operations
+type UserTokenRepository interface {
+	Create(ctx context.Context, token *entities.UserToken) error
+	GetByHash(ctx context.Context, purpose, tokenHash string) (*entities.UserToken, error)
+	Update(ctx context.Context, token *entities.UserToken) error
+	DeleteByUser(ctx context.Context, userID uuid.UUID, purpose string) error
+	DeleteExpired(ctx context.Context) (int, error)
+}
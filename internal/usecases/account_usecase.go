@@ -0,0 +1,222 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/cloudparallax/parallax/internal/domain/repositories"
+	"github.com/cloudparallax/parallax/pkg/lifecycle"
+	"github.com/cloudparallax/parallax/pkg/logging"
+	"github.com/cloudparallax/parallax/pkg/mailer"
+	"github.com/google/uuid"
+)
+
+var logger = logging.Logger("usecases")
+
+// ErrInvalidToken is returned when an emailed token is unknown, used or expired
+var ErrInvalidToken = errors.New("invalid or expired token")
+
+// AccountConfig holds configuration for account recovery and verification emails
+type AccountConfig struct {
+	BaseURL              string        // Base URL of the web app that links in emails point to
+	PasswordResetTTL     time.Duration // How long a password reset link stays valid
+	EmailVerificationTTL time.Duration // How long an email verification link stays valid
+}
+
+// AccountUseCase handles password reset and email verification business logic
+type AccountUseCase struct {
+	userRepo   repositories.UserRepository
+	tokenRepo  repositories.UserTokenRepository
+	mailer     mailer.Mailer
+	background *lifecycle.Manager
+	config     AccountConfig
+}
+
+// NewAccountUseCase creates a new account use case; password reset emails are sent as tasks of background
+func NewAccountUseCase(userRepo repositories.UserRepository, tokenRepo repositories.UserTokenRepository, mailer mailer.Mailer, background *lifecycle.Manager, config AccountConfig) *AccountUseCase {
+	return &AccountUseCase{
+		userRepo:   userRepo,
+		tokenRepo:  tokenRepo,
+		mailer:     mailer,
+		background: background,
+		config:     config,
+	}
+}
+
+// RequestPasswordReset emails a password reset link to the user with the given email.
+// The account is looked up and the email sent in the background, so requests for unknown or
+// deactivated accounts, which get no email, answer as quickly as those for existing ones.
+func (uc *AccountUseCase) RequestPasswordReset(ctx context.Context, email string) error {
+	return uc.background.Go("password_reset_email", func(ctx context.Context) {
+		uc.sendPasswordReset(ctx, email)
+	})
+}
+
+// sendPasswordReset emails a password reset link if the email belongs to an active account;
+// failures are logged
+func (uc *AccountUseCase) sendPasswordReset(ctx context.Context, email string) {
+	user, err := uc.userRepo.GetByEmail(ctx, email)
+	if err != nil || !user.IsActive {
+		return
+	}
+
+	token, err := uc.issueToken(ctx, user.ID, entities.TokenPurposePasswordReset, uc.config.PasswordResetTTL)
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to issue password reset token", "user_id", user.ID.String(), "error", err)
+		return
+	}
+
+	link := uc.link("/reset-password", token)
+	body := fmt.Sprintf("Someone asked to reset the password for your Parallax account.\n\n"+
+		"Open this link within %s to choose a new password:\n%s\n\n"+
+		"If this wasn't you, you can ignore this email.", uc.config.PasswordResetTTL, link)
+
+	_ = uc.send(ctx, user.Email, "Reset your Parallax password", body)
+}
+
+// ResetPassword sets a new password using an emailed reset token.
+// Completing a reset also proves ownership of the email address.
+func (uc *AccountUseCase) ResetPassword(ctx context.Context, token, password string) (*entities.User, error) {
+	record, err := uc.tokenRepo.GetByHash(ctx, entities.TokenPurposePasswordReset, hashToken(token))
+	if err != nil || !record.IsValid() {
+		return nil, ErrInvalidToken
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, record.UserID)
+	if err != nil || !user.IsActive {
+		return nil, ErrInvalidToken
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	record.MarkUsed()
+	if err := uc.tokenRepo.Update(ctx, record); err != nil {
+		return nil, err
+	}
+
+	user.SetPasswordHash(hash)
+	user.VerifyEmail()
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	// Any other outstanding reset links are no longer needed
+	if err := uc.tokenRepo.DeleteByUser(ctx, user.ID, entities.TokenPurposePasswordReset); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// SendVerificationEmail emails an email verification link to a user
+func (uc *AccountUseCase) SendVerificationEmail(ctx context.Context, userID uuid.UUID) error {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if user.EmailVerified {
+		return errors.New("email address is already verified")
+	}
+
+	token, err := uc.issueToken(ctx, user.ID, entities.TokenPurposeEmailVerification, uc.config.EmailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	link := uc.link("/verify-email", token)
+	body := fmt.Sprintf("Please confirm that %s is your email address by opening this link within %s:\n%s",
+		user.Email, uc.config.EmailVerificationTTL, link)
+
+	return uc.send(ctx, user.Email, "Verify your Parallax email address", body)
+}
+
+// VerifyEmail marks a user's email address as verified using an emailed token
+func (uc *AccountUseCase) VerifyEmail(ctx context.Context, token string) (*entities.User, error) {
+	record, err := uc.tokenRepo.GetByHash(ctx, entities.TokenPurposeEmailVerification, hashToken(token))
+	if err != nil || !record.IsValid() {
+		return nil, ErrInvalidToken
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, record.UserID)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	record.MarkUsed()
+	if err := uc.tokenRepo.Update(ctx, record); err != nil {
+		return nil, err
+	}
+
+	user.VerifyEmail()
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// CleanupExpiredTokens removes expired reset and verification tokens
+func (uc *AccountUseCase) CleanupExpiredTokens(ctx context.Context) (int, error) {
+	return uc.tokenRepo.DeleteExpired(ctx)
+}
+
+// issueToken replaces a user's outstanding tokens for a purpose with a new one and returns its plaintext
+func (uc *AccountUseCase) issueToken(ctx context.Context, userID uuid.UUID, purpose string, ttl time.Duration) (string, error) {
+	token, err := generateToken()
+	if err != nil {
+		return "", err
+	}
+
+	if err := uc.tokenRepo.DeleteByUser(ctx, userID, purpose); err != nil {
+		return "", err
+	}
+
+	if err := uc.tokenRepo.Create(ctx, entities.NewUserToken(userID, purpose, hashToken(token), ttl)); err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// link builds a web app link carrying a token
+func (uc *AccountUseCase) link(path, token string) string {
+	return strings.TrimRight(uc.config.BaseURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+// send delivers an email, logging failures without exposing them to the caller
+func (uc *AccountUseCase) send(ctx context.Context, to, subject, body string) error {
+	err := uc.mailer.Send(ctx, mailer.Message{To: to, Subject: subject, Body: body})
+	if err != nil {
+		logger.ErrorContext(ctx, "Failed to send email", "subject", subject, "to", to, "error", err)
+		return errors.New("failed to send email")
+	}
+	return nil
+}
+
+// generateToken creates a random URL-safe token
+func generateToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// hashToken hashes a token for storage and lookup
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
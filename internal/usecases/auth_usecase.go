@@ -0,0 +1,324 @@
+package usecases
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/cloudparallax/parallax/internal/domain/repositories"
+	"github.com/cloudparallax/parallax/pkg/totp"
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// recoveryCodeCount is the number of recovery codes issued at a time
+	recoveryCodeCount = 10
+	// totpSkew is the number of time steps of clock drift tolerated on either side
+	totpSkew = 1
+	// minPasswordLength is the minimum accepted password length
+	minPasswordLength = 8
+)
+
+var (
+	// ErrInvalidCredentials is returned when a username or password is wrong
+	ErrInvalidCredentials = errors.New("invalid credentials")
+	// ErrInvalidMFACode is returned when a TOTP or recovery code is wrong
+	ErrInvalidMFACode = errors.New("invalid authentication code")
+)
+
+// dummyPasswordHash is compared against when a user does not exist, so that
+// unknown usernames take as long to reject as wrong passwords
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("parallax-dummy-password"), bcrypt.DefaultCost)
+
+// AuthUseCase handles password authentication and two-factor authentication
+type AuthUseCase struct {
+	userRepo   repositories.UserRepository
+	tenantRepo repositories.TenantRepository
+	issuer     string
+}
+
+// NewAuthUseCase creates a new authentication use case
+func NewAuthUseCase(userRepo repositories.UserRepository, tenantRepo repositories.TenantRepository) *AuthUseCase {
+	return &AuthUseCase{
+		userRepo:   userRepo,
+		tenantRepo: tenantRepo,
+		issuer:     "Parallax",
+	}
+}
+
+// Authenticate verifies an email address and password
+func (uc *AuthUseCase) Authenticate(ctx context.Context, email, password string) (*entities.User, error) {
+	user, err := uc.userRepo.GetByEmail(ctx, email)
+	if err != nil || user.PasswordHash == "" {
+		bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+		return nil, ErrInvalidCredentials
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	if !user.IsActive {
+		return nil, ErrInvalidCredentials
+	}
+
+	user.RecordLogin()
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// GetUser retrieves a user by ID
+func (uc *AuthUseCase) GetUser(ctx context.Context, id uuid.UUID) (*entities.User, error) {
+	return uc.userRepo.GetByID(ctx, id)
+}
+
+// SetPassword hashes and stores a new password for a user
+func (uc *AuthUseCase) SetPassword(ctx context.Context, userID uuid.UUID, password string) error {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	user.SetPasswordHash(hash)
+	return uc.userRepo.Update(ctx, user)
+}
+
+// ChangePassword replaces a user's password after verifying their current one
+func (uc *AuthUseCase) ChangePassword(ctx context.Context, userID uuid.UUID, currentPassword, newPassword string) (*entities.User, error) {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.PasswordHash == "" || bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(currentPassword)) != nil {
+		return nil, ErrInvalidCredentials
+	}
+
+	hash, err := hashPassword(newPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	user.SetPasswordHash(hash)
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// EnsureAdmin creates a platform admin account with the given credentials if no user has that email
+func (uc *AuthUseCase) EnsureAdmin(ctx context.Context, email, password string) (*entities.User, error) {
+	if user, err := uc.userRepo.GetByEmail(ctx, email); err == nil {
+		return user, nil
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := entities.NewUser(uuid.Nil, email, "", "", "admin")
+	user.SetPasswordHash(hash)
+
+	err = uc.userRepo.Create(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// RequiresMFA reports whether a user must pass a second factor to sign in,
+// either because they enrolled an authenticator or because their tenant requires it
+func (uc *AuthUseCase) RequiresMFA(ctx context.Context, user *entities.User) bool {
+	if user.TOTPEnabled {
+		return true
+	}
+
+	if user.TenantID == uuid.Nil {
+		return false
+	}
+
+	tenant, err := uc.tenantRepo.GetByID(ctx, user.TenantID)
+	return err == nil && tenant.RequireMFA
+}
+
+// BeginTOTPEnrollment generates a new TOTP secret for a user and returns it with its provisioning URI
+func (uc *AuthUseCase) BeginTOTPEnrollment(ctx context.Context, userID uuid.UUID) (string, string, error) {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return "", "", err
+	}
+
+	if user.TOTPEnabled {
+		return "", "", errors.New("two-factor authentication is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", "", err
+	}
+
+	user.StartTOTPEnrollment(secret)
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return "", "", err
+	}
+
+	return secret, totp.ProvisioningURI(uc.issuer, user.Email, secret), nil
+}
+
+// ConfirmTOTPEnrollment activates a pending TOTP secret once the user proves they can generate codes.
+// It returns the plaintext recovery codes, which are not retrievable afterwards.
+func (uc *AuthUseCase) ConfirmTOTPEnrollment(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if user.TOTPPendingSecret == "" {
+		return nil, errors.New("no two-factor enrollment in progress")
+	}
+
+	counter, ok := totp.Validate(code, user.TOTPPendingSecret, time.Now(), totpSkew)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	user.EnableTOTP(counter, hashes)
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// VerifyMFA checks a TOTP code or consumes a recovery code
+func (uc *AuthUseCase) VerifyMFA(ctx context.Context, userID uuid.UUID, code string) error {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if !user.TOTPEnabled {
+		return errors.New("two-factor authentication is not enabled")
+	}
+
+	if counter, ok := totp.Validate(code, user.TOTPSecret, time.Now(), totpSkew); ok {
+		// Each code is only accepted once
+		if counter <= user.TOTPLastCounter {
+			return ErrInvalidMFACode
+		}
+		user.TOTPLastCounter = counter
+		return uc.userRepo.Update(ctx, user)
+	}
+
+	if user.UseRecoveryCode(hashRecoveryCode(code)) {
+		return uc.userRepo.Update(ctx, user)
+	}
+
+	return ErrInvalidMFACode
+}
+
+// DisableTOTP removes a user's authenticator after verifying a current code
+func (uc *AuthUseCase) DisableTOTP(ctx context.Context, userID uuid.UUID, code string) error {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	if user.TenantID != uuid.Nil {
+		if tenant, err := uc.tenantRepo.GetByID(ctx, user.TenantID); err == nil && tenant.RequireMFA {
+			return errors.New("tenant policy requires two-factor authentication")
+		}
+	}
+
+	if err := uc.VerifyMFA(ctx, userID, code); err != nil {
+		return err
+	}
+
+	user.DisableTOTP()
+	return uc.userRepo.Update(ctx, user)
+}
+
+// RegenerateRecoveryCodes replaces a user's recovery codes after verifying a current code
+func (uc *AuthUseCase) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	if err := uc.VerifyMFA(ctx, userID, code); err != nil {
+		return nil, err
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	user.SetRecoveryCodes(hashes)
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// hashPassword validates and hashes a password with bcrypt
+func hashPassword(password string) (string, error) {
+	if len(password) < minPasswordLength {
+		return "", errors.New("password must be at least 8 characters")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// generateRecoveryCodes creates a set of one-time recovery codes and their hashes
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+
+	for i := range codes {
+		bytes := make([]byte, 5)
+		if _, err := rand.Read(bytes); err != nil {
+			return nil, nil, err
+		}
+		encoded := hex.EncodeToString(bytes)
+		codes[i] = encoded[:5] + "-" + encoded[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode normalizes and hashes a recovery code
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
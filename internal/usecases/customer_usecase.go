@@ -7,6 +7,8 @@ import (
 	"github.com/cloudparallax/parallax/internal/domain/entities"
 	"github.com/cloudparallax/parallax/internal/domain/repositories"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // CustomerUseCase handles customer business logic
@@ -24,7 +26,10 @@ func NewCustomerUseCase(customerRepo repositories.CustomerRepository, tenantRepo
 }
 
 // CreateCustomer creates a new customer
-func (uc *CustomerUseCase) CreateCustomer(ctx context.Context, tenantID uuid.UUID, firstName, lastName, email string) (*entities.Customer, error) {
+func (uc *CustomerUseCase) CreateCustomer(ctx context.Context, tenantID uuid.UUID, firstName, lastName, email string) (_ *entities.Customer, err error) {
+	ctx, span := startSpan(ctx, "CustomerUseCase.CreateCustomer", trace.WithAttributes(attribute.String("tenant.id", tenantID.String())))
+	defer endSpan(span, &err)
+
 	// Verify tenant exists and is active
 	tenant, err := uc.tenantRepo.GetByID(ctx, tenantID)
 	if err != nil {
@@ -46,32 +51,50 @@ func (uc *CustomerUseCase) CreateCustomer(ctx context.Context, tenantID uuid.UUI
 }
 
 // GetCustomer retrieves a customer by ID
-func (uc *CustomerUseCase) GetCustomer(ctx context.Context, id uuid.UUID) (*entities.Customer, error) {
+func (uc *CustomerUseCase) GetCustomer(ctx context.Context, id uuid.UUID) (_ *entities.Customer, err error) {
+	ctx, span := startSpan(ctx, "CustomerUseCase.GetCustomer", trace.WithAttributes(attribute.String("customer.id", id.String())))
+	defer endSpan(span, &err)
+
 	return uc.customerRepo.GetByID(ctx, id)
 }
 
 // GetCustomersByTenant retrieves customers by tenant ID with pagination
-func (uc *CustomerUseCase) GetCustomersByTenant(ctx context.Context, tenantID uuid.UUID, limit, offset int) ([]*entities.Customer, error) {
+func (uc *CustomerUseCase) GetCustomersByTenant(ctx context.Context, tenantID uuid.UUID, limit, offset int) (_ []*entities.Customer, err error) {
+	ctx, span := startSpan(ctx, "CustomerUseCase.GetCustomersByTenant", trace.WithAttributes(attribute.String("tenant.id", tenantID.String())))
+	defer endSpan(span, &err)
+
 	return uc.customerRepo.GetByTenantID(ctx, tenantID, limit, offset)
 }
 
 // GetCustomerByEmail retrieves a customer by email within a tenant
-func (uc *CustomerUseCase) GetCustomerByEmail(ctx context.Context, tenantID uuid.UUID, email string) (*entities.Customer, error) {
+func (uc *CustomerUseCase) GetCustomerByEmail(ctx context.Context, tenantID uuid.UUID, email string) (_ *entities.Customer, err error) {
+	ctx, span := startSpan(ctx, "CustomerUseCase.GetCustomerByEmail", trace.WithAttributes(attribute.String("tenant.id", tenantID.String())))
+	defer endSpan(span, &err)
+
 	return uc.customerRepo.GetByEmail(ctx, tenantID, email)
 }
 
 // SearchCustomersByName searches customers by name within a tenant
-func (uc *CustomerUseCase) SearchCustomersByName(ctx context.Context, tenantID uuid.UUID, query string, limit, offset int) ([]*entities.Customer, error) {
+func (uc *CustomerUseCase) SearchCustomersByName(ctx context.Context, tenantID uuid.UUID, query string, limit, offset int) (_ []*entities.Customer, err error) {
+	ctx, span := startSpan(ctx, "CustomerUseCase.SearchCustomersByName", trace.WithAttributes(attribute.String("tenant.id", tenantID.String())))
+	defer endSpan(span, &err)
+
 	return uc.customerRepo.SearchByName(ctx, tenantID, query, limit, offset)
 }
 
 // GetCustomersByTags retrieves customers by tags within a tenant
-func (uc *CustomerUseCase) GetCustomersByTags(ctx context.Context, tenantID uuid.UUID, tags []string, limit, offset int) ([]*entities.Customer, error) {
+func (uc *CustomerUseCase) GetCustomersByTags(ctx context.Context, tenantID uuid.UUID, tags []string, limit, offset int) (_ []*entities.Customer, err error) {
+	ctx, span := startSpan(ctx, "CustomerUseCase.GetCustomersByTags", trace.WithAttributes(attribute.String("tenant.id", tenantID.String())))
+	defer endSpan(span, &err)
+
 	return uc.customerRepo.GetByTags(ctx, tenantID, tags, limit, offset)
 }
 
 // UpdateCustomer updates customer information
-func (uc *CustomerUseCase) UpdateCustomer(ctx context.Context, id uuid.UUID, firstName, lastName, email, phone, address, city, state, country, postalCode, companyName, jobTitle, notes string, tags []string) (*entities.Customer, error) {
+func (uc *CustomerUseCase) UpdateCustomer(ctx context.Context, id uuid.UUID, firstName, lastName, email, phone, address, city, state, country, postalCode, companyName, jobTitle, notes string, tags []string) (_ *entities.Customer, err error) {
+	ctx, span := startSpan(ctx, "CustomerUseCase.UpdateCustomer", trace.WithAttributes(attribute.String("customer.id", id.String())))
+	defer endSpan(span, &err)
+
 	customer, err := uc.customerRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -88,7 +111,10 @@ func (uc *CustomerUseCase) UpdateCustomer(ctx context.Context, id uuid.UUID, fir
 }
 
 // ActivateCustomer activates a customer
-func (uc *CustomerUseCase) ActivateCustomer(ctx context.Context, id uuid.UUID) (*entities.Customer, error) {
+func (uc *CustomerUseCase) ActivateCustomer(ctx context.Context, id uuid.UUID) (_ *entities.Customer, err error) {
+	ctx, span := startSpan(ctx, "CustomerUseCase.ActivateCustomer", trace.WithAttributes(attribute.String("customer.id", id.String())))
+	defer endSpan(span, &err)
+
 	customer, err := uc.customerRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -105,7 +131,10 @@ func (uc *CustomerUseCase) ActivateCustomer(ctx context.Context, id uuid.UUID) (
 }
 
 // DeactivateCustomer deactivates a customer
-func (uc *CustomerUseCase) DeactivateCustomer(ctx context.Context, id uuid.UUID) (*entities.Customer, error) {
+func (uc *CustomerUseCase) DeactivateCustomer(ctx context.Context, id uuid.UUID) (_ *entities.Customer, err error) {
+	ctx, span := startSpan(ctx, "CustomerUseCase.DeactivateCustomer", trace.WithAttributes(attribute.String("customer.id", id.String())))
+	defer endSpan(span, &err)
+
 	customer, err := uc.customerRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -122,7 +151,10 @@ func (uc *CustomerUseCase) DeactivateCustomer(ctx context.Context, id uuid.UUID)
 }
 
 // AddCustomerTag adds a tag to a customer
-func (uc *CustomerUseCase) AddCustomerTag(ctx context.Context, id uuid.UUID, tag string) (*entities.Customer, error) {
+func (uc *CustomerUseCase) AddCustomerTag(ctx context.Context, id uuid.UUID, tag string) (_ *entities.Customer, err error) {
+	ctx, span := startSpan(ctx, "CustomerUseCase.AddCustomerTag", trace.WithAttributes(attribute.String("customer.id", id.String())))
+	defer endSpan(span, &err)
+
 	customer, err := uc.customerRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -139,7 +171,10 @@ func (uc *CustomerUseCase) AddCustomerTag(ctx context.Context, id uuid.UUID, tag
 }
 
 // RemoveCustomerTag removes a tag from a customer
-func (uc *CustomerUseCase) RemoveCustomerTag(ctx context.Context, id uuid.UUID, tag string) (*entities.Customer, error) {
+func (uc *CustomerUseCase) RemoveCustomerTag(ctx context.Context, id uuid.UUID, tag string) (_ *entities.Customer, err error) {
+	ctx, span := startSpan(ctx, "CustomerUseCase.RemoveCustomerTag", trace.WithAttributes(attribute.String("customer.id", id.String())))
+	defer endSpan(span, &err)
+
 	customer, err := uc.customerRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -156,11 +191,17 @@ func (uc *CustomerUseCase) RemoveCustomerTag(ctx context.Context, id uuid.UUID,
 }
 
 // DeleteCustomer deletes a customer
-func (uc *CustomerUseCase) DeleteCustomer(ctx context.Context, id uuid.UUID) error {
+func (uc *CustomerUseCase) DeleteCustomer(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := startSpan(ctx, "CustomerUseCase.DeleteCustomer", trace.WithAttributes(attribute.String("customer.id", id.String())))
+	defer endSpan(span, &err)
+
 	return uc.customerRepo.Delete(ctx, id)
 }
 
 // GetCustomerCount returns the count of customers for a tenant
-func (uc *CustomerUseCase) GetCustomerCount(ctx context.Context, tenantID uuid.UUID) (int, error) {
+func (uc *CustomerUseCase) GetCustomerCount(ctx context.Context, tenantID uuid.UUID) (_ int, err error) {
+	ctx, span := startSpan(ctx, "CustomerUseCase.GetCustomerCount", trace.WithAttributes(attribute.String("tenant.id", tenantID.String())))
+	defer endSpan(span, &err)
+
 	return uc.customerRepo.CountByTenantID(ctx, tenantID)
 }
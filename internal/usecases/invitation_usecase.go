@@ -0,0 +1,217 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/cloudparallax/parallax/internal/domain/repositories"
+	"github.com/cloudparallax/parallax/pkg/mailer"
+	"github.com/google/uuid"
+)
+
+// InvitationConfig holds configuration for tenant invitations
+type InvitationConfig struct {
+	BaseURL string        // Base URL of the web app that invitation links point to
+	TTL     time.Duration // How long an invitation stays valid
+}
+
+// InvitationUseCase handles tenant invitation business logic
+type InvitationUseCase struct {
+	invitationRepo repositories.InvitationRepository
+	membershipRepo repositories.MembershipRepository
+	userRepo       repositories.UserRepository
+	tenantRepo     repositories.TenantRepository
+	mailer         mailer.Mailer
+	config         InvitationConfig
+}
+
+// NewInvitationUseCase creates a new invitation use case
+func NewInvitationUseCase(invitationRepo repositories.InvitationRepository, membershipRepo repositories.MembershipRepository, userRepo repositories.UserRepository, tenantRepo repositories.TenantRepository, mailer mailer.Mailer, config InvitationConfig) *InvitationUseCase {
+	return &InvitationUseCase{
+		invitationRepo: invitationRepo,
+		membershipRepo: membershipRepo,
+		userRepo:       userRepo,
+		tenantRepo:     tenantRepo,
+		mailer:         mailer,
+		config:         config,
+	}
+}
+
+// CreateInvitation invites an email address to join a tenant with a role and emails the invitation link.
+// A new invitation replaces any pending one for the same address.
+func (uc *InvitationUseCase) CreateInvitation(ctx context.Context, tenantID, inviterID uuid.UUID, email, role, actorRole string) (*entities.Invitation, error) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return nil, errors.New("email is required")
+	}
+
+	if !entities.IsValidRole(role) {
+		return nil, errors.New("invalid role")
+	}
+
+	if !entities.RoleOutranks(actorRole, role) {
+		return nil, ErrRoleNotAssignable
+	}
+
+	tenant, err := uc.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, errors.New("tenant not found")
+	}
+
+	if !tenant.IsActive {
+		return nil, errors.New("tenant is not active")
+	}
+
+	if user, err := uc.userRepo.GetByEmail(ctx, email); err == nil {
+		if _, err := uc.membershipRepo.GetByTenantAndUser(ctx, tenantID, user.ID); err == nil {
+			return nil, errors.New("user is already a member of this tenant")
+		}
+	}
+
+	// Fail early rather than send an invitation that cannot be accepted
+	if err := ensureSeatAvailable(ctx, uc.tenantRepo, uc.membershipRepo, tenantID); err != nil {
+		return nil, err
+	}
+
+	pending, err := uc.invitationRepo.GetPendingByTenantAndEmail(ctx, tenantID, email)
+	if err != nil {
+		return nil, err
+	}
+	for _, previous := range pending {
+		previous.Revoke()
+		if err := uc.invitationRepo.Update(ctx, previous); err != nil {
+			return nil, err
+		}
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := entities.NewInvitation(tenantID, email, role, hashToken(token), inviterID, uc.config.TTL)
+
+	err = uc.invitationRepo.Create(ctx, invitation)
+	if err != nil {
+		return nil, err
+	}
+
+	link := strings.TrimRight(uc.config.BaseURL, "/") + "/accept-invitation?token=" + token
+	body := fmt.Sprintf("You have been invited to join %s on Parallax as %s.\n\n"+
+		"Open this link within %s to accept the invitation:\n%s",
+		tenant.Name, role, uc.config.TTL, link)
+
+	err = uc.mailer.Send(ctx, mailer.Message{To: invitation.Email, Subject: "You're invited to join " + tenant.Name + " on Parallax", Body: body})
+	if err != nil {
+		// Don't leave behind an invitation nobody received
+		invitation.Revoke()
+		uc.invitationRepo.Update(ctx, invitation)
+		return nil, errors.New("failed to send invitation email")
+	}
+
+	return invitation, nil
+}
+
+// ListInvitations retrieves a tenant's invitations with pagination
+func (uc *InvitationUseCase) ListInvitations(ctx context.Context, tenantID uuid.UUID, limit, offset int) ([]*entities.Invitation, error) {
+	return uc.invitationRepo.GetByTenantID(ctx, tenantID, limit, offset)
+}
+
+// RevokeInvitation revokes a tenant's pending invitation
+func (uc *InvitationUseCase) RevokeInvitation(ctx context.Context, tenantID, invitationID uuid.UUID) (*entities.Invitation, error) {
+	invitation, err := uc.invitationRepo.GetByID(ctx, invitationID)
+	if err != nil || invitation.TenantID != tenantID {
+		return nil, errors.New("invitation not found")
+	}
+
+	if !invitation.IsPending() {
+		return nil, errors.New("invitation is no longer pending")
+	}
+
+	invitation.Revoke()
+
+	err = uc.invitationRepo.Update(ctx, invitation)
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+// AcceptInvitation accepts an invitation with its emailed token. The invited email address
+// is attached to the tenant if it already has an account; otherwise an account is created
+// with the given password and name. Receiving the token proves ownership of the address.
+func (uc *InvitationUseCase) AcceptInvitation(ctx context.Context, token, password, firstName, lastName string) (*entities.User, *entities.Membership, error) {
+	invitation, err := uc.invitationRepo.GetByTokenHash(ctx, hashToken(token))
+	if err != nil || !invitation.IsPending() {
+		return nil, nil, ErrInvalidToken
+	}
+
+	tenant, err := uc.tenantRepo.GetByID(ctx, invitation.TenantID)
+	if err != nil || !tenant.IsActive {
+		return nil, nil, errors.New("tenant is not active")
+	}
+
+	if err := ensureSeatAvailable(ctx, uc.tenantRepo, uc.membershipRepo, invitation.TenantID); err != nil {
+		return nil, nil, err
+	}
+
+	user, err := uc.userRepo.GetByEmail(ctx, invitation.Email)
+	if err != nil {
+		user, err = uc.createInvitedUser(ctx, invitation, password, firstName, lastName)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		if !user.IsActive {
+			return nil, nil, errors.New("user account is deactivated")
+		}
+		user.VerifyEmail()
+		if err := uc.userRepo.Update(ctx, user); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	membership := entities.NewMembership(invitation.TenantID, user.ID, invitation.Role)
+
+	err = uc.membershipRepo.Create(ctx, membership)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	invitation.Accept()
+
+	err = uc.invitationRepo.Update(ctx, invitation)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, membership, nil
+}
+
+// createInvitedUser creates the account for an invited email address
+func (uc *InvitationUseCase) createInvitedUser(ctx context.Context, invitation *entities.Invitation, password, firstName, lastName string) (*entities.User, error) {
+	if password == "" {
+		return nil, errors.New("password is required to create an account")
+	}
+
+	hash, err := hashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := entities.NewUser(invitation.TenantID, invitation.Email, firstName, lastName, "user")
+	user.SetPasswordHash(hash)
+	user.VerifyEmail()
+
+	err = uc.userRepo.Create(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/cloudparallax/parallax/internal/domain/entities"
 	"github.com/cloudparallax/parallax/internal/domain/repositories"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // LocationUseCase handles location business logic
@@ -24,7 +26,10 @@ func NewLocationUseCase(locationRepo repositories.LocationRepository, tenantRepo
 }
 
 // CreateLocation creates a new location
-func (uc *LocationUseCase) CreateLocation(ctx context.Context, tenantID uuid.UUID, name, address, city, state, country, postalCode string) (*entities.Location, error) {
+func (uc *LocationUseCase) CreateLocation(ctx context.Context, tenantID uuid.UUID, name, address, city, state, country, postalCode string) (_ *entities.Location, err error) {
+	ctx, span := startSpan(ctx, "LocationUseCase.CreateLocation", trace.WithAttributes(attribute.String("tenant.id", tenantID.String())))
+	defer endSpan(span, &err)
+
 	// Verify tenant exists and is active
 	tenant, err := uc.tenantRepo.GetByID(ctx, tenantID)
 	if err != nil {
@@ -56,22 +61,34 @@ func (uc *LocationUseCase) CreateLocation(ctx context.Context, tenantID uuid.UUI
 }
 
 // GetLocation retrieves a location by ID
-func (uc *LocationUseCase) GetLocation(ctx context.Context, id uuid.UUID) (*entities.Location, error) {
+func (uc *LocationUseCase) GetLocation(ctx context.Context, id uuid.UUID) (_ *entities.Location, err error) {
+	ctx, span := startSpan(ctx, "LocationUseCase.GetLocation", trace.WithAttributes(attribute.String("location.id", id.String())))
+	defer endSpan(span, &err)
+
 	return uc.locationRepo.GetByID(ctx, id)
 }
 
 // GetLocationsByTenant retrieves locations by tenant ID with pagination
-func (uc *LocationUseCase) GetLocationsByTenant(ctx context.Context, tenantID uuid.UUID, limit, offset int) ([]*entities.Location, error) {
+func (uc *LocationUseCase) GetLocationsByTenant(ctx context.Context, tenantID uuid.UUID, limit, offset int) (_ []*entities.Location, err error) {
+	ctx, span := startSpan(ctx, "LocationUseCase.GetLocationsByTenant", trace.WithAttributes(attribute.String("tenant.id", tenantID.String())))
+	defer endSpan(span, &err)
+
 	return uc.locationRepo.GetByTenantID(ctx, tenantID, limit, offset)
 }
 
 // GetActiveLocationsByTenant retrieves active locations by tenant ID
-func (uc *LocationUseCase) GetActiveLocationsByTenant(ctx context.Context, tenantID uuid.UUID) ([]*entities.Location, error) {
+func (uc *LocationUseCase) GetActiveLocationsByTenant(ctx context.Context, tenantID uuid.UUID) (_ []*entities.Location, err error) {
+	ctx, span := startSpan(ctx, "LocationUseCase.GetActiveLocationsByTenant", trace.WithAttributes(attribute.String("tenant.id", tenantID.String())))
+	defer endSpan(span, &err)
+
 	return uc.locationRepo.GetActivByTenantID(ctx, tenantID)
 }
 
 // UpdateLocation updates location information
-func (uc *LocationUseCase) UpdateLocation(ctx context.Context, id uuid.UUID, name, address, city, state, country, postalCode, phone, email, description string, capacity int) (*entities.Location, error) {
+func (uc *LocationUseCase) UpdateLocation(ctx context.Context, id uuid.UUID, name, address, city, state, country, postalCode, phone, email, description string, capacity int) (_ *entities.Location, err error) {
+	ctx, span := startSpan(ctx, "LocationUseCase.UpdateLocation", trace.WithAttributes(attribute.String("location.id", id.String())))
+	defer endSpan(span, &err)
+
 	location, err := uc.locationRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -88,7 +105,10 @@ func (uc *LocationUseCase) UpdateLocation(ctx context.Context, id uuid.UUID, nam
 }
 
 // ActivateLocation activates a location
-func (uc *LocationUseCase) ActivateLocation(ctx context.Context, id uuid.UUID) (*entities.Location, error) {
+func (uc *LocationUseCase) ActivateLocation(ctx context.Context, id uuid.UUID) (_ *entities.Location, err error) {
+	ctx, span := startSpan(ctx, "LocationUseCase.ActivateLocation", trace.WithAttributes(attribute.String("location.id", id.String())))
+	defer endSpan(span, &err)
+
 	location, err := uc.locationRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -105,7 +125,10 @@ func (uc *LocationUseCase) ActivateLocation(ctx context.Context, id uuid.UUID) (
 }
 
 // DeactivateLocation deactivates a location
-func (uc *LocationUseCase) DeactivateLocation(ctx context.Context, id uuid.UUID) (*entities.Location, error) {
+func (uc *LocationUseCase) DeactivateLocation(ctx context.Context, id uuid.UUID) (_ *entities.Location, err error) {
+	ctx, span := startSpan(ctx, "LocationUseCase.DeactivateLocation", trace.WithAttributes(attribute.String("location.id", id.String())))
+	defer endSpan(span, &err)
+
 	location, err := uc.locationRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -122,11 +145,17 @@ func (uc *LocationUseCase) DeactivateLocation(ctx context.Context, id uuid.UUID)
 }
 
 // DeleteLocation deletes a location
-func (uc *LocationUseCase) DeleteLocation(ctx context.Context, id uuid.UUID) error {
+func (uc *LocationUseCase) DeleteLocation(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := startSpan(ctx, "LocationUseCase.DeleteLocation", trace.WithAttributes(attribute.String("location.id", id.String())))
+	defer endSpan(span, &err)
+
 	return uc.locationRepo.Delete(ctx, id)
 }
 
 // GetLocationCount returns the count of locations for a tenant
-func (uc *LocationUseCase) GetLocationCount(ctx context.Context, tenantID uuid.UUID) (int, error) {
+func (uc *LocationUseCase) GetLocationCount(ctx context.Context, tenantID uuid.UUID) (_ int, err error) {
+	ctx, span := startSpan(ctx, "LocationUseCase.GetLocationCount", trace.WithAttributes(attribute.String("tenant.id", tenantID.String())))
+	defer endSpan(span, &err)
+
 	return uc.locationRepo.CountByTenantID(ctx, tenantID)
 }
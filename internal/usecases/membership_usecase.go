@@ -0,0 +1,225 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/cloudparallax/parallax/internal/domain/repositories"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrLastOwner is returned when a change would leave a tenant without an owner
+	ErrLastOwner = errors.New("tenant must keep at least one owner")
+	// ErrRoleNotAssignable is returned when a member tries to grant or change a role above their own
+	ErrRoleNotAssignable = errors.New("cannot assign a role above your own")
+	// ErrMemberNotAdded is returned for any email that cannot be added directly, so that it does
+	// not tell whether an account exists
+	ErrMemberNotAdded = errors.New("user cannot be added; send them an invitation instead")
+)
+
+// Member combines a tenant membership with the user it belongs to
+type Member struct {
+	Membership *entities.Membership
+	User       *entities.User
+}
+
+// MembershipUseCase handles tenant membership and permission business logic
+type MembershipUseCase struct {
+	membershipRepo repositories.MembershipRepository
+	userRepo       repositories.UserRepository
+	tenantRepo     repositories.TenantRepository
+}
+
+// NewMembershipUseCase creates a new membership use case
+func NewMembershipUseCase(membershipRepo repositories.MembershipRepository, userRepo repositories.UserRepository, tenantRepo repositories.TenantRepository) *MembershipUseCase {
+	return &MembershipUseCase{
+		membershipRepo: membershipRepo,
+		userRepo:       userRepo,
+		tenantRepo:     tenantRepo,
+	}
+}
+
+// AddMember adds an existing user to a tenant with the given role.
+// actorRole is the tenant role of the member making the change.
+func (uc *MembershipUseCase) AddMember(ctx context.Context, tenantID uuid.UUID, email, role, actorRole string) (*Member, error) {
+	if !entities.IsValidRole(role) {
+		return nil, errors.New("invalid role")
+	}
+
+	if !entities.RoleOutranks(actorRole, role) {
+		return nil, ErrRoleNotAssignable
+	}
+
+	user, err := uc.userRepo.GetByEmail(ctx, email)
+	if err != nil {
+		return nil, ErrMemberNotAdded
+	}
+
+	membership, err := uc.createMembership(ctx, tenantID, user.ID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Member{Membership: membership, User: user}, nil
+}
+
+// CreateTenant creates a tenant owned by the user who creates it. The tenant is removed again
+// if its owner cannot be added, so no tenant is left without an owner.
+func (uc *MembershipUseCase) CreateTenant(ctx context.Context, ownerID uuid.UUID, name, domain, plan string, maxUsers, maxLocations int) (*entities.Tenant, error) {
+	tenant := entities.NewTenant(name, domain, plan, maxUsers, maxLocations)
+
+	if err := uc.tenantRepo.Create(ctx, tenant); err != nil {
+		return nil, err
+	}
+
+	if _, err := uc.createMembership(ctx, tenant.ID, ownerID, entities.RoleOwner); err != nil {
+		if deleteErr := uc.tenantRepo.Delete(ctx, tenant.ID); deleteErr != nil {
+			return nil, errors.Join(err, deleteErr)
+		}
+		return nil, err
+	}
+
+	return tenant, nil
+}
+
+// ListMembers retrieves a tenant's members with pagination
+func (uc *MembershipUseCase) ListMembers(ctx context.Context, tenantID uuid.UUID, limit, offset int) ([]*Member, error) {
+	memberships, err := uc.membershipRepo.GetByTenantID(ctx, tenantID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]*Member, 0, len(memberships))
+	for _, membership := range memberships {
+		user, err := uc.userRepo.GetByID(ctx, membership.UserID)
+		if err != nil {
+			continue
+		}
+		members = append(members, &Member{Membership: membership, User: user})
+	}
+
+	return members, nil
+}
+
+// UpdateMemberRole changes a member's role within a tenant
+func (uc *MembershipUseCase) UpdateMemberRole(ctx context.Context, tenantID, userID uuid.UUID, role, actorRole string) (*Member, error) {
+	if !entities.IsValidRole(role) {
+		return nil, errors.New("invalid role")
+	}
+
+	membership, err := uc.membershipRepo.GetByTenantAndUser(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Members can neither promote someone above themselves nor demote someone who outranks them
+	if !entities.RoleOutranks(actorRole, role) || !entities.RoleOutranks(actorRole, membership.Role) {
+		return nil, ErrRoleNotAssignable
+	}
+
+	if membership.Role == entities.RoleOwner && role != entities.RoleOwner {
+		if err := uc.ensureAnotherOwner(ctx, tenantID); err != nil {
+			return nil, err
+		}
+	}
+
+	membership.SetRole(role)
+
+	err = uc.membershipRepo.Update(ctx, membership)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Member{Membership: membership, User: user}, nil
+}
+
+// RemoveMember removes a user from a tenant
+func (uc *MembershipUseCase) RemoveMember(ctx context.Context, tenantID, userID uuid.UUID, actorRole string) error {
+	membership, err := uc.membershipRepo.GetByTenantAndUser(ctx, tenantID, userID)
+	if err != nil {
+		return err
+	}
+
+	if !entities.RoleOutranks(actorRole, membership.Role) {
+		return ErrRoleNotAssignable
+	}
+
+	if membership.Role == entities.RoleOwner {
+		if err := uc.ensureAnotherOwner(ctx, tenantID); err != nil {
+			return err
+		}
+	}
+
+	return uc.membershipRepo.Delete(ctx, membership.ID)
+}
+
+// GetPermissions returns the role and permissions a user holds in a tenant
+func (uc *MembershipUseCase) GetPermissions(ctx context.Context, userID, tenantID uuid.UUID) (string, []string, error) {
+	membership, err := uc.membershipRepo.GetByTenantAndUser(ctx, tenantID, userID)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return membership.Role, entities.RolePermissions(membership.Role), nil
+}
+
+// GetUserMemberships retrieves every tenant membership of a user
+func (uc *MembershipUseCase) GetUserMemberships(ctx context.Context, userID uuid.UUID) ([]*entities.Membership, error) {
+	return uc.membershipRepo.GetByUserID(ctx, userID)
+}
+
+// createMembership adds a user to a tenant, respecting the tenant's user limit
+func (uc *MembershipUseCase) createMembership(ctx context.Context, tenantID, userID uuid.UUID, role string) (*entities.Membership, error) {
+	if err := ensureSeatAvailable(ctx, uc.tenantRepo, uc.membershipRepo, tenantID); err != nil {
+		return nil, err
+	}
+
+	membership := entities.NewMembership(tenantID, userID, role)
+
+	err := uc.membershipRepo.Create(ctx, membership)
+	if err != nil {
+		return nil, err
+	}
+
+	return membership, nil
+}
+
+// ensureAnotherOwner checks that a tenant has more than one owner
+func (uc *MembershipUseCase) ensureAnotherOwner(ctx context.Context, tenantID uuid.UUID) error {
+	owners, err := uc.membershipRepo.CountByTenantAndRole(ctx, tenantID, entities.RoleOwner)
+	if err != nil {
+		return err
+	}
+
+	if owners <= 1 {
+		return ErrLastOwner
+	}
+
+	return nil
+}
+
+// ensureSeatAvailable checks that a tenant has not reached its user limit
+func ensureSeatAvailable(ctx context.Context, tenantRepo repositories.TenantRepository, membershipRepo repositories.MembershipRepository, tenantID uuid.UUID) error {
+	tenant, err := tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return errors.New("tenant not found")
+	}
+
+	count, err := membershipRepo.CountByTenantID(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	if count >= tenant.MaxUsers {
+		return errors.New("tenant has reached its user limit")
+	}
+
+	return nil
+}
@@ -0,0 +1,474 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/cloudparallax/parallax/internal/domain/repositories"
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrSCIMUnauthorized is returned when a SCIM bearer token is unknown or its tenant is inactive
+	ErrSCIMUnauthorized = errors.New("invalid provisioning token")
+	// ErrSCIMNotFound is returned when a SCIM resource does not exist in the tenant
+	ErrSCIMNotFound = errors.New("resource not found")
+	// ErrSCIMConflict is returned when a SCIM resource would duplicate an existing one
+	ErrSCIMConflict = errors.New("resource already exists")
+)
+
+// SCIMUserInput holds the user attributes a directory provisions
+type SCIMUserInput struct {
+	UserName    string
+	GivenName   string
+	FamilyName  string
+	DirectoryID string
+	Active      bool
+}
+
+// SCIMGroupInput holds the group attributes a directory provisions
+type SCIMGroupInput struct {
+	DisplayName string
+	ExternalID  string
+	MemberIDs   []uuid.UUID
+}
+
+// SCIMUseCase handles directory provisioning business logic.
+// A tenant's directory manages the users whose home tenant it is and who are members of it.
+type SCIMUseCase struct {
+	scimConfigRepo repositories.SCIMConfigRepository
+	groupRepo      repositories.GroupRepository
+	userRepo       repositories.UserRepository
+	membershipRepo repositories.MembershipRepository
+	tenantRepo     repositories.TenantRepository
+}
+
+// NewSCIMUseCase creates a new SCIM provisioning use case
+func NewSCIMUseCase(scimConfigRepo repositories.SCIMConfigRepository, groupRepo repositories.GroupRepository, userRepo repositories.UserRepository, membershipRepo repositories.MembershipRepository, tenantRepo repositories.TenantRepository) *SCIMUseCase {
+	return &SCIMUseCase{
+		scimConfigRepo: scimConfigRepo,
+		groupRepo:      groupRepo,
+		userRepo:       userRepo,
+		membershipRepo: membershipRepo,
+		tenantRepo:     tenantRepo,
+	}
+}
+
+// GenerateToken creates or rotates a tenant's SCIM bearer token and returns its plaintext,
+// which is not retrievable afterwards
+func (uc *SCIMUseCase) GenerateToken(ctx context.Context, tenantID uuid.UUID) (string, *entities.SCIMConfig, error) {
+	tenant, err := uc.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return "", nil, errors.New("tenant not found")
+	}
+
+	if tenant.Plan != "enterprise" {
+		return "", nil, errors.New("directory provisioning requires the enterprise plan")
+	}
+
+	token, err := generateToken()
+	if err != nil {
+		return "", nil, err
+	}
+	token = "scim_" + token
+
+	config, err := uc.scimConfigRepo.GetByTenantID(ctx, tenantID)
+	if err != nil {
+		config = entities.NewSCIMConfig(tenantID, hashToken(token))
+	} else {
+		config.SetToken(hashToken(token))
+	}
+
+	err = uc.scimConfigRepo.Save(ctx, config)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return token, config, nil
+}
+
+// GetConfig retrieves a tenant's SCIM configuration
+func (uc *SCIMUseCase) GetConfig(ctx context.Context, tenantID uuid.UUID) (*entities.SCIMConfig, error) {
+	return uc.scimConfigRepo.GetByTenantID(ctx, tenantID)
+}
+
+// SetGroupRoles sets which tenant role each directory group grants and re-applies roles to every provisioned user
+func (uc *SCIMUseCase) SetGroupRoles(ctx context.Context, tenantID uuid.UUID, groupRoles map[string]string, defaultRole string) (*entities.SCIMConfig, error) {
+	config, err := uc.scimConfigRepo.GetByTenantID(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if defaultRole != "" && !entities.IsValidRole(defaultRole) {
+		return nil, errors.New("default role is not a valid tenant role")
+	}
+	for group, role := range groupRoles {
+		if !entities.IsValidRole(role) {
+			return nil, errors.New("group " + group + " is mapped to an invalid tenant role: " + role)
+		}
+	}
+
+	config.SetGroupRoles(groupRoles, defaultRole)
+
+	err = uc.scimConfigRepo.Save(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := uc.ListUsers(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		if err := uc.syncRole(ctx, config, user.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return config, nil
+}
+
+// DeleteConfig removes a tenant's SCIM configuration, revoking its token
+func (uc *SCIMUseCase) DeleteConfig(ctx context.Context, tenantID uuid.UUID) error {
+	return uc.scimConfigRepo.Delete(ctx, tenantID)
+}
+
+// Authenticate resolves the tenant a SCIM bearer token belongs to
+func (uc *SCIMUseCase) Authenticate(ctx context.Context, token string) (uuid.UUID, error) {
+	config, err := uc.scimConfigRepo.GetByTokenHash(ctx, hashToken(token))
+	if err != nil {
+		return uuid.Nil, ErrSCIMUnauthorized
+	}
+
+	tenant, err := uc.tenantRepo.GetByID(ctx, config.TenantID)
+	if err != nil || !tenant.IsActive {
+		return uuid.Nil, ErrSCIMUnauthorized
+	}
+
+	config.RecordUse()
+	if err := uc.scimConfigRepo.Save(ctx, config); err != nil {
+		return uuid.Nil, err
+	}
+
+	return config.TenantID, nil
+}
+
+// ListUsers retrieves the users a tenant's directory manages
+func (uc *SCIMUseCase) ListUsers(ctx context.Context, tenantID uuid.UUID) ([]*entities.User, error) {
+	memberships, err := uc.membershipRepo.GetByTenantID(ctx, tenantID, math.MaxInt32, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make([]*entities.User, 0, len(memberships))
+	for _, membership := range memberships {
+		user, err := uc.userRepo.GetByID(ctx, membership.UserID)
+		if err != nil || user.TenantID != tenantID {
+			continue
+		}
+		users = append(users, user)
+	}
+
+	return users, nil
+}
+
+// GetUser retrieves a user the tenant's directory manages
+func (uc *SCIMUseCase) GetUser(ctx context.Context, tenantID, userID uuid.UUID) (*entities.User, error) {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil || user.TenantID != tenantID {
+		return nil, ErrSCIMNotFound
+	}
+
+	if _, err := uc.membershipRepo.GetByTenantAndUser(ctx, tenantID, userID); err != nil {
+		return nil, ErrSCIMNotFound
+	}
+
+	return user, nil
+}
+
+// GetUserGroups retrieves the tenant's groups a user belongs to
+func (uc *SCIMUseCase) GetUserGroups(ctx context.Context, tenantID, userID uuid.UUID) ([]*entities.Group, error) {
+	return uc.groupRepo.GetByMember(ctx, tenantID, userID)
+}
+
+// CreateUser provisions a user into a tenant. A user the directory deprovisioned earlier is re-attached.
+func (uc *SCIMUseCase) CreateUser(ctx context.Context, tenantID uuid.UUID, input SCIMUserInput) (*entities.User, error) {
+	if !strings.Contains(input.UserName, "@") {
+		return nil, errors.New("userName must be an email address")
+	}
+
+	config, err := uc.scimConfigRepo.GetByTenantID(ctx, tenantID)
+	if err != nil {
+		return nil, ErrSCIMUnauthorized
+	}
+
+	user, err := uc.userRepo.GetByEmail(ctx, input.UserName)
+	if err == nil {
+		if user.TenantID != tenantID {
+			return nil, ErrSCIMConflict
+		}
+		if _, err := uc.membershipRepo.GetByTenantAndUser(ctx, tenantID, user.ID); err == nil {
+			return nil, ErrSCIMConflict
+		}
+	}
+
+	if err := ensureSeatAvailable(ctx, uc.tenantRepo, uc.membershipRepo, tenantID); err != nil {
+		return nil, err
+	}
+
+	if user == nil {
+		user = entities.NewUser(tenantID, input.UserName, input.GivenName, input.FamilyName, "user")
+		uc.applyUserInput(user, input)
+		if err := uc.userRepo.Create(ctx, user); err != nil {
+			return nil, err
+		}
+	} else {
+		uc.applyUserInput(user, input)
+		if err := uc.userRepo.Update(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+
+	err = uc.membershipRepo.Create(ctx, entities.NewMembership(tenantID, user.ID, config.DefaultRole))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.syncRole(ctx, config, user.ID); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ReplaceUser updates a provisioned user's attributes
+func (uc *SCIMUseCase) ReplaceUser(ctx context.Context, tenantID, userID uuid.UUID, input SCIMUserInput) (*entities.User, error) {
+	user, err := uc.GetUser(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !strings.Contains(input.UserName, "@") {
+		return nil, errors.New("userName must be an email address")
+	}
+
+	if existing, err := uc.userRepo.GetByEmail(ctx, input.UserName); err == nil && existing.ID != user.ID {
+		return nil, ErrSCIMConflict
+	}
+
+	uc.applyUserInput(user, input)
+
+	err = uc.userRepo.Update(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// DeleteUser deprovisions a user: the account is deactivated and removed from the tenant and its groups
+func (uc *SCIMUseCase) DeleteUser(ctx context.Context, tenantID, userID uuid.UUID) (*entities.User, error) {
+	user, err := uc.GetUser(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	user.Deactivate()
+	if err := uc.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	groups, err := uc.groupRepo.GetByMember(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		group.RemoveMember(userID)
+		if err := uc.groupRepo.Update(ctx, group); err != nil {
+			return nil, err
+		}
+	}
+
+	membership, err := uc.membershipRepo.GetByTenantAndUser(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.membershipRepo.Delete(ctx, membership.ID); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ListGroups retrieves a tenant's directory groups
+func (uc *SCIMUseCase) ListGroups(ctx context.Context, tenantID uuid.UUID) ([]*entities.Group, error) {
+	return uc.groupRepo.GetByTenantID(ctx, tenantID)
+}
+
+// GetGroup retrieves one of a tenant's directory groups
+func (uc *SCIMUseCase) GetGroup(ctx context.Context, tenantID, groupID uuid.UUID) (*entities.Group, error) {
+	group, err := uc.groupRepo.GetByID(ctx, groupID)
+	if err != nil || group.TenantID != tenantID {
+		return nil, ErrSCIMNotFound
+	}
+
+	return group, nil
+}
+
+// CreateGroup provisions a directory group and applies the roles it grants to its members
+func (uc *SCIMUseCase) CreateGroup(ctx context.Context, tenantID uuid.UUID, input SCIMGroupInput) (*entities.Group, error) {
+	if input.DisplayName == "" {
+		return nil, errors.New("displayName is required")
+	}
+
+	if err := uc.validateMembers(ctx, tenantID, input.MemberIDs); err != nil {
+		return nil, err
+	}
+
+	group := entities.NewGroup(tenantID, input.DisplayName, input.ExternalID)
+	group.SetMembers(input.MemberIDs)
+
+	if err := uc.groupRepo.Create(ctx, group); err != nil {
+		return nil, ErrSCIMConflict
+	}
+
+	if err := uc.syncRoles(ctx, tenantID, group.MemberIDs); err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+// ReplaceGroup updates a directory group and re-applies roles to everyone who joined or left it
+func (uc *SCIMUseCase) ReplaceGroup(ctx context.Context, tenantID, groupID uuid.UUID, input SCIMGroupInput) (*entities.Group, error) {
+	group, err := uc.GetGroup(ctx, tenantID, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.DisplayName == "" {
+		return nil, errors.New("displayName is required")
+	}
+
+	if err := uc.validateMembers(ctx, tenantID, input.MemberIDs); err != nil {
+		return nil, err
+	}
+
+	affected := append(append([]uuid.UUID{}, group.MemberIDs...), input.MemberIDs...)
+
+	updated := *group
+	updated.Update(input.DisplayName, input.ExternalID)
+	updated.SetMembers(input.MemberIDs)
+
+	if err := uc.groupRepo.Update(ctx, &updated); err != nil {
+		return nil, ErrSCIMConflict
+	}
+
+	if err := uc.syncRoles(ctx, tenantID, affected); err != nil {
+		return nil, err
+	}
+
+	return &updated, nil
+}
+
+// DeleteGroup removes a directory group and re-applies roles to its former members
+func (uc *SCIMUseCase) DeleteGroup(ctx context.Context, tenantID, groupID uuid.UUID) error {
+	group, err := uc.GetGroup(ctx, tenantID, groupID)
+	if err != nil {
+		return err
+	}
+
+	if err := uc.groupRepo.Delete(ctx, group.ID); err != nil {
+		return err
+	}
+
+	return uc.syncRoles(ctx, tenantID, group.MemberIDs)
+}
+
+// applyUserInput copies directory attributes onto a user
+func (uc *SCIMUseCase) applyUserInput(user *entities.User, input SCIMUserInput) {
+	user.SetEmail(input.UserName)
+	user.Update(input.GivenName, input.FamilyName)
+	user.SetDirectoryID(input.DirectoryID)
+
+	if input.Active {
+		user.Activate()
+	} else {
+		user.Deactivate()
+	}
+}
+
+// validateMembers checks that every group member is a user the tenant's directory manages
+func (uc *SCIMUseCase) validateMembers(ctx context.Context, tenantID uuid.UUID, memberIDs []uuid.UUID) error {
+	for _, id := range memberIDs {
+		if _, err := uc.GetUser(ctx, tenantID, id); err != nil {
+			return errors.New("group member " + id.String() + " is not a provisioned user")
+		}
+	}
+	return nil
+}
+
+// syncRoles re-applies group-mapped roles to a set of users
+func (uc *SCIMUseCase) syncRoles(ctx context.Context, tenantID uuid.UUID, userIDs []uuid.UUID) error {
+	config, err := uc.scimConfigRepo.GetByTenantID(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range userIDs {
+		if err := uc.syncRole(ctx, config, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncRole gives a user the highest role mapped from their groups, or the default role when
+// none of their groups are mapped. Roles are left alone when no groups are mapped at all.
+func (uc *SCIMUseCase) syncRole(ctx context.Context, config *entities.SCIMConfig, userID uuid.UUID) error {
+	if !config.ManagesRoles() {
+		return nil
+	}
+
+	membership, err := uc.membershipRepo.GetByTenantAndUser(ctx, config.TenantID, userID)
+	if err != nil {
+		// Deprovisioned users keep their group history but have no role to sync
+		return nil
+	}
+
+	groups, err := uc.groupRepo.GetByMember(ctx, config.TenantID, userID)
+	if err != nil {
+		return err
+	}
+
+	role := ""
+	for _, group := range groups {
+		if mapped, ok := config.RoleForGroup(group.DisplayName); ok && (role == "" || entities.RoleOutranks(mapped, role)) {
+			role = mapped
+		}
+	}
+	if role == "" {
+		role = config.DefaultRole
+	}
+
+	if membership.Role == role {
+		return nil
+	}
+
+	if membership.Role == entities.RoleOwner {
+		owners, err := uc.membershipRepo.CountByTenantAndRole(ctx, config.TenantID, entities.RoleOwner)
+		if err != nil {
+			return err
+		}
+		if owners <= 1 {
+			logger.WarnContext(ctx, "SCIM role sync kept the last owner of a tenant", "tenant_id", config.TenantID.String(), "member_id", userID)
+			return nil
+		}
+	}
+
+	membership.SetRole(role)
+	return uc.membershipRepo.Update(ctx, membership)
+}
@@ -0,0 +1,257 @@
+package usecases
+
+import (
+	"context"
+	"errors"
+
+	"github.com/cloudparallax/parallax/internal/domain/entities"
+	"github.com/cloudparallax/parallax/internal/domain/repositories"
+	"github.com/google/uuid"
+)
+
+// OIDCIdentity represents an identity asserted by a tenant's identity provider
+type OIDCIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+	Claims        map[string]interface{}
+}
+
+// SSOUseCase handles single sign-on business logic
+type SSOUseCase struct {
+	oidcConfigRepo repositories.OIDCConfigRepository
+	userRepo       repositories.UserRepository
+	tenantRepo     repositories.TenantRepository
+	membershipRepo repositories.MembershipRepository
+}
+
+// NewSSOUseCase creates a new single sign-on use case
+func NewSSOUseCase(oidcConfigRepo repositories.OIDCConfigRepository, userRepo repositories.UserRepository, tenantRepo repositories.TenantRepository, membershipRepo repositories.MembershipRepository) *SSOUseCase {
+	return &SSOUseCase{
+		oidcConfigRepo: oidcConfigRepo,
+		userRepo:       userRepo,
+		tenantRepo:     tenantRepo,
+		membershipRepo: membershipRepo,
+	}
+}
+
+// ConfigureOIDC creates or updates a tenant's OIDC configuration
+func (uc *SSOUseCase) ConfigureOIDC(ctx context.Context, tenantID uuid.UUID, issuer, clientID, clientSecret, redirectURL string, scopes, allowedDomains []string, roleClaim string, roleMapping map[string]string, defaultRole string) (*entities.OIDCConfig, error) {
+	tenant, err := uc.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, errors.New("tenant not found")
+	}
+
+	if tenant.Plan != "enterprise" {
+		return nil, errors.New("single sign-on requires the enterprise plan")
+	}
+
+	if issuer == "" || clientID == "" {
+		return nil, errors.New("issuer and client ID are required")
+	}
+
+	if defaultRole != "" && !entities.IsValidRole(defaultRole) {
+		return nil, errors.New("default role is not a valid tenant role")
+	}
+	for _, role := range roleMapping {
+		if !entities.IsValidRole(role) {
+			return nil, errors.New("role mapping contains an invalid tenant role: " + role)
+		}
+	}
+
+	config, err := uc.oidcConfigRepo.GetByTenantID(ctx, tenantID)
+	if err != nil {
+		if clientSecret == "" {
+			return nil, errors.New("client secret is required")
+		}
+		config = entities.NewOIDCConfig(tenantID, issuer, clientID, clientSecret)
+	} else if clientSecret != "" {
+		config.SetClientSecret(clientSecret)
+	}
+
+	config.Update(issuer, clientID, redirectURL, scopes, allowedDomains, roleClaim, roleMapping, defaultRole)
+
+	err = uc.oidcConfigRepo.Save(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// GetOIDCConfig retrieves a tenant's OIDC configuration
+func (uc *SSOUseCase) GetOIDCConfig(ctx context.Context, tenantID uuid.UUID) (*entities.OIDCConfig, error) {
+	return uc.oidcConfigRepo.GetByTenantID(ctx, tenantID)
+}
+
+// GetLoginConfig retrieves a tenant's OIDC configuration if single sign-on is usable
+func (uc *SSOUseCase) GetLoginConfig(ctx context.Context, tenantID uuid.UUID) (*entities.OIDCConfig, error) {
+	tenant, err := uc.tenantRepo.GetByID(ctx, tenantID)
+	if err != nil {
+		return nil, errors.New("tenant not found")
+	}
+
+	if !tenant.IsActive {
+		return nil, errors.New("tenant is not active")
+	}
+
+	config, err := uc.oidcConfigRepo.GetByTenantID(ctx, tenantID)
+	if err != nil || !config.IsEnabled {
+		return nil, errors.New("single sign-on is not enabled for this tenant")
+	}
+
+	return config, nil
+}
+
+// SetOIDCEnabled enables or disables single sign-on for a tenant
+func (uc *SSOUseCase) SetOIDCEnabled(ctx context.Context, tenantID uuid.UUID, enabled bool) (*entities.OIDCConfig, error) {
+	config, err := uc.oidcConfigRepo.GetByTenantID(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	if enabled {
+		config.Enable()
+	} else {
+		config.Disable()
+	}
+
+	err = uc.oidcConfigRepo.Save(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// DeleteOIDCConfig removes a tenant's OIDC configuration
+func (uc *SSOUseCase) DeleteOIDCConfig(ctx context.Context, tenantID uuid.UUID) error {
+	return uc.oidcConfigRepo.Delete(ctx, tenantID)
+}
+
+// ProvisionOIDCUser finds or just-in-time creates the user for a verified identity
+// and syncs their tenant role from the identity provider's claims
+func (uc *SSOUseCase) ProvisionOIDCUser(ctx context.Context, config *entities.OIDCConfig, identity *OIDCIdentity) (*entities.User, error) {
+	if identity.Email == "" {
+		return nil, errors.New("identity provider did not return an email address")
+	}
+
+	if !identity.EmailVerified {
+		return nil, errors.New("email address is not verified by the identity provider")
+	}
+
+	if !config.IsEmailAllowed(identity.Email) {
+		return nil, errors.New("email domain is not allowed for this tenant")
+	}
+
+	// The mapped role only applies within the tenant; the platform role of SSO users stays "user"
+	role := config.MapRole(claimValues(identity.Claims[config.RoleClaim]))
+	if !entities.IsValidRole(role) {
+		return nil, errors.New("role mapping contains an invalid tenant role: " + role)
+	}
+
+	user, err := uc.userRepo.GetByExternalID(ctx, config.TenantID, entities.AuthProviderOIDC, identity.Subject)
+	if err != nil {
+		user, err = uc.userRepo.GetByEmail(ctx, identity.Email)
+		if err == nil && user.TenantID != config.TenantID {
+			return nil, errors.New("email is registered with another tenant")
+		}
+	}
+
+	if err != nil {
+		user, err = uc.createOIDCUser(ctx, config.TenantID, identity)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !user.IsActive {
+		return nil, errors.New("user account is deactivated")
+	}
+
+	if user.ExternalID != identity.Subject {
+		user.LinkExternalIdentity(entities.AuthProviderOIDC, identity.Subject)
+	}
+	user.VerifyEmail()
+	user.RecordLogin()
+
+	err = uc.userRepo.Update(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.syncMembership(ctx, config.TenantID, user.ID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// createOIDCUser provisions a new user for a first-time single sign-on login
+func (uc *SSOUseCase) createOIDCUser(ctx context.Context, tenantID uuid.UUID, identity *OIDCIdentity) (*entities.User, error) {
+	if err := ensureSeatAvailable(ctx, uc.tenantRepo, uc.membershipRepo, tenantID); err != nil {
+		return nil, err
+	}
+
+	user := entities.NewUser(tenantID, identity.Email, identity.GivenName, identity.FamilyName, "user")
+	user.LinkExternalIdentity(entities.AuthProviderOIDC, identity.Subject)
+
+	err := uc.userRepo.Create(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// syncMembership gives a user the role mapped from their identity provider claims in the tenant.
+// The last owner of a tenant keeps their role, so a login cannot leave the tenant without one.
+func (uc *SSOUseCase) syncMembership(ctx context.Context, tenantID, userID uuid.UUID, role string) error {
+	membership, err := uc.membershipRepo.GetByTenantAndUser(ctx, tenantID, userID)
+	if err != nil {
+		if err := ensureSeatAvailable(ctx, uc.tenantRepo, uc.membershipRepo, tenantID); err != nil {
+			return err
+		}
+		return uc.membershipRepo.Create(ctx, entities.NewMembership(tenantID, userID, role))
+	}
+
+	if membership.Role == role {
+		return nil
+	}
+
+	if membership.Role == entities.RoleOwner {
+		owners, err := uc.membershipRepo.CountByTenantAndRole(ctx, tenantID, entities.RoleOwner)
+		if err != nil {
+			return err
+		}
+		if owners <= 1 {
+			logger.WarnContext(ctx, "SSO role sync kept the last owner of a tenant", "tenant_id", tenantID.String(), "member_id", userID)
+			return nil
+		}
+	}
+
+	membership.SetRole(role)
+	return uc.membershipRepo.Update(ctx, membership)
+}
+
+// claimValues normalizes a string or list claim into a slice of strings
+func claimValues(claim interface{}) []string {
+	switch value := claim.(type) {
+	case string:
+		return []string{value}
+	case []string:
+		return value
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
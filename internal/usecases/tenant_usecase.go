@@ -2,12 +2,18 @@ package usecases
 
 import (
 	"context"
+	"errors"
+	"net/url"
+	"strings"
 
 	"github.com/cloudparallax/parallax/internal/domain/entities"
 	"github.com/cloudparallax/parallax/internal/domain/repositories"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
+
 // TenantUseCase handles tenant business logic
 type TenantUseCase struct {
 	tenantRepo repositories.TenantRepository
@@ -21,10 +27,13 @@ func NewTenantUseCase(tenantRepo repositories.TenantRepository) *TenantUseCase {
 }
 
 // CreateTenant creates a new tenant
-func (uc *TenantUseCase) CreateTenant(ctx context.Context, name, domain, plan string, maxUsers, maxLocations int) (*entities.Tenant, error) {
+func (uc *TenantUseCase) CreateTenant(ctx context.Context, name, domain, plan string, maxUsers, maxLocations int) (_ *entities.Tenant, err error) {
+	ctx, span := startSpan(ctx, "TenantUseCase.CreateTenant")
+	defer endSpan(span, &err)
+
 	tenant := entities.NewTenant(name, domain, plan, maxUsers, maxLocations)
 	
-	err := uc.tenantRepo.Create(ctx, tenant)
+	err = uc.tenantRepo.Create(ctx, tenant)
 	if err != nil {
 		return nil, err
 	}
@@ -33,28 +42,40 @@ func (uc *TenantUseCase) CreateTenant(ctx context.Context, name, domain, plan st
 }
 
 // GetTenant retrieves a tenant by ID
-func (uc *TenantUseCase) GetTenant(ctx context.Context, id uuid.UUID) (*entities.Tenant, error) {
+func (uc *TenantUseCase) GetTenant(ctx context.Context, id uuid.UUID) (_ *entities.Tenant, err error) {
+	ctx, span := startSpan(ctx, "TenantUseCase.GetTenant", trace.WithAttributes(attribute.String("tenant.id", id.String())))
+	defer endSpan(span, &err)
+
 	return uc.tenantRepo.GetByID(ctx, id)
 }
 
 // GetTenantByDomain retrieves a tenant by domain
-func (uc *TenantUseCase) GetTenantByDomain(ctx context.Context, domain string) (*entities.Tenant, error) {
+func (uc *TenantUseCase) GetTenantByDomain(ctx context.Context, domain string) (_ *entities.Tenant, err error) {
+	ctx, span := startSpan(ctx, "TenantUseCase.GetTenantByDomain")
+	defer endSpan(span, &err)
+
 	return uc.tenantRepo.GetByDomain(ctx, domain)
 }
 
 // GetAllTenants retrieves all tenants with pagination
-func (uc *TenantUseCase) GetAllTenants(ctx context.Context, limit, offset int) ([]*entities.Tenant, error) {
+func (uc *TenantUseCase) GetAllTenants(ctx context.Context, limit, offset int) (_ []*entities.Tenant, err error) {
+	ctx, span := startSpan(ctx, "TenantUseCase.GetAllTenants")
+	defer endSpan(span, &err)
+
 	return uc.tenantRepo.GetAll(ctx, limit, offset)
 }
 
 // UpdateTenant updates tenant information
-func (uc *TenantUseCase) UpdateTenant(ctx context.Context, id uuid.UUID, name, plan string, maxUsers, maxLocations int) (*entities.Tenant, error) {
+func (uc *TenantUseCase) UpdateTenant(ctx context.Context, id uuid.UUID, name string) (_ *entities.Tenant, err error) {
+	ctx, span := startSpan(ctx, "TenantUseCase.UpdateTenant", trace.WithAttributes(attribute.String("tenant.id", id.String())))
+	defer endSpan(span, &err)
+
 	tenant, err := uc.tenantRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	
-	tenant.Update(name, plan, maxUsers, maxLocations)
+	tenant.Update(name)
 	
 	err = uc.tenantRepo.Update(ctx, tenant)
 	if err != nil {
@@ -64,8 +85,31 @@ func (uc *TenantUseCase) UpdateTenant(ctx context.Context, id uuid.UUID, name, p
 	return tenant, nil
 }
 
+// SetTenantPlan changes a tenant's plan and limits
+func (uc *TenantUseCase) SetTenantPlan(ctx context.Context, id uuid.UUID, plan string, maxUsers, maxLocations int) (_ *entities.Tenant, err error) {
+	ctx, span := startSpan(ctx, "TenantUseCase.SetTenantPlan", trace.WithAttributes(attribute.String("tenant.id", id.String()), attribute.String("tenant.plan", plan)))
+	defer endSpan(span, &err)
+
+	tenant, err := uc.tenantRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	tenant.SetPlan(plan, maxUsers, maxLocations)
+
+	err = uc.tenantRepo.Update(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	return tenant, nil
+}
+
 // ActivateTenant activates a tenant
-func (uc *TenantUseCase) ActivateTenant(ctx context.Context, id uuid.UUID) (*entities.Tenant, error) {
+func (uc *TenantUseCase) ActivateTenant(ctx context.Context, id uuid.UUID) (_ *entities.Tenant, err error) {
+	ctx, span := startSpan(ctx, "TenantUseCase.ActivateTenant", trace.WithAttributes(attribute.String("tenant.id", id.String())))
+	defer endSpan(span, &err)
+
 	tenant, err := uc.tenantRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -82,7 +126,10 @@ func (uc *TenantUseCase) ActivateTenant(ctx context.Context, id uuid.UUID) (*ent
 }
 
 // DeactivateTenant deactivates a tenant
-func (uc *TenantUseCase) DeactivateTenant(ctx context.Context, id uuid.UUID) (*entities.Tenant, error) {
+func (uc *TenantUseCase) DeactivateTenant(ctx context.Context, id uuid.UUID) (_ *entities.Tenant, err error) {
+	ctx, span := startSpan(ctx, "TenantUseCase.DeactivateTenant", trace.WithAttributes(attribute.String("tenant.id", id.String())))
+	defer endSpan(span, &err)
+
 	tenant, err := uc.tenantRepo.GetByID(ctx, id)
 	if err != nil {
 		return nil, err
@@ -98,12 +145,69 @@ func (uc *TenantUseCase) DeactivateTenant(ctx context.Context, id uuid.UUID) (*e
 	return tenant, nil
 }
 
+// SetMFAPolicy sets whether a tenant requires two-factor authentication for all users
+func (uc *TenantUseCase) SetMFAPolicy(ctx context.Context, id uuid.UUID, required bool) (_ *entities.Tenant, err error) {
+	ctx, span := startSpan(ctx, "TenantUseCase.SetMFAPolicy", trace.WithAttributes(attribute.String("tenant.id", id.String())))
+	defer endSpan(span, &err)
+
+	tenant, err := uc.tenantRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	
+	tenant.SetMFARequired(required)
+	
+	err = uc.tenantRepo.Update(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+	
+	return tenant, nil
+}
+
+// SetAllowedOrigins sets the browser origins allowed to call the API for a tenant.
+// Each origin must be a scheme and host with an optional port, such as https://app.example.com.
+func (uc *TenantUseCase) SetAllowedOrigins(ctx context.Context, id uuid.UUID, origins []string) (_ *entities.Tenant, err error) {
+	ctx, span := startSpan(ctx, "TenantUseCase.SetAllowedOrigins", trace.WithAttributes(attribute.String("tenant.id", id.String())))
+	defer endSpan(span, &err)
+
+	tenant, err := uc.tenantRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := make([]string, 0, len(origins))
+	for _, origin := range origins {
+		parsed, err := url.Parse(strings.TrimSpace(origin))
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" ||
+			strings.Trim(parsed.Path, "/") != "" || parsed.RawQuery != "" || parsed.Fragment != "" || parsed.User != nil {
+			return nil, errors.New("invalid origin: " + origin)
+		}
+		normalized = append(normalized, strings.ToLower(parsed.Scheme+"://"+parsed.Host))
+	}
+
+	tenant.SetAllowedOrigins(normalized)
+
+	err = uc.tenantRepo.Update(ctx, tenant)
+	if err != nil {
+		return nil, err
+	}
+
+	return tenant, nil
+}
+
 // DeleteTenant deletes a tenant
-func (uc *TenantUseCase) DeleteTenant(ctx context.Context, id uuid.UUID) error {
+func (uc *TenantUseCase) DeleteTenant(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := startSpan(ctx, "TenantUseCase.DeleteTenant", trace.WithAttributes(attribute.String("tenant.id", id.String())))
+	defer endSpan(span, &err)
+
 	return uc.tenantRepo.Delete(ctx, id)
 }
 
 // GetActiveTenantCount returns the count of active tenants
-func (uc *TenantUseCase) GetActiveTenantCount(ctx context.Context) (int, error) {
+func (uc *TenantUseCase) GetActiveTenantCount(ctx context.Context) (_ int, err error) {
+	ctx, span := startSpan(ctx, "TenantUseCase.GetActiveTenantCount")
+	defer endSpan(span, &err)
+
 	return uc.tenantRepo.GetActiveCount(ctx)
 }
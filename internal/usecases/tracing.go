@@ -0,0 +1,28 @@
+package usecases
+
+import (
+	"context"
+
+	"github.com/cloudparallax/parallax/pkg/tracing"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/cloudparallax/parallax/internal/usecases")
+
+// startSpan starts a span as a child of the one in ctx, or of the server span of the request
+// ctx belongs to
+func startSpan(ctx context.Context, name string, options ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return tracer.Start(tracing.Context(ctx), name, options...)
+}
+
+// endSpan records *err, if any, and ends the span. It is meant to be deferred with a pointer
+// to a named error result.
+func endSpan(span trace.Span, err *error) {
+	if *err != nil {
+		span.RecordError(*err)
+		span.SetStatus(codes.Error, (*err).Error())
+	}
+	span.End()
+}
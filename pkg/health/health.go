@@ -0,0 +1,196 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Check reports whether a dependency is usable; it should return promptly once ctx is done
+type Check func(ctx context.Context) error
+
+// State is the lifecycle state of the service
+type State int32
+
+// Lifecycle states; the service is only ready to receive traffic in StateReady
+const (
+	StateStarting State = iota
+	StateReady
+	StateStopping
+)
+
+// String returns the state's name
+func (s State) String() string {
+	switch s {
+	case StateStarting:
+		return "starting"
+	case StateReady:
+		return "ready"
+	case StateStopping:
+		return "stopping"
+	default:
+		return "unknown"
+	}
+}
+
+// Check and report statuses
+const (
+	StatusOK          = "ok"
+	StatusFail        = "fail"
+	StatusTimeout     = "timeout"
+	StatusUnavailable = "unavailable"
+)
+
+// Config holds configuration for the health check registry
+type Config struct {
+	Timeout time.Duration // Longest time each check may take before it fails
+}
+
+// CheckResult is the outcome of one check. Error is left out of JSON, since errors such as
+// dial failures name internal hosts; log it instead.
+type CheckResult struct {
+	Status    string  `json:"status"`
+	LatencyMs float64 `json:"latency_ms"`
+	Error     string  `json:"-"`
+}
+
+// Report is the outcome of a readiness check
+type Report struct {
+	Status string                 `json:"status"`
+	State  string                 `json:"state"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+// Ready reports whether the service can receive traffic
+func (r Report) Ready() bool {
+	return r.Status == StatusOK
+}
+
+// Registry holds the health checks that repositories, session stores and other backends
+// register, and the service's lifecycle state
+type Registry struct {
+	checks  map[string]Check
+	timeout time.Duration
+	state   atomic.Int32
+	mutex   sync.RWMutex
+}
+
+// NewRegistry creates a registry in the starting state
+func NewRegistry(config ...Config) *Registry {
+	cfg := Config{Timeout: 2 * time.Second}
+	if len(config) > 0 {
+		cfg = config[0]
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 2 * time.Second
+	}
+
+	return &Registry{
+		checks:  make(map[string]Check),
+		timeout: cfg.Timeout,
+	}
+}
+
+// Register adds a check, replacing any check registered under the same name
+func (r *Registry) Register(name string, check Check) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.checks[name] = check
+}
+
+// SetState changes the lifecycle state
+func (r *Registry) SetState(state State) {
+	r.state.Store(int32(state))
+}
+
+// State returns the lifecycle state
+func (r *Registry) State() State {
+	return State(r.state.Load())
+}
+
+// Check runs every check concurrently. The report is only ready when the service is in the
+// ready state and every check passes.
+func (r *Registry) Check(ctx context.Context) Report {
+	r.mutex.RLock()
+	names := make([]string, 0, len(r.checks))
+	for name := range r.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	checks := make([]Check, len(names))
+	for i, name := range names {
+		checks[i] = r.checks[name]
+	}
+	r.mutex.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = r.run(ctx, check)
+		}()
+	}
+	wg.Wait()
+
+	state := r.State()
+	report := Report{
+		Status: StatusOK,
+		State:  state.String(),
+		Checks: make(map[string]CheckResult, len(names)),
+	}
+	if state != StateReady {
+		report.Status = StatusUnavailable
+	}
+	for i, name := range names {
+		report.Checks[name] = results[i]
+		if results[i].Status != StatusOK {
+			report.Status = StatusUnavailable
+		}
+	}
+	return report
+}
+
+// run runs one check within the timeout
+func (r *Registry) run(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				done <- fmt.Errorf("check panicked: %v", recovered)
+			}
+		}()
+		done <- check(ctx)
+	}()
+
+	// A check that ignores its context still fails at the timeout
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result := CheckResult{
+		Status:    StatusOK,
+		LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		result.Status = StatusFail
+		result.Error = err.Error()
+		if errors.Is(err, context.DeadlineExceeded) {
+			result.Status = StatusTimeout
+			result.Error = "timed out after " + r.timeout.String()
+		}
+	}
+	return result
+}
@@ -0,0 +1,134 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cloudparallax/parallax/pkg/logging"
+)
+
+var logger = logging.Logger("lifecycle")
+
+// Task is a background loop; it should return once ctx is done
+type Task func(ctx context.Context)
+
+// Hook releases a resource at shutdown, such as flushing an exporter
+type Hook func(ctx context.Context) error
+
+// ErrStopped is returned when a task is started after shutdown has begun
+var ErrStopped = errors.New("lifecycle: manager is shutting down")
+
+// Manager runs background tasks with a shared context that is cancelled at shutdown, waits
+// for them to return, then runs shutdown hooks
+type Manager struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	tasks   sync.WaitGroup
+	hooks   []namedHook
+	stopped bool
+	mutex   sync.Mutex
+}
+
+// namedHook is a shutdown hook and the name it is logged under
+type namedHook struct {
+	name string
+	hook Hook
+}
+
+// NewManager creates a lifecycle manager
+func NewManager() *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{ctx: ctx, cancel: cancel}
+}
+
+// Go starts a background task. A panic in the task is logged rather than crashing the process.
+func (m *Manager) Go(name string, task Task) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if m.stopped {
+		return ErrStopped
+	}
+
+	m.tasks.Add(1)
+	go func() {
+		defer m.tasks.Done()
+		defer func() {
+			if recovered := recover(); recovered != nil {
+				logger.Error("Background task panicked", "task", name, "panic", fmt.Sprint(recovered))
+			}
+		}()
+
+		logger.Debug("Background task started", "task", name)
+		task(m.ctx)
+		logger.Debug("Background task stopped", "task", name)
+	}()
+	return nil
+}
+
+// Every starts a background task that calls fn at each interval until shutdown
+func (m *Manager) Every(name string, interval time.Duration, fn Task) error {
+	return m.Go(name, func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				fn(ctx)
+			}
+		}
+	})
+}
+
+// OnShutdown adds a hook that runs after every task has returned; hooks run in reverse order
+func (m *Manager) OnShutdown(name string, hook Hook) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.hooks = append(m.hooks, namedHook{name: name, hook: hook})
+}
+
+// Context returns the context cancelled at shutdown
+func (m *Manager) Context() context.Context {
+	return m.ctx
+}
+
+// Shutdown cancels the tasks' context, waits for them to return and runs the shutdown hooks.
+// Once ctx is done it stops waiting and returns the context's error with any hook errors.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mutex.Lock()
+	if m.stopped {
+		m.mutex.Unlock()
+		return nil
+	}
+	m.stopped = true
+	hooks := m.hooks
+	m.mutex.Unlock()
+
+	m.cancel()
+
+	var errs []error
+	done := make(chan struct{})
+	go func() {
+		m.tasks.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		logger.Warn("Background tasks did not stop in time")
+		errs = append(errs, ctx.Err())
+	}
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := hooks[i].hook(ctx); err != nil {
+			logger.Warn("Shutdown hook failed", "hook", hooks[i].name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", hooks[i].name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
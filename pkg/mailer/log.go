@@ -0,0 +1,37 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// LogMailer writes messages to an output stream instead of sending them, for development
+type LogMailer struct {
+	out   io.Writer
+	mutex sync.Mutex
+}
+
+// NewLogMailer creates a mailer that prints messages to stdout, or to the given writer
+func NewLogMailer(out ...io.Writer) *LogMailer {
+	var writer io.Writer = os.Stdout
+	if len(out) > 0 && out[0] != nil {
+		writer = out[0]
+	}
+	return &LogMailer{out: writer}
+}
+
+// Send prints the message
+func (m *LogMailer) Send(ctx context.Context, message Message) error {
+	if err := message.validate(); err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	_, err := fmt.Fprintf(m.out, "---- mail ----\nTo: %s\nSubject: %s\n\n%s\n--------------\n", message.To, message.Subject, message.Body)
+	return err
+}
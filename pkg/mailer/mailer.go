@@ -0,0 +1,33 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"strings"
+)
+
+// Message represents a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers email messages
+type Mailer interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// ErrInvalidHeader is returned when a recipient or subject contains line breaks
+var ErrInvalidHeader = errors.New("mail headers must not contain line breaks")
+
+// validate rejects messages whose header values could inject extra headers
+func (m Message) validate() error {
+	if m.To == "" {
+		return errors.New("mail recipient is required")
+	}
+	if strings.ContainsAny(m.To, "\r\n") || strings.ContainsAny(m.Subject, "\r\n") {
+		return ErrInvalidHeader
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package mailer
+
+import (
+	"context"
+	"sync"
+)
+
+// MemoryMailer records messages in memory so tests can inspect them
+type MemoryMailer struct {
+	messages []Message
+	mutex    sync.Mutex
+}
+
+// NewMemoryMailer creates a new in-memory mailer
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+// Send records the message
+func (m *MemoryMailer) Send(ctx context.Context, message Message) error {
+	if err := message.validate(); err != nil {
+		return err
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+// Messages returns every message sent so far
+func (m *MemoryMailer) Messages() []Message {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return append([]Message(nil), m.messages...)
+}
+
+// Last returns the most recent message sent to a recipient
+func (m *MemoryMailer) Last(to string) (Message, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].To == to {
+			return m.messages[i], true
+		}
+	}
+	return Message{}, false
+}
+
+// Reset discards all recorded messages
+func (m *MemoryMailer) Reset() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.messages = nil
+}
@@ -0,0 +1,361 @@
+// Package metrics is a minimal registry of counters, gauges and histograms, exposed in the
+// Prometheus text format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the content type of the text exposition format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// DefaultBuckets are histogram buckets for request latencies in seconds
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Sample is a value reported by a gauge function, with its label values in the gauge's label order
+type Sample struct {
+	Labels []string
+	Value  float64
+}
+
+// Registry holds metrics and writes them out; it is safe for concurrent use
+type Registry struct {
+	families []family
+	names    map[string]bool
+	mutex    sync.Mutex
+}
+
+// family is a named metric with all its series
+type family interface {
+	write(w *bufio.Writer)
+}
+
+// desc describes a metric family
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{names: make(map[string]bool)}
+}
+
+// register adds a family; metric names are unique
+func (r *Registry) register(d desc, f family) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.names[d.name] {
+		panic("metrics: duplicate metric " + d.name)
+	}
+	r.names[d.name] = true
+	r.families = append(r.families, f)
+}
+
+// WriteTo writes every metric in the text exposition format
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mutex.Lock()
+	families := append([]family(nil), r.families...)
+	r.mutex.Unlock()
+
+	counter := &countingWriter{w: w}
+	buffered := bufio.NewWriter(counter)
+	for _, f := range families {
+		f.write(buffered)
+	}
+	err := buffered.Flush()
+	return counter.n, err
+}
+
+// Counter is a value that only goes up, such as a number of requests
+type Counter struct {
+	desc
+	series map[string]*series
+	mutex  sync.Mutex
+}
+
+// NewCounter registers a counter with the given label names
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{desc: desc{name: name, help: help, kind: "counter", labels: labels}, series: make(map[string]*series)}
+	r.register(c.desc, c)
+	return c
+}
+
+// Inc adds one to the series with the given label values
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds a non-negative amount to the series with the given label values
+func (c *Counter) Add(value float64, labelValues ...string) {
+	if value < 0 {
+		panic("metrics: counter " + c.name + " cannot decrease")
+	}
+	c.mutex.Lock()
+	getSeries(c.series, c.desc, labelValues).value += value
+	c.mutex.Unlock()
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	writeSeries(w, c.desc, c.series)
+}
+
+// Gauge is a value that goes up and down, such as requests in flight
+type Gauge struct {
+	desc
+	series map[string]*series
+	mutex  sync.Mutex
+}
+
+// NewGauge registers a gauge with the given label names
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{desc: desc{name: name, help: help, kind: "gauge", labels: labels}, series: make(map[string]*series)}
+	r.register(g.desc, g)
+	return g
+}
+
+// Set sets the series with the given label values
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.mutex.Lock()
+	getSeries(g.series, g.desc, labelValues).value = value
+	g.mutex.Unlock()
+}
+
+// Add adds an amount, which may be negative, to the series with the given label values
+func (g *Gauge) Add(value float64, labelValues ...string) {
+	g.mutex.Lock()
+	getSeries(g.series, g.desc, labelValues).value += value
+	g.mutex.Unlock()
+}
+
+// Inc adds one to the series with the given label values
+func (g *Gauge) Inc(labelValues ...string) {
+	g.Add(1, labelValues...)
+}
+
+// Dec subtracts one from the series with the given label values
+func (g *Gauge) Dec(labelValues ...string) {
+	g.Add(-1, labelValues...)
+}
+
+func (g *Gauge) write(w *bufio.Writer) {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	writeSeries(w, g.desc, g.series)
+}
+
+// gaugeFunc is a gauge whose samples are read when metrics are written
+type gaugeFunc struct {
+	desc
+	collect func() []Sample
+}
+
+// NewGaugeFunc registers a gauge whose samples are collected each time metrics are written,
+// for values that are cheaper to read than to track, such as counts of stored records
+func (r *Registry) NewGaugeFunc(name, help string, labels []string, collect func() []Sample) {
+	g := &gaugeFunc{desc: desc{name: name, help: help, kind: "gauge", labels: labels}, collect: collect}
+	r.register(g.desc, g)
+}
+
+func (g *gaugeFunc) write(w *bufio.Writer) {
+	collected := make(map[string]*series)
+	for _, sample := range g.collect() {
+		getSeries(collected, g.desc, sample.Labels).value = sample.Value
+	}
+	writeSeries(w, g.desc, collected)
+}
+
+// Histogram counts observations, such as request durations, in cumulative buckets
+type Histogram struct {
+	desc
+	buckets []float64
+	series  map[string]*histogramSeries
+	mutex   sync.Mutex
+}
+
+// histogramSeries holds the bucket counts of one set of label values
+type histogramSeries struct {
+	labelValues []string
+	counts      []uint64
+	count       uint64
+	sum         float64
+}
+
+// NewHistogram registers a histogram with the given upper bucket bounds and label names;
+// DefaultBuckets are used when buckets is empty
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+	buckets = append([]float64(nil), buckets...)
+	sort.Float64s(buckets)
+
+	h := &Histogram{
+		desc:    desc{name: name, help: help, kind: "histogram", labels: labels},
+		buckets: buckets,
+		series:  make(map[string]*histogramSeries),
+	}
+	r.register(h.desc, h)
+	return h
+}
+
+// Observe records a value in the series with the given label values
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.checkLabels(labelValues)
+	key := seriesKey(labelValues)
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	s, exists := h.series[key]
+	if !exists {
+		s = &histogramSeries{labelValues: append([]string(nil), labelValues...), counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, value); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.count++
+	s.sum += value
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	writeHeader(w, h.desc)
+	for _, key := range sortedKeys(h.series) {
+		s := h.series[key]
+		cumulative := uint64(0)
+		for i, bound := range h.buckets {
+			cumulative += s.counts[i]
+			writeSample(w, h.name+"_bucket", h.labels, s.labelValues, "le", formatValue(bound), float64(cumulative))
+		}
+		writeSample(w, h.name+"_bucket", h.labels, s.labelValues, "le", "+Inf", float64(s.count))
+		writeSample(w, h.name+"_sum", h.labels, s.labelValues, "", "", s.sum)
+		writeSample(w, h.name+"_count", h.labels, s.labelValues, "", "", float64(s.count))
+	}
+}
+
+// series is one value of a counter or gauge
+type series struct {
+	labelValues []string
+	value       float64
+}
+
+// getSeries returns the series for label values, creating it if needed
+func getSeries(all map[string]*series, d desc, labelValues []string) *series {
+	d.checkLabels(labelValues)
+	key := seriesKey(labelValues)
+	s, exists := all[key]
+	if !exists {
+		s = &series{labelValues: append([]string(nil), labelValues...)}
+		all[key] = s
+	}
+	return s
+}
+
+// checkLabels panics when label values don't match the label names, a programming error
+func (d desc) checkLabels(labelValues []string) {
+	if len(labelValues) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s takes %d label values, got %d", d.name, len(d.labels), len(labelValues)))
+	}
+}
+
+// seriesKey joins label values with a separator that can't appear in valid UTF-8
+func seriesKey(labelValues []string) string {
+	return strings.Join(labelValues, "\xff")
+}
+
+// sortedKeys returns map keys in order, so output is stable between scrapes
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeSeries writes a counter or gauge family
+func writeSeries(w *bufio.Writer, d desc, all map[string]*series) {
+	writeHeader(w, d)
+	for _, key := range sortedKeys(all) {
+		writeSample(w, d.name, d.labels, all[key].labelValues, "", "", all[key].value)
+	}
+}
+
+// writeHeader writes the HELP and TYPE lines of a family
+func writeHeader(w *bufio.Writer, d desc) {
+	help := strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(d.help)
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, help, d.name, d.kind)
+}
+
+// writeSample writes one sample line, with an optional extra label such as a bucket's le
+func writeSample(w *bufio.Writer, name string, labels, labelValues []string, extraLabel, extraValue string, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraLabel != "" {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			writeLabel(w, label, labelValues[i])
+		}
+		if extraLabel != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			writeLabel(w, extraLabel, extraValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatValue(value))
+	w.WriteByte('\n')
+}
+
+// writeLabel writes a label pair, escaping the value
+func writeLabel(w *bufio.Writer, label, value string) {
+	w.WriteString(label)
+	w.WriteString(`="`)
+	w.WriteString(strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value))
+	w.WriteByte('"')
+}
+
+// formatValue formats a sample value, including infinities and NaN
+func formatValue(value float64) string {
+	switch {
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	case math.IsNaN(value):
+		return "NaN"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
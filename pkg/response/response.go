@@ -1,13 +1,15 @@
 package response
 
 import (
-	"log"
 	"net/http"
 
 	"github.com/cloudparallax/parallax/pkg/errors"
+	"github.com/cloudparallax/parallax/pkg/logging"
 	"github.com/gofiber/fiber/v3"
 )
 
+var logger = logging.Logger("response")
+
 // Response represents a standard API response
 type Response struct {
 	Success bool       `json:"success"`
@@ -72,7 +74,7 @@ func NoContent(c fiber.Ctx) error {
 // Error sends an error response
 func Error(c fiber.Ctx, err error) error {
 	// Log the error
-	log.Printf("API Error: %v", err)
+	logger.WarnContext(c.RequestCtx(), "API error", "error", err)
 
 	// Check if it's an AppError
 	if appErr, ok := errors.GetAppError(err); ok {
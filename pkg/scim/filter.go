@@ -0,0 +1,328 @@
+package scim
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Attributes holds a resource's attribute values keyed by lowercase attribute path,
+// such as "username" or "emails.value", for filter evaluation
+type Attributes map[string][]string
+
+// Add records values for an attribute path
+func (a Attributes) Add(path string, values ...string) {
+	key := strings.ToLower(path)
+	a[key] = append(a[key], values...)
+}
+
+// Filter is a parsed SCIM filter expression
+type Filter interface {
+	Matches(attributes Attributes) bool
+}
+
+// comparison compares an attribute against a value
+type comparison struct {
+	path  string
+	op    string
+	value string
+}
+
+// logical combines two filters with and/or
+type logical struct {
+	op          string
+	left, right Filter
+}
+
+// negation inverts a filter
+type negation struct {
+	filter Filter
+}
+
+// Matches evaluates the comparison; string comparisons are case-insensitive
+func (c comparison) Matches(attributes Attributes) bool {
+	values := attributes[c.path]
+	if c.op == "pr" {
+		for _, value := range values {
+			if value != "" {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, value := range values {
+		value = strings.ToLower(value)
+		var matched bool
+		switch c.op {
+		case "eq":
+			matched = value == c.value
+		case "ne":
+			matched = value != c.value
+		case "co":
+			matched = strings.Contains(value, c.value)
+		case "sw":
+			matched = strings.HasPrefix(value, c.value)
+		case "ew":
+			matched = strings.HasSuffix(value, c.value)
+		case "gt":
+			matched = value > c.value
+		case "ge":
+			matched = value >= c.value
+		case "lt":
+			matched = value < c.value
+		case "le":
+			matched = value <= c.value
+		}
+		if matched {
+			return true
+		}
+	}
+
+	// An absent attribute is "not equal" to any value
+	return c.op == "ne" && len(values) == 0
+}
+
+// Matches evaluates both sides
+func (l logical) Matches(attributes Attributes) bool {
+	if l.op == "and" {
+		return l.left.Matches(attributes) && l.right.Matches(attributes)
+	}
+	return l.left.Matches(attributes) || l.right.Matches(attributes)
+}
+
+// Matches inverts the inner filter
+func (n negation) Matches(attributes Attributes) bool {
+	return !n.filter.Matches(attributes)
+}
+
+// ParseFilter parses a filter expression as defined in RFC 7644 section 3.4.2.2.
+// Attribute paths, operators, and, or, not and parentheses are supported.
+func ParseFilter(expression string) (Filter, error) {
+	tokens, err := tokenize(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	filter, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos != len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in filter", p.tokens[p.pos].text)
+	}
+
+	return filter, nil
+}
+
+// token is a lexical element of a filter
+type token struct {
+	text   string
+	quoted bool
+}
+
+// tokenize splits a filter into words, quoted strings and parentheses
+func tokenize(expression string) ([]token, error) {
+	var tokens []token
+	runes := []rune(expression)
+
+	for i := 0; i < len(runes); {
+		switch r := runes[i]; {
+		case r == ' ' || r == '\t':
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, token{text: string(r)})
+			i++
+		case r == '"':
+			var builder strings.Builder
+			i++
+			for ; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				builder.WriteRune(runes[i])
+			}
+			if i >= len(runes) {
+				return nil, errors.New("unterminated string in filter")
+			}
+			tokens = append(tokens, token{text: builder.String(), quoted: true})
+			i++
+		default:
+			start := i
+			for i < len(runes) && runes[i] != ' ' && runes[i] != '\t' && runes[i] != '(' && runes[i] != ')' {
+				i++
+			}
+			tokens = append(tokens, token{text: string(runes[start:i])})
+		}
+	}
+
+	return tokens, nil
+}
+
+// parser is a recursive-descent parser over filter tokens
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// peekKeyword reports whether the next token is the given unquoted keyword
+func (p *parser) peekKeyword(keyword string) bool {
+	return p.pos < len(p.tokens) && !p.tokens[p.pos].quoted && strings.EqualFold(p.tokens[p.pos].text, keyword)
+}
+
+// parseOr parses expressions joined by or
+func (p *parser) parseOr() (Filter, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peekKeyword("or") {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = logical{op: "or", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+// parseAnd parses expressions joined by and, which binds tighter than or
+func (p *parser) parseAnd() (Filter, error) {
+	left, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peekKeyword("and") {
+		p.pos++
+		right, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		left = logical{op: "and", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+// parseTerm parses a negation, a parenthesized expression or a comparison
+func (p *parser) parseTerm() (Filter, error) {
+	if p.peekKeyword("not") {
+		p.pos++
+		filter, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+		return negation{filter: filter}, nil
+	}
+
+	if p.peekKeyword("(") {
+		p.pos++
+		filter, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.peekKeyword(")") {
+			return nil, errors.New("missing closing parenthesis in filter")
+		}
+		p.pos++
+		return filter, nil
+	}
+
+	if p.pos+1 >= len(p.tokens) {
+		return nil, errors.New("incomplete filter expression")
+	}
+
+	path := strings.ToLower(p.tokens[p.pos].text)
+	op := strings.ToLower(p.tokens[p.pos+1].text)
+	p.pos += 2
+
+	// Strip a schema URN prefix such as urn:...:User:userName
+	if i := strings.LastIndex(path, ":"); i >= 0 {
+		path = path[i+1:]
+	}
+
+	if op == "pr" {
+		return comparison{path: path, op: op}, nil
+	}
+
+	switch op {
+	case "eq", "ne", "co", "sw", "ew", "gt", "ge", "lt", "le":
+	default:
+		return nil, fmt.Errorf("unsupported filter operator %q", op)
+	}
+
+	if p.pos >= len(p.tokens) {
+		return nil, errors.New("missing filter value")
+	}
+	value := p.tokens[p.pos]
+	p.pos++
+
+	if !value.quoted && strings.EqualFold(value.text, "null") {
+		// "eq null" matches absent attributes, "ne null" present ones
+		if op == "eq" {
+			return negation{filter: comparison{path: path, op: "pr"}}, nil
+		}
+		return comparison{path: path, op: "pr"}, nil
+	}
+
+	return comparison{path: path, op: op, value: strings.ToLower(value.text)}, nil
+}
+
+// Path is a parsed PATCH operation path such as members[value eq "2819c223"].display
+type Path struct {
+	Attribute    string
+	Filter       Filter
+	SubAttribute string
+}
+
+// ParsePath parses a PATCH operation path
+func ParsePath(path string) (Path, error) {
+	path = strings.TrimSpace(path)
+	if path == "" {
+		return Path{}, nil
+	}
+
+	// Strip a schema URN prefix; a bracketed filter may itself contain colons
+	bracket := strings.Index(path, "[")
+	prefix := path
+	if bracket >= 0 {
+		prefix = path[:bracket]
+	}
+	if i := strings.LastIndex(prefix, ":"); i >= 0 {
+		path = path[i+1:]
+		if bracket >= 0 {
+			bracket -= i + 1
+		}
+	}
+
+	var parsed Path
+	if bracket >= 0 {
+		end := strings.LastIndex(path, "]")
+		if end < bracket {
+			return Path{}, errors.New("missing closing bracket in path")
+		}
+
+		filter, err := ParseFilter(path[bracket+1 : end])
+		if err != nil {
+			return Path{}, err
+		}
+
+		parsed.Attribute = strings.ToLower(path[:bracket])
+		parsed.Filter = filter
+		parsed.SubAttribute = strings.ToLower(strings.TrimPrefix(path[end+1:], "."))
+		return parsed, nil
+	}
+
+	parts := strings.SplitN(path, ".", 2)
+	parsed.Attribute = strings.ToLower(parts[0])
+	if len(parts) == 2 {
+		parsed.SubAttribute = strings.ToLower(parts[1])
+	}
+	return parsed, nil
+}
@@ -0,0 +1,29 @@
+package tracing
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v3"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// requestSpanKey is the Locals key of a request's server span
+type requestSpanKey struct{}
+
+// SetRequestSpan stores the server span of a request in its Locals
+func SetRequestSpan(c fiber.Ctx, span trace.Span) {
+	c.Locals(requestSpanKey{}, span)
+}
+
+// Context returns ctx with the server span of its request as the current span. Handlers pass
+// c.RequestCtx() on, which returns Locals as context values but is not an OpenTelemetry
+// context, so code starting spans or making traced calls with it goes through here first.
+func Context(ctx context.Context) context.Context {
+	if trace.SpanContextFromContext(ctx).IsValid() {
+		return ctx
+	}
+	if span, ok := ctx.Value(requestSpanKey{}).(trace.Span); ok {
+		return trace.ContextWithSpan(ctx, span)
+	}
+	return ctx
+}
@@ -1,33 +1,201 @@
 package app
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"log/slog"
+	"net/url"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/cloudparallax/parallax/internal/adapters/http"
+	"github.com/cloudparallax/parallax/internal/config"
+	"github.com/cloudparallax/parallax/pkg/health"
+	"github.com/cloudparallax/parallax/pkg/lifecycle"
+	"github.com/cloudparallax/parallax/pkg/logging"
+	"github.com/cloudparallax/parallax/pkg/version"
 	"github.com/gofiber/fiber/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
-// LoadApp initializes and starts the API server
-func LoadApp() {
-	port := GetEnv("SERVER_PORT", "8080")
-	log.Printf("Starting Parallax API server on port %s\n", port)
+var logger = logging.Logger("app")
+
+// LoadApp initializes and starts the API server with a validated configuration, and shuts it
+// down gracefully on SIGINT or SIGTERM
+func LoadApp(cfg *config.Config) {
+	setupLogging(cfg.Logging)
+	logger.Info("Configuration loaded", "sources", cfg.Sources())
+
+	// Background tasks stop, and spans are flushed, once the server has drained
+	background := lifecycle.NewManager()
+	background.OnShutdown("tracing", setupTracing(cfg.Tracing).Shutdown)
+
+	port := cfg.Server.Port
+	build := version.Get()
+	logger.Info("Starting Parallax API server", "port", port, "version", build.Version, "commit", build.Commit, "build_time", build.BuildTime)
 
 	// Create Fiber app with custom config
 	app := fiber.New(fiber.Config{
 		ErrorHandler: customErrorHandler,
-		AppName:      "Parallax API v1.0.0",
+		AppName:      "Parallax API " + version.Version,
 	})
 
 	// Setup routes (router handles all middleware setup)
-	router := http.NewRouter(app)
-	log.Println("Setting up routes...")
+	router := http.NewRouter(app, background, cfg)
+	logger.Debug("Setting up routes")
 	router.SetupRoutes()
-	log.Println("Routes setup complete")
+	logger.Debug("Routes setup complete")
+
+	// CORS policies, rate limits and logging are reloaded on SIGHUP and when the config file changes
+	reloader := newConfigReloader(cfg, router)
+	if err := reloader.handleSignals(background); err != nil {
+		logger.Error("Failed to handle reload signals", "error", err)
+	}
+	if cfg.File() != "" && cfg.Server.ConfigWatchInterval > 0 {
+		if err := reloader.watch(background, cfg.File(), cfg.Server.ConfigWatchInterval); err != nil {
+			logger.Error("Failed to watch the config file", "file", cfg.File(), "error", err)
+		}
+	}
+
+	// Only report ready once listening, and stop before shutting down so traffic drains
+	app.Hooks().OnListen(func(fiber.ListenData) error {
+		router.Health().SetState(health.StateReady)
+		return nil
+	})
+	app.Hooks().OnPreShutdown(func() error {
+		router.Health().SetState(health.StateStopping)
+		return nil
+	})
+
+	signals, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	listenErr := make(chan error, 1)
+	go func() {
+		listenErr <- app.Listen(fmt.Sprintf(":%d", port))
+	}()
+
+	select {
+	case err := <-listenErr:
+		logger.Error("Server stopped", "error", err)
+		shutdown(background, cfg.Server.ShutdownTimeout)
+		os.Exit(1)
+	case <-signals.Done():
+	}
+
+	// A second signal stops the process at once
+	stop()
+
+	// Fail readiness first, so load balancers stop sending traffic before the listener closes
+	drainDelay := cfg.Server.ShutdownDrainDelay
+	timeout := cfg.Server.ShutdownTimeout
+	logger.Info("Shutting down", "drain_delay", drainDelay.String(), "timeout", timeout.String())
+	router.Health().SetState(health.StateStopping)
+	time.Sleep(drainDelay)
+
+	// In-flight requests finish; connections still open at the timeout are closed
+	if err := app.ShutdownWithTimeout(timeout); err != nil {
+		logger.Warn("Server did not drain in time, closing open connections", "error", err)
+	}
+	<-listenErr
+
+	shutdown(background, timeout)
+	logger.Info("Server stopped")
+}
+
+// shutdown stops background tasks and runs shutdown hooks within the timeout
+func shutdown(background *lifecycle.Manager, timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := background.Shutdown(ctx); err != nil {
+		logger.Warn("Background shutdown incomplete", "error", err)
+	}
+}
+
+// setupLogging configures structured logging; the settings have been validated, so levels parse
+func setupLogging(settings config.Logging) {
+	cfg := logging.Config{
+		Level:       slog.LevelInfo,
+		Format:      settings.Format,
+		RedactKeys:  append(append([]string{}, logging.DefaultRedactKeys...), settings.RedactKeys...),
+		ContextKeys: []string{"request_id", "trace_id", "tenant_id", "user_id"},
+		Levels:      make(map[string]slog.Level, len(settings.Levels)),
+	}
+	if level, err := logging.ParseLevel(settings.Level); err == nil {
+		cfg.Level = level
+	}
+	for pkg, name := range settings.Levels {
+		if level, err := logging.ParseLevel(name); err == nil {
+			cfg.Levels[strings.ToLower(strings.TrimSpace(pkg))] = level
+		}
+	}
+
+	if err := logging.Setup(cfg); err != nil {
+		cfg.Format = "json"
+		logging.Setup(cfg)
+		logger.Warn("Invalid logging configuration, using the default", "error", err)
+	}
+}
+
+// setupTracing installs the OpenTelemetry tracer provider with the configured span exporter:
+// none, otlp or stdout. Trace context is propagated even when spans are not exported.
+func setupTracing(settings config.Tracing) *sdktrace.TracerProvider {
+	service, _ := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", settings.ServiceName),
+		attribute.String("service.version", version.Version),
+	))
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(service),
+		// Traces started by a caller follow its sampling decision
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(settings.SampleRatio))),
+	}
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch name := strings.ToLower(settings.Exporter); name {
+	case "none", "":
+	case "otlp":
+		exporter, err = otlptracehttp.New(context.Background(),
+			otlptracehttp.WithEndpointURL(strings.TrimSuffix(settings.OTLPEndpoint, "/")+"/v1/traces"),
+			otlptracehttp.WithHeaders(decodeHeaders(settings.OTLPHeaders)),
+		)
+	case "stdout", "console":
+		exporter, err = stdouttrace.New()
+	default:
+		logger.Warn("Unknown trace exporter, spans will not be exported", "exporter", name)
+	}
+	if err != nil {
+		logger.Warn("Invalid trace exporter configuration, spans will not be exported", "exporter", settings.Exporter, "error", err)
+	} else if exporter != nil {
+		options = append(options, sdktrace.WithBatcher(exporter))
+	}
+
+	provider := sdktrace.NewTracerProvider(options...)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider
+}
 
-	fmt.Printf("🚀 Starting Parallax API server at :%s\n", port)
-	log.Fatal(app.Listen(fmt.Sprintf(":%s", port)))
+// decodeHeaders decodes percent-encoded header values, as in OTEL_EXPORTER_OTLP_HEADERS, so
+// they can hold commas
+func decodeHeaders(encoded map[string]string) map[string]string {
+	headers := make(map[string]string, len(encoded))
+	for name, value := range encoded {
+		if decoded, err := url.QueryUnescape(value); err == nil {
+			value = decoded
+		}
+		headers[name] = value
+	}
+	return headers
 }
 
 // customErrorHandler handles errors in a consistent way
@@ -38,7 +206,11 @@ func customErrorHandler(ctx fiber.Ctx, err error) error {
 		code = e.Code
 	}
 
-	log.Printf("Error occurred: %s (code: %d)\n", err.Error(), code)
+	level := slog.LevelWarn
+	if code >= fiber.StatusInternalServerError {
+		level = slog.LevelError
+	}
+	logger.Log(ctx.RequestCtx(), level, "Error occurred", "code", code, "error", err)
 	return ctx.Status(code).JSON(fiber.Map{
 		"success": false,
 		"error": fiber.Map{
@@ -47,13 +219,3 @@ func customErrorHandler(ctx fiber.Ctx, err error) error {
 		},
 	})
 }
-
-// GetEnv gets environment variable with fallback
-func GetEnv(key, fallback string) string {
-	if value, exists := os.LookupEnv(key); exists {
-		log.Printf("Environment variable %s is set to %s\n", key, value)
-		return value
-	}
-	log.Printf("Environment variable %s is not set, using fallback %s\n", key, fallback)
-	return fallback
-}
\ No newline at end of file
@@ -0,0 +1,95 @@
+package app
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/cloudparallax/parallax/internal/adapters/http"
+	"github.com/cloudparallax/parallax/internal/config"
+	"github.com/cloudparallax/parallax/pkg/lifecycle"
+)
+
+// configReloader applies configuration changes to the running server: CORS policies, rate
+// limits and logging. Other changed settings are reported as needing a restart.
+type configReloader struct {
+	mutex   sync.Mutex
+	current *config.Config
+	router  *http.Router
+}
+
+// newConfigReloader creates a reloader for the configuration the server started with
+func newConfigReloader(cfg *config.Config, router *http.Router) *configReloader {
+	return &configReloader{current: cfg, router: router}
+}
+
+// reload loads the configuration again and applies it; an invalid configuration is rejected
+// and the current one kept
+func (r *configReloader) reload(trigger string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	next, err := r.current.Reload()
+	if err != nil {
+		logger.Error("Configuration reload rejected, keeping the current configuration", "trigger", trigger, "error", err)
+		return
+	}
+
+	merged, changes := r.current.Merge(next)
+	if changes.Empty() {
+		logger.Info("Configuration unchanged", "trigger", trigger)
+		return
+	}
+
+	if len(changes.Reloaded) > 0 {
+		if err := r.router.Reload(merged); err != nil {
+			logger.Error("Configuration reload rejected, keeping the current configuration", "trigger", trigger, "error", err)
+			return
+		}
+		setupLogging(merged.Logging)
+		logger.Info("Configuration reloaded", "trigger", trigger, "settings", changes.Reloaded)
+	}
+	if len(changes.Restart) > 0 {
+		logger.Warn("Changed settings need a restart to take effect", "trigger", trigger, "settings", changes.Restart)
+	}
+	r.current = merged
+}
+
+// handleSignals reloads the configuration on SIGHUP until shutdown
+func (r *configReloader) handleSignals(background *lifecycle.Manager) error {
+	hangups := make(chan os.Signal, 1)
+	signal.Notify(hangups, syscall.SIGHUP)
+
+	err := background.Go("config_reload_signal", func(ctx context.Context) {
+		defer signal.Stop(hangups)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hangups:
+				r.reload("signal")
+			}
+		}
+	})
+	if err != nil {
+		signal.Stop(hangups)
+	}
+	return err
+}
+
+// watch reloads the configuration when its file's size or modification time changes, checking
+// at each interval. A file that is briefly missing, as when an editor replaces it, is skipped.
+func (r *configReloader) watch(background *lifecycle.Manager, path string, interval time.Duration) error {
+	last, _ := os.Stat(path)
+	return background.Every("config_watch", interval, func(ctx context.Context) {
+		info, err := os.Stat(path)
+		if err != nil || (last != nil && info.Size() == last.Size() && info.ModTime().Equal(last.ModTime())) {
+			return
+		}
+		last = info
+		r.reload("file")
+	})
+}